@@ -0,0 +1,176 @@
+package logger_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+)
+
+// blockingWriter blocks each write until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+	out     testutils.SyncBuffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.out.Write(p)
+}
+
+// slowWriter takes a millisecond for each write.
+type slowWriter struct {
+	out *testutils.SyncBuffer
+}
+
+func (w slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return w.out.Write(p)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(_ []byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestAsyncWriter(t *testing.T) {
+	t.Run("writes lines after flush", func(t *testing.T) {
+		var out testutils.SyncBuffer
+		w := log.NewAsyncWriter(&out, nil)
+		defer w.Close()
+
+		for _, line := range []string{"one\n", "two\n", "three\n"} {
+			_, err := w.Write([]byte(line))
+			testutils.AssertNil(t, err)
+		}
+		testutils.AssertNil(t, w.Flush())
+		testutils.AssertEqual(t, "one\ntwo\nthree\n", out.String())
+	})
+
+	t.Run("drops lines when the queue is full", func(t *testing.T) {
+		bw := &blockingWriter{release: make(chan struct{})}
+		w := log.NewAsyncWriter(bw, &log.AsyncConfig{QueueSize: 2})
+
+		// The first line may be taken from the queue by the writing
+		// goroutine, so at least 7 of 10 lines are dropped.
+		for i := 0; i < 10; i++ {
+			n, err := w.Write([]byte("line\n"))
+			testutils.AssertNil(t, err)
+			testutils.AssertEqual(t, 5, n)
+		}
+		testutils.AssertTrue(t, w.Dropped() >= 7)
+
+		close(bw.release)
+		testutils.AssertNil(t, w.Close())
+		testutils.AssertEqual(t, 10-int(w.Dropped()), strings.Count(bw.out.String(), "line\n"))
+	})
+
+	t.Run("blocks when the queue is full", func(t *testing.T) {
+		bw := &blockingWriter{release: make(chan struct{})}
+		w := log.NewAsyncWriter(bw, &log.AsyncConfig{QueueSize: 1, Overflow: log.BlockOnOverflow})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 5; i++ {
+				_, _ = w.Write([]byte("line\n"))
+			}
+		}()
+		close(bw.release)
+		<-done
+
+		testutils.AssertNil(t, w.Close())
+		testutils.AssertEqual(t, uint64(0), w.Dropped())
+		testutils.AssertEqual(t, 5, strings.Count(bw.out.String(), "line\n"))
+	})
+
+	t.Run("flush returns while others keep writing", func(t *testing.T) {
+		var out testutils.SyncBuffer
+		w := log.NewAsyncWriter(slowWriter{&out}, &log.AsyncConfig{QueueSize: 4, Overflow: log.BlockOnOverflow})
+		_, err := w.Write([]byte("first\n"))
+		testutils.AssertNil(t, err)
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						_, _ = w.Write([]byte("line\n"))
+					}
+				}
+			}()
+		}
+
+		flushed := make(chan error)
+		go func() { flushed <- w.Flush() }()
+		select {
+		case err := <-flushed:
+			testutils.AssertNil(t, err)
+			testutils.AssertTrue(t, strings.HasPrefix(out.String(), "first\n"))
+		case <-time.After(5 * time.Second):
+			t.Error("Flush did not return")
+		}
+
+		close(stop)
+		wg.Wait()
+		testutils.AssertNil(t, w.Close())
+	})
+
+	t.Run("reports write errors on flush", func(t *testing.T) {
+		w := log.NewAsyncWriter(failingWriter{}, nil)
+		_, err := w.Write([]byte("line\n"))
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, "write failed", w.Flush().Error())
+		testutils.AssertNil(t, w.Flush())
+		testutils.AssertNil(t, w.Close())
+	})
+
+	t.Run("writes every line queued before close", func(t *testing.T) {
+		for _, overflow := range []log.OverflowPolicy{log.DropOnOverflow, log.BlockOnOverflow} {
+			var out testutils.SyncBuffer
+			w := log.NewAsyncWriter(&out, &log.AsyncConfig{Overflow: overflow})
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			written := 0
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						if _, err := w.Write([]byte("line\n")); err != nil {
+							return
+						}
+						mu.Lock()
+						written++
+						mu.Unlock()
+					}
+				}()
+			}
+			time.Sleep(5 * time.Millisecond)
+			testutils.AssertNil(t, w.Close())
+			wg.Wait()
+
+			testutils.AssertEqual(t, written-int(w.Dropped()), strings.Count(out.String(), "\n"))
+		}
+	})
+
+	t.Run("fails writes after close", func(t *testing.T) {
+		var out testutils.SyncBuffer
+		w := log.NewAsyncWriter(&out, nil)
+		testutils.AssertNil(t, w.Close())
+		testutils.AssertNil(t, w.Close())
+
+		_, err := w.Write([]byte("line\n"))
+		testutils.AssertNotNil(t, err)
+	})
+}
@@ -0,0 +1,241 @@
+// Package config reads a log.Config from a YAML, JSON or TOML file, see
+// Load. It is a separate module so that the log package, and the
+// drivers, do not depend on the YAML and TOML decoders:
+//
+//	logger, err := config.Open("zerolog", "/etc/agent/log.yaml")
+//	if err != nil {
+//		// ...
+//	}
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/secureworks/logger/log"
+)
+
+// fileConfig is the structure of the files read by Load.
+type fileConfig struct {
+	Level          string                 `json:"level" yaml:"level" toml:"level"`
+	LevelOverrides map[string]string      `json:"level_overrides" yaml:"level_overrides" toml:"level_overrides"`
+	Format         string                 `json:"format" yaml:"format" toml:"format"`
+	LocalDevel     bool                   `json:"local_devel" yaml:"local_devel" toml:"local_devel"`
+	ErrorStack     bool                   `json:"error_stack" yaml:"error_stack" toml:"error_stack"`
+	FieldNames     fieldNamesConfig       `json:"field_names" yaml:"field_names" toml:"field_names"`
+	LevelCase      string                 `json:"level_case" yaml:"level_case" toml:"level_case"`
+	TimeFormat     string                 `json:"time_format" yaml:"time_format" toml:"time_format"`
+	UTC            bool                   `json:"utc" yaml:"utc" toml:"utc"`
+	Schema         string                 `json:"schema" yaml:"schema" toml:"schema"`
+	Output         string                 `json:"output" yaml:"output" toml:"output"`
+	File           fileOutputConfig       `json:"file" yaml:"file" toml:"file"`
+	StaticFields   map[string]interface{} `json:"static_fields" yaml:"static_fields" toml:"static_fields"`
+	Resource       map[string]interface{} `json:"resource" yaml:"resource" toml:"resource"`
+}
+
+type fieldNamesConfig struct {
+	Message string `json:"message" yaml:"message" toml:"message"`
+	Level   string `json:"level" yaml:"level" toml:"level"`
+	Time    string `json:"time" yaml:"time" toml:"time"`
+	Error   string `json:"error" yaml:"error" toml:"error"`
+	Caller  string `json:"caller" yaml:"caller" toml:"caller"`
+	Stack   string `json:"stack" yaml:"stack" toml:"stack"`
+}
+
+type fileOutputConfig struct {
+	Path       string `json:"path" yaml:"path" toml:"path"`
+	MaxSize    int64  `json:"max_size" yaml:"max_size" toml:"max_size"` // In megabytes.
+	Interval   string `json:"interval" yaml:"interval" toml:"interval"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" toml:"max_backups"`
+	Compress   bool   `json:"compress" yaml:"compress" toml:"compress"`
+}
+
+// Load reads a log.Config from the YAML (".yaml" or ".yml"), JSON
+// (".json") or TOML (".toml") file at path, eg:
+//
+//	level: INFO
+//	level_overrides:
+//	  db: DEBUG
+//	format: logfmt       # json, logfmt, console or default.
+//	local_devel: false
+//	error_stack: true
+//	field_names:         # See log.FieldNames.
+//	  message: msg
+//	  time: ts
+//	level_case: upper    # lower (the default) or upper.
+//	time_format: unixms  # none, unix, unixmicro, unixnano or a layout.
+//	utc: true
+//	schema: ecs          # ecs or gcp, see log.Schema.
+//	output: file         # stderr (the default), stdout or file.
+//	file:                # Used by the file output, see log.FileConfig.
+//	  path: /var/log/agent.log
+//	  max_size: 100      # In megabytes.
+//	  interval: 24h
+//	  max_backups: 7
+//	  compress: true
+//	static_fields:
+//	  service: agent
+//	resource:
+//	  service.name: agent
+//
+// Env vars take precedence over the file: each log.EnvKey that is set
+// replaces the setting read from the file, except that the overrides in
+// log.LogLevel, the static fields set by env vars such as
+// log.ServiceName and the attributes in log.OTelResourceAttributes are
+// merged into those read from the file, and that log.LogFile sets the
+// output to that file. Settings in neither keep the values of
+// log.DefaultConfig.
+//
+// Load returns an error describing any unknown field or invalid value
+// in the file or env, and validates the result with
+// log.Config.Validate. Files written to are shared by Loggers as they
+// are for log.LogFile, see log.OpenSharedFile.
+func Load(path string) (*log.Config, error) {
+	fc, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &log.Config{
+		LocalDevel:     fc.LocalDevel,
+		EnableErrStack: fc.ErrorStack,
+		FieldNames:     log.FieldNames(fc.FieldNames),
+		TimeFormat:     fc.TimeFormat,
+		UTC:            fc.UTC,
+		StaticFields:   fc.StaticFields,
+		Resource:       fc.Resource,
+	}
+	if fc.Level != "" {
+		if config.Level, config.LevelOverrides, err = log.ParseLevels(fc.Level); err != nil {
+			return nil, fmt.Errorf("config: Invalid level in %s: %w", path, err)
+		}
+	}
+	for name, str := range fc.LevelOverrides {
+		lvl, err := log.ParseLevel(str)
+		if err != nil {
+			return nil, fmt.Errorf("config: Invalid level override for %s in %s: %w", name, path, err)
+		}
+		if config.LevelOverrides == nil {
+			config.LevelOverrides = make(log.LevelOverrides)
+		}
+		config.LevelOverrides[name] = lvl
+	}
+	if fc.Format != "" {
+		if config.Format, err = log.ParseFormat(fc.Format); err != nil {
+			return nil, fmt.Errorf("config: Invalid format in %s: %w", path, err)
+		}
+	}
+	if fc.LevelCase != "" {
+		if config.LevelCase, err = log.ParseLevelCase(fc.LevelCase); err != nil {
+			return nil, fmt.Errorf("config: Invalid level case in %s: %w", path, err)
+		}
+	}
+	if fc.Schema != "" {
+		if config.Schema, err = log.ParseSchema(fc.Schema); err != nil {
+			return nil, fmt.Errorf("config: Invalid schema in %s: %w", path, err)
+		}
+	}
+
+	file := log.FileConfig{
+		Path:           fc.File.Path,
+		MaxSize:        fc.File.MaxSize << 20,
+		MaxBackups:     fc.File.MaxBackups,
+		Compress:       fc.File.Compress,
+		ReopenOnSIGHUP: true,
+	}
+	if fc.File.Interval != "" {
+		if file.Interval, err = time.ParseDuration(fc.File.Interval); err != nil {
+			return nil, fmt.Errorf("config: Invalid file interval in %s: %w", path, err)
+		}
+	}
+
+	output := strings.ToLower(fc.Output)
+	switch output {
+	case "", "stderr", "stdout", "file":
+	default:
+		return nil, fmt.Errorf("config: Invalid output in %s: unknown output %q, want stderr, stdout or file", path, fc.Output)
+	}
+	if err := config.LoadEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+	if err := file.LoadEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+	if os.Getenv(log.LogFile.String()) != "" {
+		output = "file"
+	}
+
+	// The config is validated before the file output is opened, so that
+	// no file is left open if it is invalid.
+	config.Output = os.Stderr
+	if output == "stdout" {
+		config.Output = os.Stdout
+	}
+	if output == "file" && file.Path == "" {
+		return nil, fmt.Errorf("config: Invalid output in %s: missing file path", path)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if output == "file" {
+		if config.Output, err = log.OpenSharedFile(file); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// Open returns a new instance of the Logger registered as name, as
+// log.Open does, with the Config read by Load from the file at path,
+// and options.
+func Open(name, path string, opts ...log.Option) (log.Logger, error) {
+	config, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return log.Open(name, config, opts...)
+}
+
+// Decodes the file at path using the format given by its extension.
+func readConfigFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: Failed to read config: %w", err)
+	}
+
+	fc := new(fileConfig)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(fc); errors.Is(err, io.EOF) {
+			err = nil // Empty file.
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(fc)
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.Decode(string(data), fc)
+		if undecoded := md.Undecoded(); err == nil && len(undecoded) > 0 {
+			err = fmt.Errorf("unknown field %q", undecoded[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("config: Unsupported config file type (%s), want .yaml, .yml, .json or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: Failed to decode %s: %w", path, err)
+	}
+	return fc, nil
+}
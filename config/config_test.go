@@ -0,0 +1,207 @@
+package config_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/secureworks/logger/config"
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	"github.com/secureworks/logger/testlogger"
+)
+
+var defaultConfig = &log.Config{
+	Level:          log.INFO,
+	LocalDevel:     false,
+	Format:         log.JSONFormat,
+	EnableErrStack: false,
+	Output:         os.Stderr,
+}
+
+func TestLoad(t *testing.T) {
+	writeConfig := func(t *testing.T, name, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), name)
+		testutils.AssertNil(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	want := &log.Config{
+		Level:          log.WARN,
+		LevelOverrides: log.LevelOverrides{"db": log.DEBUG, "http": log.TRACE},
+		Format:         log.LogfmtFormat,
+		EnableErrStack: true,
+		FieldNames:     log.FieldNames{Message: "msg", Time: "ts"},
+		LevelCase:      log.UpperCaseLevels,
+		Schema:         log.ECSSchema(),
+		Output:         os.Stdout,
+		Resource:       map[string]interface{}{"service.name": "agent"},
+	}
+
+	for name, content := range map[string]string{
+		"config.yaml": `
+level: WARN,db=DEBUG
+level_overrides:
+  http: trace
+format: logfmt
+error_stack: true
+field_names:
+  message: msg
+  time: ts
+level_case: upper
+schema: ecs
+output: stdout
+resource:
+  service.name: agent
+`,
+		"config.json": `{
+  "level": "WARN,db=DEBUG",
+  "level_overrides": {"http": "trace"},
+  "format": "logfmt",
+  "error_stack": true,
+  "field_names": {"message": "msg", "time": "ts"},
+  "level_case": "upper",
+  "schema": "ecs",
+  "output": "stdout",
+  "resource": {"service.name": "agent"}
+}`,
+		"config.toml": `
+level = "WARN,db=DEBUG"
+format = "logfmt"
+error_stack = true
+level_case = "upper"
+schema = "ecs"
+output = "stdout"
+
+[level_overrides]
+http = "trace"
+
+[field_names]
+message = "msg"
+time = "ts"
+
+[resource]
+"service.name" = "agent"
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := config.Load(writeConfig(t, name, content))
+			testutils.AssertNil(t, err)
+			testutils.AssertEqual(t, want, cfg)
+		})
+	}
+
+	t.Run("with an empty file", func(t *testing.T) {
+		cfg, err := config.Load(writeConfig(t, "config.yml", ""))
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, defaultConfig, cfg)
+	})
+
+	t.Run("with file output", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "agent.log")
+		cfg, err := config.Load(writeConfig(t, "config.yaml", "output: file\nfile:\n  path: "+path+"\n"))
+		testutils.AssertNil(t, err)
+		w, ok := cfg.Output.(*log.FileWriter)
+		testutils.AssertTrue(t, ok)
+		defer w.Close()
+
+		_, err = io.WriteString(w, "written\n")
+		testutils.AssertNil(t, err)
+		got, err := os.ReadFile(path)
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, "written\n", string(got))
+	})
+
+	t.Run("env takes precedence", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "agent.log")
+		t.Setenv(log.LogLevel.String(), "ERROR,db=INFO")
+		t.Setenv(log.Format.String(), "json")
+		t.Setenv(log.EnableErrStack.String(), "false")
+		t.Setenv(log.LocalDevel.String(), "1")
+		t.Setenv(log.OTelResourceAttributes.String(), "team=a")
+		t.Setenv(log.LogFile.String(), path)
+		t.Setenv(log.LogSchema.String(), "gcp")
+
+		cfg, err := config.Load(writeConfig(t, "config.json", `{
+  "level": "WARN,db=DEBUG",
+  "level_overrides": {"http": "trace"},
+  "format": "logfmt",
+  "error_stack": true,
+  "output": "stdout",
+  "resource": {"service.name": "agent"}
+}`))
+		testutils.AssertNil(t, err)
+		defer cfg.Output.(io.Closer).Close()
+
+		testutils.AssertEqual(t, log.ERROR, cfg.Level)
+		testutils.AssertEqual(t, log.LevelOverrides{"db": log.INFO, "http": log.TRACE}, cfg.LevelOverrides)
+		testutils.AssertEqual(t, log.JSONFormat, cfg.Format)
+		testutils.AssertFalse(t, cfg.EnableErrStack)
+		testutils.AssertTrue(t, cfg.LocalDevel)
+		testutils.AssertEqual(t, map[string]interface{}{"service.name": "agent", "team": "a"}, cfg.Resource)
+		testutils.AssertEqual(t, log.GCPSchema(), cfg.Schema)
+		_, ok := cfg.Output.(*log.FileWriter)
+		testutils.AssertTrue(t, ok)
+	})
+
+	t.Run("with errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			name, content, want string
+		}{
+			{"config.yaml", "level: VERBOSE", `config: Invalid level in %s: unknown level "VERBOSE", want TRACE, DEBUG, INFO, WARN, ERROR, PANIC or FATAL`},
+			{"config.yaml", "level: INFO,=DEBUG", `config: Invalid level in %s: missing name in override "=DEBUG"`},
+			{"config.yaml", "level_overrides: {db: LOUD}", `config: Invalid level override for db in %s: unknown level "LOUD", want TRACE, DEBUG, INFO, WARN, ERROR, PANIC or FATAL`},
+			{"config.json", `{"format": "xml"}`, `config: Invalid format in %s: unknown format "xml", want json, logfmt, console or default`},
+			{"config.yaml", "level_case: title", `config: Invalid level case in %s: unknown level case "title", want lower or upper`},
+			{"config.yaml", "schema: splunk", `config: Invalid schema in %s: unknown schema "splunk", want ecs or gcp`},
+			{"config.toml", `output = "syslog"`, `config: Invalid output in %s: unknown output "syslog", want stderr, stdout or file`},
+			{"config.toml", `output = "file"`, `config: Invalid output in %s: missing file path`},
+			{"config.toml", "[file]\ninterval = \"daily\"", `config: Invalid file interval in %s: time: invalid duration "daily"`},
+			{"config.yaml", "levels: INFO", "config: Failed to decode %s: yaml: unmarshal errors:\n  line 1: field levels not found in type config.fileConfig"},
+			{"config.json", `{"levels": "INFO"}`, `config: Failed to decode %s: json: unknown field "levels"`},
+			{"config.toml", `levels = "INFO"`, `config: Failed to decode %s: unknown field "levels"`},
+		} {
+			path := writeConfig(t, tc.name, tc.content)
+			_, err := config.Load(path)
+			testutils.AssertNotNil(t, err)
+			testutils.AssertEqual(t, fmt.Sprintf(tc.want, path), err.Error())
+		}
+
+		// The file output is not opened for invalid configs.
+		logPath := filepath.Join(t.TempDir(), "agent.log")
+		_, err := config.Load(writeConfig(t, "config.yaml", "level_overrides: {\"\": DEBUG}\noutput: file\nfile:\n  path: "+logPath+"\n"))
+		testutils.AssertEqual(t, "log: Invalid level override: missing name", err.Error())
+		_, err = os.Stat(logPath)
+		testutils.AssertTrue(t, errors.Is(err, os.ErrNotExist))
+
+		_, err = config.Load(writeConfig(t, "config.ini", ""))
+		testutils.AssertEqual(t, "config: Unsupported config file type (.ini), want .yaml, .yml, .json or .toml", err.Error())
+
+		_, err = config.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		testutils.AssertTrue(t, errors.Is(err, os.ErrNotExist))
+
+		t.Setenv(log.LogLevel.String(), "LOUD")
+		_, err = config.Load(writeConfig(t, "config.yaml", ""))
+		testutils.AssertEqual(t, `log: Invalid LOG_LEVEL (LOUD): unknown level "LOUD", want TRACE, DEBUG, INFO, WARN, ERROR, PANIC or FATAL`, err.Error())
+
+		t.Setenv(log.LogLevel.String(), "")
+		t.Setenv(log.LogSchema.String(), "splunk")
+		_, err = config.Load(writeConfig(t, "config.yaml", ""))
+		testutils.AssertEqual(t, `log: Invalid LOG_SCHEMA (splunk): unknown schema "splunk", want ecs or gcp`, err.Error())
+	})
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	testutils.AssertNil(t, os.WriteFile(path, []byte("level: DEBUG\n"), 0o600))
+
+	logger, err := config.Open("test", path)
+	testutils.AssertNil(t, err)
+	testutils.AssertEqual(t, log.DEBUG, logger.(*testlogger.Logger).Config.Level)
+
+	_, err = config.Open("test", filepath.Join(filepath.Dir(path), "missing.yaml"))
+	testutils.AssertNotNil(t, err)
+}
@@ -0,0 +1,11 @@
+module github.com/secureworks/logger/config
+
+go 1.18
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/secureworks/logger/internal v1.2.1
+	github.com/secureworks/logger/log v1.2.0
+	github.com/secureworks/logger/testlogger v1.2.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -24,6 +24,7 @@ var loadedConfig = &log.Config{
 	Format:         log.ImplementationDefaultFormat,
 	EnableErrStack: true,
 	Output:         os.Stderr,
+	StaticFields:   map[string]interface{}{log.EnvironmentField: "prod"},
 }
 
 func TestDefaultConfig(t *testing.T) {
@@ -45,6 +46,135 @@ func TestDefaultConfig(t *testing.T) {
 		testutils.AssertEqual(t, loadedConfig, config)
 	})
 
+	t.Run("with named format", func(t *testing.T) {
+		for name, format := range map[string]log.LoggerFormat{
+			"json":    log.JSONFormat,
+			"LOGFMT":  log.LogfmtFormat,
+			"1":       log.LogfmtFormat,
+			"console": log.ConsoleFormat,
+			"default": log.ImplementationDefaultFormat,
+			"unknown": log.JSONFormat,
+			"42":      log.JSONFormat,
+		} {
+			config := log.DefaultConfig(func(varname string) string {
+				if varname == log.Format.String() {
+					return name
+				}
+				return ""
+			})
+			testutils.AssertEqual(t, format, config.Format)
+		}
+	})
+
+	t.Run("with level overrides", func(t *testing.T) {
+		config := log.DefaultConfig(func(varname string) string {
+			if varname == log.LogLevel.String() {
+				return "WARN, db=DEBUG,http.client=trace"
+			}
+			return ""
+		})
+		testutils.AssertEqual(t, log.WARN, config.Level)
+		testutils.AssertEqual(t, log.LevelOverrides{
+			"db":          log.DEBUG,
+			"http.client": log.TRACE,
+		}, config.LevelOverrides)
+
+		for name, want := range map[string]log.Level{
+			"db":               log.DEBUG,
+			"db.pool":          log.DEBUG,
+			"http":             log.WARN,
+			"http.client":      log.TRACE,
+			"http.client.pool": log.TRACE,
+			"dbx":              log.WARN,
+		} {
+			lvl := config.Level
+			if _, ovr, ok := config.LevelOverrides.Match(name); ok {
+				lvl = ovr
+			}
+			testutils.AssertEqual(t, want, lvl)
+		}
+	})
+
+	t.Run("with resource attributes", func(t *testing.T) {
+		fakeenv := map[string]string{
+			log.OTelResourceAttributes.String(): "service.name=ignored, deployment.environment=prod,team=a%2Cb,invalid",
+			log.OTelServiceName.String():        "agent",
+		}
+
+		config := log.DefaultConfig(func(varname string) string { return fakeenv[varname] })
+		testutils.AssertEqual(t, map[string]interface{}{
+			"service.name":           "agent",
+			"deployment.environment": "prod",
+			"team":                   "a,b",
+		}, config.Resource)
+	})
+
+	t.Run("with static fields", func(t *testing.T) {
+		fakeenv := map[string]string{
+			log.Environment.String():      "prod",
+			log.ServiceName.String():      "agent",
+			log.ServiceVersion.String():   "1.0",
+			log.LogProcessFields.String(): "true",
+		}
+		host, err := os.Hostname()
+		testutils.AssertNil(t, err)
+
+		config := log.DefaultConfig(func(varname string) string { return fakeenv[varname] })
+		testutils.AssertEqual(t, map[string]interface{}{
+			log.EnvironmentField: "prod",
+			log.ServiceField:     "agent",
+			log.VersionField:     "1.0",
+			log.HostField:        host,
+			log.PIDField:         os.Getpid(),
+		}, config.StaticFields)
+
+		logger := testlogger.MustNew(config)
+		logger.With(map[string]interface{}{log.VersionField: "2.0"}).Info().Msg("static")
+		entry := logger.GetEntries()[0]
+		testutils.AssertEqual(t, "agent", entry.StringField(log.ServiceField))
+		testutils.AssertEqual(t, "2.0", entry.StringField(log.VersionField))
+	})
+
+	t.Run("with invalid values", func(t *testing.T) {
+		fakeenv := map[string]string{
+			log.LogLevel.String():               "DEBUG",
+			log.Format.String():                 "xml",
+			log.LogSchema.String():              "splunk",
+			log.EnableErrStack.String():         "yes",
+			log.OTelResourceAttributes.String(): "team=a,invalid",
+		}
+
+		config := log.DefaultConfig(func(varname string) string { return fakeenv[varname] })
+		testutils.AssertEqual(t, log.DEBUG, config.Level)
+		testutils.AssertEqual(t, log.JSONFormat, config.Format)
+		testutils.AssertNil(t, config.Schema)
+		testutils.AssertEqual(t, map[string]interface{}{"team": "a"}, config.Resource)
+		testutils.AssertEqual(t, `log: Invalid ERROR_STACK (yes): strconv.ParseBool: parsing "yes": invalid syntax`, config.Validate().Error())
+
+		for key, want := range map[log.EnvKey]string{
+			log.LogLevel:               `log: Invalid LOG_LEVEL (INFO,=DEBUG): missing name in override "=DEBUG"`,
+			log.Format:                 `log: Invalid LOG_FORMAT (xml): unknown format "xml", want json, logfmt, console or default`,
+			log.LogSchema:              `log: Invalid LOG_SCHEMA (splunk): unknown schema "splunk", want ecs or gcp`,
+			log.OTelResourceAttributes: `log: Invalid OTEL_RESOURCE_ATTRIBUTES (team=a,invalid): invalid attribute "invalid", want key=value`,
+			log.LogFileMaxSize:         `log: Invalid LOG_FILE_MAX_SIZE (big): strconv.ParseInt: parsing "big": invalid syntax`,
+		} {
+			str := fakeenv[key.String()]
+			switch key {
+			case log.LogLevel:
+				str = "INFO,=DEBUG"
+			case log.LogFileMaxSize:
+				str = "big"
+			}
+			config := log.DefaultConfig(func(varname string) string {
+				if varname == key.String() {
+					return str
+				}
+				return ""
+			})
+			testutils.AssertEqual(t, want, config.Validate().Error())
+		}
+	})
+
 	t.Run("with Sentry config but missing Sentry DSN", func(t *testing.T) {
 		fakeenv := map[string]string{
 			"SENTRY_LEVELS":  "FATAL,PANIC,ERROR,WARN",
@@ -57,6 +187,7 @@ func TestDefaultConfig(t *testing.T) {
 		config := log.DefaultConfig(func(varname string) string {
 			return fakeenv[varname]
 		})
+		config.StaticFields = nil // Set by ENVIRONMENT.
 		testutils.AssertEqual(t, defaultConfig, config)
 	})
 }
@@ -80,6 +211,17 @@ func TestOpenRegister(t *testing.T) {
 		testutils.AssertNotNil(t, logger)
 	})
 
+	t.Run("Open with an invalid config fails", func(t *testing.T) {
+		logger, err := log.Open("test", &log.Config{Level: 7})
+		testutils.AssertNil(t, logger)
+		testutils.AssertEqual(t, "log: Invalid level (7)", err.Error())
+
+		// Drivers write to os.Stderr if Output is nil.
+		logger, err = log.Open("test", &log.Config{})
+		testutils.AssertNil(t, err)
+		testutils.AssertNotNil(t, logger)
+	})
+
 	t.Run("Open with config sets config", func(t *testing.T) {
 		logger, err := log.Open("test", nil)
 		testutils.AssertNil(t, err)
@@ -98,3 +240,20 @@ func TestOpenRegister(t *testing.T) {
 		testutils.AssertEqual(t, loadedConfig, config)
 	})
 }
+
+func TestConfig_Validate(t *testing.T) {
+	testutils.AssertNil(t, log.DefaultConfig(func(string) string { return "" }).Validate())
+
+	for want, config := range map[string]*log.Config{
+		"log: Invalid level (7)":                    {Level: 7, Output: os.Stderr},
+		"log: Invalid level (-5) for db":            {LevelOverrides: log.LevelOverrides{"db": -5}, Output: os.Stderr},
+		"log: Invalid level override: missing name": {LevelOverrides: log.LevelOverrides{"": log.INFO}, Output: os.Stderr},
+		"log: Invalid format (9)":                   {Format: 9, Output: os.Stderr},
+		"log: Invalid level case (2)":               {LevelCase: 2, Output: os.Stderr},
+		"log: Missing output":                       {},
+	} {
+		err := config.Validate()
+		testutils.AssertNotNil(t, err)
+		testutils.AssertEqual(t, want, err.Error())
+	}
+}
@@ -2,11 +2,14 @@ package logger_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/secureworks/logger/internal/testutils"
 	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/logrus"
 	_ "github.com/secureworks/logger/testlogger"
+	_ "github.com/secureworks/logger/zerolog"
 )
 
 func TestLog_ContextUtilities(t *testing.T) {
@@ -29,3 +32,83 @@ func TestLog_ContextUtilities(t *testing.T) {
 		testutils.AssertEqual(t, entry, log.EntryFromCtx(ctx))
 	})
 }
+
+type requestIDKey struct{}
+
+func requestIDFields(ctx context.Context) map[string]interface{} {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return map[string]interface{}{"request_id": id}
+	}
+	return nil
+}
+
+func TestLog_FromCtx(t *testing.T) {
+	t.Cleanup(log.RegisterCtxFields(requestIDFields))
+
+	for _, driver := range []string{"zerolog", "logrus"} {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+			logger, err := log.Open(driver, config)
+			testutils.AssertNil(t, err)
+
+			ctx := log.CtxWithLogger(context.Background(), logger)
+			ctx = context.WithValue(ctx, requestIDKey{}, "abc")
+			log.FromCtx(ctx).Info().WithStr("k", "v").Msg("with ctx")
+
+			var fields map[string]interface{}
+			testutils.AssertNil(t, json.Unmarshal(out.Bytes(), &fields))
+			testutils.AssertEqual(t, "abc", fields["request_id"])
+			testutils.AssertEqual(t, "v", fields["k"])
+		})
+	}
+
+	t.Run("with a Schema", func(t *testing.T) {
+		config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+		config.Schema = &log.Schema{Keys: map[string]string{"request_id": "http.request.id"}}
+		logger, err := log.Open("zerolog", config)
+		testutils.AssertNil(t, err)
+
+		ctx := log.CtxWithLogger(context.Background(), logger)
+		ctx = context.WithValue(ctx, requestIDKey{}, "abc")
+		log.FromCtx(ctx).Info().Msg("with ctx")
+
+		var fields map[string]interface{}
+		testutils.AssertNil(t, json.Unmarshal(out.Bytes(), &fields))
+		testutils.AssertEqual(t, "abc", fields["http.request.id"])
+		testutils.AssertNil(t, fields["request_id"])
+	})
+
+	t.Run("without ctx fields", func(t *testing.T) {
+		logger, _ := log.Open("test", nil)
+		ctx := log.CtxWithLogger(context.Background(), logger)
+
+		testutils.AssertSame(t, logger, log.FromCtx(ctx))
+	})
+
+	t.Run("unregistered", func(t *testing.T) {
+		config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+		logger, err := log.Open("zerolog", config)
+		testutils.AssertNil(t, err)
+		ctx := log.CtxWithLogger(context.Background(), logger)
+		ctx = context.WithValue(ctx, requestIDKey{}, "abc")
+
+		unregister := log.RegisterCtxFields(func(context.Context) map[string]interface{} {
+			return map[string]interface{}{"other": "field"}
+		})
+		unregister()
+		unregister() // A no-op.
+		log.FromCtx(ctx).Info().Msg("with ctx")
+
+		var fields map[string]interface{}
+		testutils.AssertNil(t, json.Unmarshal(out.Bytes(), &fields))
+		testutils.AssertEqual(t, "abc", fields["request_id"])
+		testutils.AssertNil(t, fields["other"])
+	})
+
+	t.Run("without a Logger", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), requestIDKey{}, "abc")
+
+		testutils.AssertEqual(t, log.Noop(), log.FromCtx(ctx))
+	})
+}
@@ -0,0 +1,135 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/logrus"
+	"github.com/secureworks/logger/testlogger"
+	_ "github.com/secureworks/logger/zerolog"
+)
+
+type dictUser struct {
+	ID    string
+	Token string
+	Roles []string
+}
+
+func (u *dictUser) MarshalLogObject(d log.Dict) {
+	d.Str("id", u.ID).
+		Str("token", u.Token).
+		Field("roles", u.Roles).
+		Dict("meta", func(d log.Dict) { d.Bool("admin", false) })
+}
+
+// Writes an entry with nested objects.
+func withDicts(entry log.Entry) {
+	entry.
+		WithDict("http", func(d log.Dict) {
+			d.Str("method", "GET").
+				Int("status", 200).
+				Int64("bytes", 1<<40).
+				Uint64("id", 7).
+				Float("ratio", 0.5).
+				Dict("request", func(d log.Dict) {
+					d.Str("authorization", "Bearer abc")
+				}).
+				Object("user", &dictUser{ID: "u1", Token: "t1", Roles: []string{"ops"}}).
+				Object("none", nil)
+		}).
+		WithObject("user", &dictUser{ID: "u2", Token: "t2"}).
+		WithObject("nil", nil).
+		Msg("nested")
+}
+
+func TestEntry_WithDict(t *testing.T) {
+	redaction := &log.RedactionPolicy{Keys: []string{"token", "http.request.authorization"}}
+	want := map[string]interface{}{
+		"http": map[string]interface{}{
+			"method":  "GET",
+			"status":  json.Number("200"),
+			"bytes":   json.Number("1099511627776"),
+			"id":      json.Number("7"),
+			"ratio":   json.Number("0.5"),
+			"request": map[string]interface{}{"authorization": log.Redacted},
+			"user": map[string]interface{}{
+				"id":    "u1",
+				"token": log.Redacted,
+				"roles": []interface{}{"ops"},
+				"meta":  map[string]interface{}{"admin": false},
+			},
+			"none": nil,
+		},
+		"user": map[string]interface{}{
+			"id":    "u2",
+			"token": log.Redacted,
+			"roles": nil,
+			"meta":  map[string]interface{}{"admin": false},
+		},
+		"nil":     nil,
+		"level":   "info",
+		"message": "nested",
+	}
+	decode := func(t *testing.T, byt []byte) map[string]interface{} {
+		t.Helper()
+
+		dec := json.NewDecoder(bytes.NewReader(byt))
+		dec.UseNumber()
+		fields := make(map[string]interface{})
+		testutils.AssertNil(t, dec.Decode(&fields))
+		return fields
+	}
+
+	for _, driver := range []string{"zerolog", "logrus"} {
+		t.Run(driver, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			hook := &testutils.RecordingHook{Lvls: []log.Level{log.INFO}}
+			logger, err := log.Open(driver, &log.Config{
+				Level:      log.INFO,
+				Format:     log.JSONFormat,
+				Output:     out,
+				TimeFormat: log.TimeFormatNone,
+				Redaction:  redaction,
+			}, log.WithHooks(hook))
+			testutils.AssertNil(t, err)
+
+			withDicts(logger.Info())
+			testutils.AssertEqual(t, want, decode(t, out.Bytes()))
+
+			records := hook.Records()
+			testutils.AssertEqual(t, 1, len(records))
+			http, ok := records[0].Fields()["http"].(map[string]interface{})
+			testutils.AssertTrue(t, ok)
+			testutils.AssertEqual(t, "GET", http["method"])
+			user, ok := http["user"].(map[string]interface{})
+			testutils.AssertTrue(t, ok)
+			testutils.AssertEqual(t, log.Redacted, user["token"])
+		})
+	}
+
+	t.Run("testlogger", func(t *testing.T) {
+		logger, err := testlogger.New(&log.Config{Level: log.INFO, Redaction: redaction})
+		testutils.AssertNil(t, err)
+
+		withDicts(logger.Info())
+		testutils.AssertEqual(t, want, decode(t, logger.Config.Output.(*bytes.Buffer).Bytes()))
+	})
+
+	t.Run("tee", func(t *testing.T) {
+		first, _ := testlogger.New(&log.Config{Level: log.INFO, Redaction: redaction})
+		second, _ := testlogger.New(&log.Config{Level: log.INFO, Redaction: redaction})
+		calls := 0
+		log.Tee(first, second).Info().WithDict("d", func(d log.Dict) { calls++ }).Send()
+
+		testutils.AssertEqual(t, 1, calls)
+		testutils.AssertEqual(t, `{"d":{},"level":"info"}`, first.Config.Output.(*bytes.Buffer).String())
+		testutils.AssertEqual(t, `{"d":{},"level":"info"}`, second.Config.Output.(*bytes.Buffer).String())
+	})
+
+	t.Run("noop", func(t *testing.T) {
+		testutils.AssertNotPanics(t, func() { withDicts(log.Noop().Info()) })
+	})
+}
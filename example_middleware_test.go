@@ -17,6 +17,7 @@ import (
 func Example_usingMiddleware() {
 	config := log.DefaultConfig(nil)
 	config.Output = os.Stdout
+	config.TimeFormat = log.TimeFormatNone // Reproducible output.
 	logger, _ := log.Open("zerolog", config)
 
 	// Pick attributes to log. You can also skip defaults.
@@ -13,6 +13,7 @@ import (
 func Example_usingOptions() {
 	config := log.DefaultConfig(nil)
 	config.Output = os.Stdout
+	config.TimeFormat = log.TimeFormatNone // Reproducible output.
 
 	// This CustomOption is attaching a hook using Zerolog's Logger.Hook method.
 	// See: https://pkg.go.dev/github.com/rs/zerolog#Logger.Hook
@@ -19,6 +19,9 @@ func Example() {
 	// ... but setting the output must be done directly:
 	config.Output = os.Stdout
 
+	// Times are omitted here so that the output is reproducible.
+	config.TimeFormat = log.TimeFormatNone
+
 	fmt.Println()
 
 	// If the second (config) argument passed to log.Open is nil then
@@ -43,7 +43,7 @@ func Example_usingTestlogger() {
 		fmt.Println(entry.StringField("tfield"))
 	}
 
-	// Output: {"error":"error message","level":"INFO","message":"test message","tfield":"test-value"}
+	// Output: {"environment":"test","error":"error message","level":"info","message":"test message","tfield":"test-value"}
 	// test message
 	// error message
 	// test-value
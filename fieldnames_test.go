@@ -0,0 +1,141 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/secureworks/errors"
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/logrus"
+	"github.com/secureworks/logger/testlogger"
+	_ "github.com/secureworks/logger/zerolog"
+)
+
+func TestFieldNames(t *testing.T) {
+	render := func(t *testing.T, driver string, config *log.Config) map[string]interface{} {
+		t.Helper()
+
+		out := new(bytes.Buffer)
+		config.Level = log.INFO
+		config.Output = out
+		logger, err := log.Open(driver, config)
+		testutils.AssertNil(t, err)
+
+		logger.Warn().
+			Caller().
+			WithError(errors.New("boom")).
+			WithStr("key", "value").
+			Msg("message")
+
+		fields := make(map[string]interface{})
+		testutils.AssertNil(t, json.Unmarshal(out.Bytes(), &fields))
+		return fields
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		zerologFields := render(t, "zerolog", &log.Config{EnableErrStack: true})
+		logrusFields := render(t, "logrus", &log.Config{EnableErrStack: true})
+
+		testutils.AssertEqual(t,
+			[]string{"caller", "error", "key", "level", "message", "stack", "time"},
+			sortedKeys(zerologFields),
+		)
+		testutils.AssertEqual(t, sortedKeys(zerologFields), sortedKeys(logrusFields))
+		for _, fields := range []map[string]interface{}{zerologFields, logrusFields} {
+			testutils.AssertEqual(t, "warn", fields[log.LevelField])
+			testutils.AssertEqual(t, "message", fields[log.MessageField])
+			testutils.AssertEqual(t, "boom", fields[log.ErrorField])
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		config := func() *log.Config {
+			return &log.Config{
+				FieldNames: log.FieldNames{
+					Message: "msg",
+					Level:   "severity",
+					Time:    "ts",
+					Error:   "err",
+					Caller:  "src",
+					Stack:   "trace",
+				},
+				LevelCase:      log.UpperCaseLevels,
+				EnableErrStack: true,
+			}
+		}
+		zerologFields := render(t, "zerolog", config())
+		logrusFields := render(t, "logrus", config())
+
+		testutils.AssertEqual(t,
+			[]string{"err", "key", "msg", "severity", "src", "trace", "ts"},
+			sortedKeys(zerologFields),
+		)
+		testutils.AssertEqual(t, sortedKeys(zerologFields), sortedKeys(logrusFields))
+		for _, fields := range []map[string]interface{}{zerologFields, logrusFields} {
+			testutils.AssertEqual(t, "WARN", fields["severity"])
+			testutils.AssertEqual(t, "message", fields["msg"])
+			testutils.AssertEqual(t, "boom", fields["err"])
+		}
+	})
+
+	t.Run("logfmt", func(t *testing.T) {
+		for _, driver := range []string{"zerolog", "logrus"} {
+			out := new(bytes.Buffer)
+			logger, err := log.Open(driver, &log.Config{
+				Level:      log.INFO,
+				Format:     log.LogfmtFormat,
+				Output:     out,
+				FieldNames: log.FieldNames{Message: "msg", Level: "severity"},
+				LevelCase:  log.UpperCaseLevels,
+				TimeFormat: log.TimeFormatNone,
+			})
+			testutils.AssertNil(t, err)
+
+			logger.Info().WithStr("msg", "collides").Msg("message")
+			testutils.AssertEqual(t, "severity=INFO msg=message fields.msg=collides\n", out.String())
+		}
+	})
+
+	t.Run("logrus default format", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		logger, err := log.Open("logrus", &log.Config{
+			Level:      log.INFO,
+			Format:     log.ImplementationDefaultFormat,
+			Output:     out,
+			FieldNames: log.FieldNames{Message: "msg", Level: "severity"},
+			TimeFormat: log.TimeFormatNone,
+		})
+		testutils.AssertNil(t, err)
+
+		logger.Info().Msg("message")
+		testutils.AssertEqual(t, "severity=info msg=message\n", out.String())
+	})
+
+	t.Run("testlogger", func(t *testing.T) {
+		logger, err := testlogger.New(&log.Config{
+			Level:      log.INFO,
+			FieldNames: log.FieldNames{Message: "msg", Level: "severity", Error: "err"},
+			LevelCase:  log.UpperCaseLevels,
+		})
+		testutils.AssertNil(t, err)
+		logger.Error().WithError(errors.New("boom")).Msg("message")
+
+		testutils.AssertEqual(t,
+			`{"err":"boom","msg":"message","severity":"ERROR"}`,
+			logger.Config.Output.(*bytes.Buffer).String(),
+		)
+	})
+}
+
+// Returns the keys of fields in order.
+func sortedKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
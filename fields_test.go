@@ -0,0 +1,145 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/logrus"
+	"github.com/secureworks/logger/testlogger"
+	_ "github.com/secureworks/logger/zerolog"
+)
+
+type fieldsStringer struct{}
+
+func (fieldsStringer) String() string { return "stringer" }
+
+// Writes an entry using each of the typed With… methods.
+func withTypedFields(entry log.Entry) {
+	entry.
+		WithFloat("float", 2.5).
+		WithFloat("floats", 1, 0.5).
+		WithInt64("int64", -1<<40).
+		WithInt64("int64s", 1, -2).
+		WithUint64("uint64", 1<<63).
+		WithUint64("uint64s", 3, 4).
+		WithBytes("bytes", []byte("text")).
+		WithHex("hex", []byte{0xca, 0xfe}).
+		WithIP("ip", net.IPv4(192, 0, 2, 1)).
+		WithIP("ips", net.ParseIP("2001:db8::1"), net.IPv4(192, 0, 2, 2)).
+		WithStringer("stringer", fieldsStringer{}).
+		WithStringer("nil", nil).
+		WithJSON("json", []byte(`{"a":[1,true]}`)).
+		Msg("typed")
+}
+
+func TestEntry_TypedFields(t *testing.T) {
+	want := map[string]interface{}{
+		"float":    json.Number("2.5"),
+		"floats":   []interface{}{json.Number("1"), json.Number("0.5")},
+		"int64":    json.Number("-1099511627776"),
+		"int64s":   []interface{}{json.Number("1"), json.Number("-2")},
+		"uint64":   json.Number("9223372036854775808"),
+		"uint64s":  []interface{}{json.Number("3"), json.Number("4")},
+		"bytes":    "text",
+		"hex":      "cafe",
+		"ip":       "192.0.2.1",
+		"ips":      []interface{}{"2001:db8::1", "192.0.2.2"},
+		"stringer": "stringer",
+		"nil":      nil,
+		"json":     map[string]interface{}{"a": []interface{}{json.Number("1"), true}},
+		"level":    "info",
+		"message":  "typed",
+	}
+	decode := func(t *testing.T, byt []byte) map[string]interface{} {
+		t.Helper()
+
+		dec := json.NewDecoder(bytes.NewReader(byt))
+		dec.UseNumber()
+		fields := make(map[string]interface{})
+		testutils.AssertNil(t, dec.Decode(&fields))
+		return fields
+	}
+
+	for _, driver := range []string{"zerolog", "logrus"} {
+		t.Run(driver, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			logger, err := log.Open(driver, &log.Config{
+				Level:      log.INFO,
+				Format:     log.JSONFormat,
+				Output:     out,
+				TimeFormat: log.TimeFormatNone,
+			})
+			testutils.AssertNil(t, err)
+
+			withTypedFields(logger.Info())
+			testutils.AssertEqual(t, want, decode(t, out.Bytes()))
+		})
+	}
+
+	t.Run("testlogger", func(t *testing.T) {
+		logger, err := testlogger.New(&log.Config{Level: log.INFO})
+		testutils.AssertNil(t, err)
+
+		withTypedFields(logger.Info())
+		testutils.AssertEqual(t, want, decode(t, logger.Config.Output.(*bytes.Buffer).Bytes()))
+
+		entry := logger.GetEntries()[0]
+		testutils.AssertEqual(t, "cafe", entry.StringField("hex"))
+		testutils.AssertEqual(t, int64(-1<<40), entry.Field("int64"))
+	})
+
+	t.Run("logfmt", func(t *testing.T) {
+		var lines []string
+		for _, driver := range []string{"zerolog", "logrus"} {
+			out := new(bytes.Buffer)
+			logger, err := log.Open(driver, &log.Config{
+				Level:      log.INFO,
+				Format:     log.LogfmtFormat,
+				Output:     out,
+				TimeFormat: log.TimeFormatNone,
+			})
+			testutils.AssertNil(t, err)
+
+			logger.Info().
+				WithFloat("float", 2.5).
+				WithHex("hex", []byte{0xca, 0xfe}).
+				WithIP("ip", net.IPv4(192, 0, 2, 1)).
+				WithJSON("json", []byte(`{"a":1}`)).
+				Msg("typed")
+			lines = append(lines, out.String())
+		}
+		testutils.AssertEqual(t, `level=info message=typed float=2.5 hex=cafe ip=192.0.2.1 json="{\"a\":1}"`+"\n", lines[0])
+		testutils.AssertEqual(t, lines[0], lines[1])
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		logger, err := log.Open("zerolog", &log.Config{
+			Level:      log.INFO,
+			Output:     out,
+			TimeFormat: log.TimeFormatNone,
+			Redaction:  &log.RedactionPolicy{Keys: []string{"secret"}},
+		})
+		testutils.AssertNil(t, err)
+
+		logger.Info().WithHex("secret", []byte{1}).WithJSON("secret", []byte(`{}`)).Msg("typed")
+		testutils.AssertEqual(t, `{"secret":"[REDACTED]","secret":"[REDACTED]","level":"info","message":"typed"}`+"\n", out.String())
+	})
+
+	t.Run("noop and tee", func(t *testing.T) {
+		testutils.AssertNotPanics(t, func() { withTypedFields(log.Noop().Info()) })
+
+		first, _ := testlogger.New(&log.Config{Level: log.INFO})
+		second, _ := testlogger.New(&log.Config{Level: log.INFO})
+		withTypedFields(log.Tee(first, second).Info())
+		testutils.AssertEqual(t,
+			first.Config.Output.(*bytes.Buffer).String(),
+			second.Config.Output.(*bytes.Buffer).String(),
+		)
+		testutils.AssertEqual(t, want, decode(t, first.Config.Output.(*bytes.Buffer).Bytes()))
+	})
+}
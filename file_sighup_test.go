@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package logger_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+)
+
+func TestFileWriter_SIGHUP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	w, err := log.OpenFile(log.FileConfig{Path: path, ReopenOnSIGHUP: true})
+	testutils.AssertNil(t, err)
+	defer w.Close()
+
+	testutils.AssertNil(t, os.Rename(path, path+".1"))
+	testutils.AssertNil(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	// Wait for the file to be reopened.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file was not reopened")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
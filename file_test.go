@@ -0,0 +1,221 @@
+package logger_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+)
+
+// Returns the lines of the log file and its backups in dir.
+func readLogLines(t *testing.T, dir string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"))
+	testutils.AssertNil(t, err)
+
+	var lines []string
+	for _, path := range paths {
+		f, err := os.Open(path)
+		testutils.AssertNil(t, err)
+
+		var scanner *bufio.Scanner
+		if strings.HasSuffix(path, ".gz") {
+			gz, err := gzip.NewReader(f)
+			testutils.AssertNil(t, err)
+			scanner = bufio.NewScanner(gz)
+		} else {
+			scanner = bufio.NewScanner(f)
+		}
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		testutils.AssertNil(t, scanner.Err())
+		_ = f.Close()
+	}
+	return lines
+}
+
+func TestFileWriter(t *testing.T) {
+	t.Run("rotates by size and keeps backups", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := log.OpenFile(log.FileConfig{
+			Path:       filepath.Join(dir, "agent.log"),
+			MaxSize:    20,
+			MaxBackups: 2,
+			Compress:   true,
+		})
+		testutils.AssertNil(t, err)
+
+		for i := 0; i < 5; i++ {
+			_, err := w.Write([]byte("0123456789abcdef\n")) // Rotates each time.
+			testutils.AssertNil(t, err)
+		}
+		testutils.AssertNil(t, w.Close())
+
+		backups, _ := filepath.Glob(filepath.Join(dir, "agent-*.log.gz"))
+		testutils.AssertEqual(t, 2, len(backups))
+		testutils.AssertEqual(t, 3, len(readLogLines(t, dir)))
+	})
+
+	t.Run("rotates by time", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := log.OpenFile(log.FileConfig{
+			Path:     filepath.Join(dir, "agent.log"),
+			Interval: 20 * time.Millisecond,
+		})
+		testutils.AssertNil(t, err)
+
+		_, _ = w.Write([]byte("first\n"))
+		time.Sleep(40 * time.Millisecond)
+		_, _ = w.Write([]byte("second\n"))
+		testutils.AssertNil(t, w.Close())
+
+		backups, _ := filepath.Glob(filepath.Join(dir, "agent-*.log"))
+		testutils.AssertEqual(t, 1, len(backups))
+		byt, _ := os.ReadFile(backups[0])
+		testutils.AssertEqual(t, "first\n", string(byt))
+	})
+
+	t.Run("reopens a moved file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "agent.log")
+		w, err := log.OpenFile(log.FileConfig{Path: path})
+		testutils.AssertNil(t, err)
+
+		_, _ = w.Write([]byte("first\n"))
+		testutils.AssertNil(t, os.Rename(path, path+".1"))
+		testutils.AssertNil(t, w.Reopen())
+		_, _ = w.Write([]byte("second\n"))
+		testutils.AssertNil(t, w.Close())
+
+		byt, _ := os.ReadFile(path)
+		testutils.AssertEqual(t, "second\n", string(byt))
+
+		_, err = w.Write([]byte("closed\n"))
+		testutils.AssertNotNil(t, err)
+	})
+
+	t.Run("recovers if the file cannot be reopened", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "logs")
+		path := filepath.Join(dir, "agent.log")
+		w, err := log.OpenFile(log.FileConfig{Path: path})
+		testutils.AssertNil(t, err)
+		defer w.Close()
+
+		// A file in place of the directory stops the file being opened.
+		testutils.AssertNil(t, os.RemoveAll(dir))
+		testutils.AssertNil(t, os.WriteFile(dir, nil, 0o644))
+
+		// Reopen keeps the old file.
+		testutils.AssertNotNil(t, w.Reopen())
+		_, err = w.Write([]byte("kept\n"))
+		testutils.AssertNil(t, err)
+
+		// Write opens the file again after a failed rotation.
+		testutils.AssertNotNil(t, w.Rotate())
+		_, err = w.Write([]byte("lost\n"))
+		testutils.AssertNotNil(t, err)
+
+		testutils.AssertNil(t, os.Remove(dir))
+		_, err = w.Write([]byte("reopened\n"))
+		testutils.AssertNil(t, err)
+		byt, _ := os.ReadFile(path)
+		testutils.AssertEqual(t, "reopened\n", string(byt))
+	})
+
+	t.Run("is safe for concurrent loggers", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := log.OpenFile(log.FileConfig{
+			Path:    filepath.Join(dir, "agent.log"),
+			MaxSize: 1024,
+		})
+		testutils.AssertNil(t, err)
+
+		var wg sync.WaitGroup
+		for _, name := range []string{"zerolog", "logrus"} {
+			config := log.DefaultConfig(func(string) string { return "" })
+			config.Output = w
+			logger, err := log.Open(name, config)
+			testutils.AssertNil(t, err)
+
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 50; j++ {
+						logger.Info().WithInt("j", j).Msg("concurrent message")
+					}
+				}()
+			}
+		}
+		wg.Wait()
+		testutils.AssertNil(t, w.Close())
+
+		lines := readLogLines(t, dir)
+		testutils.AssertEqual(t, 400, len(lines))
+		for _, line := range lines {
+			var fields map[string]interface{}
+			testutils.AssertNil(t, json.Unmarshal([]byte(line), &fields))
+		}
+	})
+}
+
+func TestDefaultConfig_LogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	env := map[string]string{
+		log.LogFile.String():           path,
+		log.LogFileMaxSize.String():    "10",
+		log.LogFileMaxBackups.String(): "3",
+	}
+
+	// The file is opened by Open rather than DefaultConfig.
+	config := log.DefaultConfig(func(key string) string { return env[key] })
+	testutils.AssertNil(t, config.Output)
+	testutils.AssertNil(t, config.Validate())
+	_, err := os.Stat(path)
+	testutils.AssertTrue(t, os.IsNotExist(err))
+
+	logger, err := log.Open("zerolog", config)
+	testutils.AssertNil(t, err)
+	logger.Info().Msg("to file")
+	testutils.AssertNil(t, config.Output)
+	byt, err := os.ReadFile(path)
+	testutils.AssertNil(t, err)
+	testutils.AssertStringContains(t, "to file", string(byt))
+
+	// Configs for the same path share the FileWriter.
+	w, err := log.OpenSharedFile(log.FileConfig{Path: path})
+	testutils.AssertNil(t, err)
+	defer w.Close()
+	_, err = log.Open("logrus", log.DefaultConfig(func(key string) string { return env[key] }))
+	testutils.AssertNil(t, err)
+	w2, err := log.OpenSharedFile(log.FileConfig{Path: path})
+	testutils.AssertNil(t, err)
+	testutils.AssertSame(t, w, w2)
+
+	// Invalid values are skipped and reported by Validate and Open.
+	env[log.LogFileMaxSize.String()] = "ten"
+	config = log.DefaultConfig(func(key string) string { return env[key] })
+	want := `log: Invalid LOG_FILE_MAX_SIZE (ten): strconv.ParseInt: parsing "ten": invalid syntax`
+	testutils.AssertEqual(t, want, config.Validate().Error())
+	_, err = log.Open("zerolog", config)
+	testutils.AssertEqual(t, want, err.Error())
+
+	// Files that cannot be opened are reported by Open.
+	env[log.LogFile.String()] = filepath.Join(path, "agent.log")
+	env[log.LogFileMaxSize.String()] = "10"
+	config = log.DefaultConfig(func(key string) string { return env[key] })
+	testutils.AssertNil(t, config.Validate())
+	logger, err = log.Open("zerolog", config)
+	testutils.AssertNil(t, logger)
+	testutils.AssertStringContains(t, "log: Failed to open LOG_FILE", err.Error())
+}
@@ -0,0 +1,98 @@
+package logger_test
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/secureworks/errors"
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/logrus"
+	_ "github.com/secureworks/logger/zerolog"
+)
+
+var timeValueRE = regexp.MustCompile(`time=\S+`)
+
+func TestLogfmtFormat(t *testing.T) {
+	render := func(driver string) string {
+		t.Helper()
+
+		out := new(bytes.Buffer)
+		logger, err := log.Open(driver, &log.Config{
+			Level:  log.INFO,
+			Format: log.LogfmtFormat,
+			Output: out,
+		})
+		testutils.AssertNil(t, err)
+
+		logger.Warn().
+			WithStr("str", "two words").
+			WithStr("strs", "a", "b").
+			WithInt("int", 42).
+			WithBool("bool", true).
+			WithDur("dur", 1500*time.Microsecond).
+			WithTime("ts", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)).
+			WithField("map", map[string]interface{}{"k": "v"}).
+			WithField("message", "collides").
+			WithError(errors.New(`bad "thing"`)).
+			Msg("message with = sign")
+		logger.Info().WithStr("empty", "").Send()
+		logger.Debug().Msg("not written")
+
+		// Times will vary, but must be present.
+		testutils.AssertEqual(t, 2, len(timeValueRE.FindAllString(out.String(), -1)))
+		return timeValueRE.ReplaceAllString(out.String(), "time=TIME")
+	}
+
+	zerologOut := render("zerolog")
+	logrusOut := render("logrus")
+
+	testutils.AssertEqual(t, zerologOut, logrusOut)
+	testutils.AssertEqual(t,
+		`level=warn time=TIME message="message with = sign" bool=true dur=1.5 error="bad \"thing\"" fields.message=collides int=42 map="{\"k\":\"v\"}" str="two words" strs="[\"a\",\"b\"]" ts=2020-01-02T03:04:05Z`+"\n"+
+			`level=info time=TIME empty=""`+"\n",
+		zerologOut,
+	)
+}
+
+var consoleTimeRE = regexp.MustCompile(`(?m)^\d\d:\d\d:\d\d `)
+
+func TestConsoleFormat(t *testing.T) {
+	// Errors from github.com/secureworks/errors carry their own stack
+	// trace, so both drivers will write the same frames.
+	errWithStack := errors.NewWithStackTrace("error value")
+
+	render := func(driver string) string {
+		t.Helper()
+
+		out := new(bytes.Buffer)
+		logger, err := log.Open(driver, &log.Config{
+			Level:          log.INFO,
+			Format:         log.ConsoleFormat,
+			EnableErrStack: true,
+			Output:         out,
+		})
+		testutils.AssertNil(t, err)
+
+		logger.Info().WithStr("str", "two words").WithBool("bool", true).Msg("message")
+		logger.Warn().WithError(errWithStack).Msg("with stack")
+
+		testutils.AssertEqual(t, 2, len(consoleTimeRE.FindAllString(out.String(), -1)))
+		return consoleTimeRE.ReplaceAllString(out.String(), "TIME ")
+	}
+
+	zerologOut := render("zerolog")
+	logrusOut := render("logrus")
+
+	testutils.AssertEqual(t, zerologOut, logrusOut)
+
+	lines := strings.Split(zerologOut, "\n")
+	testutils.AssertEqual(t, `TIME INF message bool=true str="two words"`, lines[0])
+	testutils.AssertEqual(t, `TIME WRN with stack error="error value"`, lines[1])
+	testutils.AssertEqual(t, `  stack:`, lines[2])
+	testutils.AssertEqual(t, `    github.com/secureworks/logger_test.TestConsoleFormat`, lines[3])
+	testutils.AssertStringContains(t, "format_test.go:", lines[4])
+}
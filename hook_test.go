@@ -0,0 +1,97 @@
+package logger_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	"github.com/secureworks/logger/testlogger"
+)
+
+type orderHook struct {
+	name  string
+	order *[]string
+}
+
+func (h orderHook) Levels() []log.Level { return []log.Level{log.INFO} }
+
+func (h orderHook) Fire(_ *log.Record) error {
+	*h.order = append(*h.order, h.name)
+	return nil
+}
+
+type funcHook func(*log.Record) error
+
+func (h funcHook) Levels() []log.Level { return []log.Level{log.INFO} }
+
+func (h funcHook) Fire(r *log.Record) error { return h(r) }
+
+func TestHooks(t *testing.T) {
+	t.Run("fired in order for their levels", func(t *testing.T) {
+		var order []string
+		logger, err := testlogger.New(nil,
+			log.WithHooks(orderHook{"first", &order}, orderHook{"second", &order}),
+			log.WithHooks(orderHook{"third", &order}))
+		testutils.AssertNil(t, err)
+
+		logger.Info().Msg("hooked")
+		logger.Warn().Msg("not hooked")
+		testutils.AssertEqual(t, []string{"first", "second", "third"}, order)
+	})
+
+	t.Run("errors are reported", func(t *testing.T) {
+		failing := &testutils.RecordingHook{Lvls: []log.Level{log.ERROR}, Err: errors.New("hook failed")}
+		next := &testutils.RecordingHook{Lvls: []log.Level{log.ERROR}}
+
+		var reported []error
+		logger, err := testlogger.New(nil,
+			log.WithHooks(failing, next),
+			log.WithHookErrorHandler(func(h log.Hook, err error) {
+				testutils.AssertSame(t, failing, h)
+				reported = append(reported, err)
+			}))
+		testutils.AssertNil(t, err)
+
+		logger.Error().Msg("failed")
+		testutils.AssertEqual(t, 1, len(reported))
+		testutils.AssertEqual(t, "hook failed", reported[0].Error())
+		testutils.AssertEqual(t, 1, len(next.Records()))
+		testutils.AssertTrue(t, logger.GetEntries()[0].Sent)
+	})
+
+	t.Run("records are read-only", func(t *testing.T) {
+		hook := &testutils.RecordingHook{Lvls: []log.Level{log.INFO}}
+		logger, err := testlogger.New(nil, log.WithHooks(hook))
+		testutils.AssertNil(t, err)
+
+		logger.Info().WithStr("key", "value").Msg("message")
+		rec := hook.Records()[0]
+		rec.Fields()["key"] = "changed"
+		val, _ := rec.Field("key")
+		testutils.AssertEqual(t, "value", val)
+	})
+
+	t.Run("hooks may add hooks", func(t *testing.T) {
+		var order []string
+		logger, err := testlogger.New(nil)
+		testutils.AssertNil(t, err)
+		hooks := logger.Hooks()
+		hooks.Add(funcHook(func(*log.Record) error {
+			if len(order) == 0 {
+				hooks.Add(orderHook{"added", &order})
+			}
+			order = append(order, "adding")
+			return nil
+		}))
+
+		logger.Info().Msg("first")
+		logger.Info().Msg("second")
+		testutils.AssertEqual(t, []string{"adding", "adding", "added"}, order)
+	})
+
+	t.Run("unsupported logger", func(t *testing.T) {
+		err := log.WithHooks(&testutils.RecordingHook{})(struct{}{})
+		testutils.AssertNotNil(t, err)
+	})
+}
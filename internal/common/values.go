@@ -0,0 +1,29 @@
+package common
+
+import (
+	"fmt"
+	"net"
+)
+
+// IPValue returns the value written for the IPs given to
+// log.Entry.WithIP: the string of a single IP, or the strings of
+// several.
+func IPValue(ips []net.IP) interface{} {
+	if len(ips) == 1 {
+		return ips[0].String()
+	}
+	strs := make([]string, len(ips))
+	for i, ip := range ips {
+		strs[i] = ip.String()
+	}
+	return strs
+}
+
+// StringerValue returns the value written for val by
+// log.Entry.WithStringer: val.String(), or nil if val is nil.
+func StringerValue(val fmt.Stringer) interface{} {
+	if val == nil {
+		return nil
+	}
+	return val.String()
+}
@@ -0,0 +1,177 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/secureworks/logger/log"
+)
+
+// ANSI color codes used by the console format.
+const (
+	colorRed     = 31
+	colorGreen   = 32
+	colorYellow  = 33
+	colorBlue    = 34
+	colorMagenta = 35
+	colorCyan    = 36
+	colorGray    = 90
+)
+
+// ConsoleTimeFormat is the short time format used by the console
+// format.
+const ConsoleTimeFormat = "15:04:05"
+
+// ConsoleEncoder returns an Encoder for human-readable lines meant for
+// local development, eg:
+//
+//	15:04:05 INF message here bool=true str="two words"
+//
+// The level is abbreviated and, if color is true, colorized; fields are
+// sorted by key and rendered as in AppendLogfmt. Stack traces in the
+// Record.StackKey and log.PanicStack fields are rendered on the lines
+// following the entry, one frame per line.
+func ConsoleEncoder(color bool) Encoder {
+	return func(dst []byte, rec *Record) []byte {
+		return appendConsole(dst, rec, color)
+	}
+}
+
+// IsTerminal reports whether w is a terminal that should be written to
+// with color. The NO_COLOR environment variable
+// (https://no-color.org) disables color.
+func IsTerminal(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+func appendConsole(dst []byte, rec *Record, color bool) []byte {
+	var stacks []string
+
+	if rec.Time != "" {
+		ts := rec.Time
+		if t, err := time.Parse(TimeFormat, ts); err == nil {
+			ts = t.Format(ConsoleTimeFormat)
+		}
+		dst = appendColored(dst, ts, colorGray, color)
+		dst = append(dst, ' ')
+	}
+
+	abbr, lvlColor := consoleLevel(rec.Level)
+	dst = appendColored(dst, abbr, lvlColor, color)
+
+	if rec.Message != "" {
+		dst = append(dst, ' ')
+		dst = append(dst, rec.Message...)
+	}
+
+	stackKey := keyOr(rec.StackKey, log.StackField)
+	for _, key := range rec.SortedKeys() {
+		val := rec.Fields[key]
+		if (key == stackKey || key == log.PanicStack) && isStack(val) {
+			stacks = append(stacks, key)
+			continue
+		}
+
+		dst = append(dst, ' ')
+		dst = appendColored(dst, rec.FieldKey(key)+"=", colorCyan, color)
+		dst = appendLogfmtValue(dst, val)
+	}
+
+	for _, key := range stacks {
+		dst = append(dst, '\n')
+		dst = appendColored(dst, "  "+key+":", colorCyan, color)
+		dst = appendStack(dst, rec.Fields[key].([]interface{}))
+	}
+	return dst
+}
+
+// Returns the abbreviated level name and its color.
+func consoleLevel(lvl string) (string, int) {
+	switch strings.ToLower(lvl) {
+	case "trace":
+		return "TRC", colorMagenta
+	case "debug":
+		return "DBG", colorBlue
+	case "info":
+		return "INF", colorGreen
+	case "warn":
+		return "WRN", colorYellow
+	case "error":
+		return "ERR", colorRed
+	case "panic":
+		return "PNC", colorRed
+	case "fatal":
+		return "FTL", colorRed
+	case "":
+		return "???", colorGray
+	default:
+		return strings.ToUpper(lvl), colorGray
+	}
+}
+
+func appendColored(dst []byte, s string, color int, enabled bool) []byte {
+	if !enabled {
+		return append(dst, s...)
+	}
+	dst = append(dst, fmt.Sprintf("\x1b[%dm", color)...)
+	dst = append(dst, s...)
+	return append(dst, "\x1b[0m"...)
+}
+
+// Checks if val is a normalized stack trace, a list of frames as
+// written by github.com/secureworks/errors.
+func isStack(val interface{}) bool {
+	frames, ok := val.([]interface{})
+	if !ok || len(frames) == 0 {
+		return false
+	}
+	for _, f := range frames {
+		if _, ok := f.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func appendStack(dst []byte, frames []interface{}) []byte {
+	for _, f := range frames {
+		frame := f.(map[string]interface{})
+		dst = append(dst, "\n    "...)
+		dst = append(dst, frameString(frame, "function")...)
+		dst = append(dst, "\n        "...)
+		dst = append(dst, frameString(frame, "file")...)
+		if line := frameString(frame, "line"); line != "" {
+			dst = append(dst, ':')
+			dst = append(dst, line...)
+		}
+	}
+	return dst
+}
+
+func frameString(frame map[string]interface{}, key string) string {
+	switch v := frame[key].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,228 @@
+// Package format renders log lines in the text formats shared by the
+// logger drivers. Drivers convert their entries into a Record, with
+// field values normalized to the types produced by decoding JSON, so
+// that every driver renders the same entry to the same bytes.
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/secureworks/logger/log"
+)
+
+// Default keys for the core values of a Record in the text formats,
+// those of log.DefaultFieldNames.
+const (
+	LevelKey   = log.LevelField
+	TimeKey    = log.TimeField
+	MessageKey = log.MessageField
+)
+
+// TimeFormat is the format the drivers use for times in the text
+// formats.
+const TimeFormat = time.RFC3339
+
+// Record is a driver-agnostic representation of a single log line.
+// Field values should be normalized, see Normalize.
+type Record struct {
+	Level   string
+	Time    string
+	Message string
+	Fields  map[string]interface{}
+
+	// Keys the core values are written with; LevelKey, TimeKey and
+	// MessageKey if empty. StackKey is the field holding the stack
+	// trace of an error; log.StackField if empty.
+	LevelKey   string
+	TimeKey    string
+	MessageKey string
+	StackKey   string
+}
+
+// Encoder appends the rendered rec to dst, without a trailing newline.
+type Encoder func(dst []byte, rec *Record) []byte
+
+// FromJSON decodes a JSON object log line into a Record. The values
+// found at levelKey, timeKey and msgKey are removed from the fields and
+// used as the core values of the Record. If a core key appears more
+// than once, as Zerolog writes fields that clash with the core values,
+// the last value is used and the others are kept as fields.
+func FromJSON(line []byte, levelKey, timeKey, msgKey string) (*Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object: %v", tok)
+	}
+
+	fields := make(map[string]interface{})
+	core := make(map[string]interface{}, 3)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case levelKey, timeKey, msgKey:
+			if prev, ok := core[key]; ok {
+				fields[key] = prev
+			}
+			core[key] = val
+		default:
+			fields[key] = val
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return &Record{
+		Level:      coreString(core, levelKey),
+		Time:       coreString(core, timeKey),
+		Message:    coreString(core, msgKey),
+		Fields:     fields,
+		LevelKey:   levelKey,
+		TimeKey:    timeKey,
+		MessageKey: msgKey,
+	}, nil
+}
+
+// Keys returns the keys the core values of rec are written with.
+func (rec *Record) Keys() (levelKey, timeKey, msgKey string) {
+	return keyOr(rec.LevelKey, LevelKey), keyOr(rec.TimeKey, TimeKey), keyOr(rec.MessageKey, MessageKey)
+}
+
+// FieldKey returns key, prefixed with "fields." if it collides with one
+// of the core keys of rec.
+func (rec *Record) FieldKey(key string) string {
+	levelKey, timeKey, msgKey := rec.Keys()
+	switch key {
+	case levelKey, timeKey, msgKey:
+		return "fields." + key
+	}
+	return key
+}
+
+// SortedKeys returns the keys of the fields of rec sorted by how they
+// will be written, see Record.FieldKey.
+func (rec *Record) SortedKeys() []string {
+	return sortedKeys(rec.Fields, rec.FieldKey)
+}
+
+// Returns key, or def if key is empty.
+func keyOr(key, def string) string {
+	if key == "" {
+		return def
+	}
+	return key
+}
+
+func coreString(core map[string]interface{}, key string) string {
+	val, ok := core[key]
+	if !ok {
+		return ""
+	}
+	if s, ok := val.(string); ok {
+		return s
+	}
+	return fmt.Sprint(val)
+}
+
+// Normalize converts a Go value into the value that would be produced
+// by encoding it the way Zerolog does and decoding the JSON with
+// json.Decoder.UseNumber: nil, bool, string, json.Number,
+// []interface{} or map[string]interface{}.
+func Normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, bool, string, json.Number:
+		return val
+	case error:
+		return val.Error()
+	case time.Time:
+		return val.Format(TimeFormat)
+	case time.Duration:
+		return durationNumber(val)
+	case []error:
+		out := make([]interface{}, len(val))
+		for i, e := range val {
+			out[i] = Normalize(e)
+		}
+		return out
+	case []time.Time:
+		out := make([]interface{}, len(val))
+		for i, t := range val {
+			out[i] = Normalize(t)
+		}
+		return out
+	case []time.Duration:
+		out := make([]interface{}, len(val))
+		for i, d := range val {
+			out[i] = durationNumber(d)
+		}
+		return out
+	}
+
+	byt, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.UseNumber()
+
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	return out
+}
+
+// Durations are written the way Zerolog writes them by default: as
+// floating point milliseconds.
+func durationNumber(d time.Duration) interface{} {
+	byt, _ := json.Marshal(float64(d) / float64(time.Millisecond))
+	return json.Number(byt)
+}
+
+// Writer converts JSON log lines written to it into the format given by
+// Encode before writing them to Out. Lines that cannot be decoded are
+// written as is.
+type Writer struct {
+	Out    io.Writer
+	Encode Encoder
+
+	// Keys used to find the core values in the JSON lines, and the
+	// stack trace field, see Record.
+	LevelKey   string
+	TimeKey    string
+	MessageKey string
+	StackKey   string
+}
+
+// Write implements io.Writer. It expects p to hold a single JSON log
+// line, which is how the drivers write.
+func (w *Writer) Write(p []byte) (int, error) {
+	rec, err := FromJSON(p, w.LevelKey, w.TimeKey, w.MessageKey)
+	if err != nil {
+		return w.Out.Write(p)
+	}
+	rec.StackKey = w.StackKey
+
+	buf := w.Encode(make([]byte, 0, len(p)), rec)
+	buf = append(buf, '\n')
+	if _, err := w.Out.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
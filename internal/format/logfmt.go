@@ -0,0 +1,132 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// AppendLogfmt is an Encoder for logfmt (key=value) lines. The core keys
+// are written first, in the order level, time and message, followed by
+// the remaining fields sorted by key. Empty core values are omitted.
+//
+// Values are written as follows:
+//   - strings are written bare unless they are empty or contain
+//     spaces, '=', '"', control or non-printable characters, in which
+//     case they are quoted and escaped using strconv.Quote;
+//   - numbers and Booleans are written as they appear in JSON;
+//   - nil is written as null;
+//   - lists and objects are written as compact JSON, quoted.
+//
+// Characters in keys that are not allowed in logfmt keys are replaced
+// with '_'. Fields that collide with a core key are prefixed with
+// "fields.", as Logrus does.
+func AppendLogfmt(dst []byte, rec *Record) []byte {
+	first := true
+	appendPair := func(key string, val interface{}) {
+		if !first {
+			dst = append(dst, ' ')
+		}
+		first = false
+		dst = appendLogfmtKey(dst, key)
+		dst = append(dst, '=')
+		dst = appendLogfmtValue(dst, val)
+	}
+
+	levelKey, timeKey, msgKey := rec.Keys()
+	if rec.Level != "" {
+		appendPair(levelKey, rec.Level)
+	}
+	if rec.Time != "" {
+		appendPair(timeKey, rec.Time)
+	}
+	if rec.Message != "" {
+		appendPair(msgKey, rec.Message)
+	}
+	for _, key := range rec.SortedKeys() {
+		appendPair(rec.FieldKey(key), rec.Fields[key])
+	}
+	return dst
+}
+
+// SortedKeys returns the keys of fields sorted by how they will be
+// written, see FieldKey.
+func SortedKeys(fields map[string]interface{}) []string {
+	return sortedKeys(fields, FieldKey)
+}
+
+func sortedKeys(fields map[string]interface{}, fieldKey func(string) string) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return fieldKey(keys[i]) < fieldKey(keys[j]) })
+	return keys
+}
+
+// FieldKey returns key, prefixed with "fields." if it collides with one
+// of the default core keys.
+func FieldKey(key string) string {
+	return (&Record{}).FieldKey(key)
+}
+
+func appendLogfmtKey(dst []byte, key string) []byte {
+	if key == "" {
+		return append(dst, '_')
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			r = '_'
+		}
+		dst = utf8.AppendRune(dst, r)
+	}
+	return dst
+}
+
+func appendLogfmtValue(dst []byte, val interface{}) []byte {
+	switch v := val.(type) {
+	case nil:
+		return append(dst, "null"...)
+	case string:
+		return appendLogfmtString(dst, v)
+	case bool:
+		return strconv.AppendBool(dst, v)
+	case json.Number:
+		return append(dst, v...)
+	default:
+		return appendLogfmtString(dst, compactJSON(v))
+	}
+}
+
+func appendLogfmtString(dst []byte, s string) []byte {
+	if !needsQuoting(s) {
+		return append(dst, s...)
+	}
+	return strconv.AppendQuote(dst, s)
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Encodes a normalized value as compact JSON, without escaping HTML.
+func compactJSON(v interface{}) string {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	return string(bytes.TrimRight(buf.Bytes(), "\n"))
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/secureworks/logger/log"
@@ -26,6 +27,54 @@ func NewConfigWithBuffer(t *testing.T, logLevel log.Level) (*log.Config, *bytes.
 	return config, out
 }
 
+// SyncBuffer is a bytes.Buffer that is safe for concurrent use, for
+// asserting against the output of loggers that write from other
+// goroutines.
+type SyncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *SyncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *SyncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// RecordingHook is a log.Hook fired for Lvls that keeps the Records it
+// is fired with, and returns Err from Fire.
+type RecordingHook struct {
+	Lvls []log.Level
+	Err  error
+
+	mu      sync.Mutex
+	records []*log.Record
+}
+
+func (h *RecordingHook) Levels() []log.Level {
+	return h.Lvls
+}
+
+func (h *RecordingHook) Fire(r *log.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return h.Err
+}
+
+// Records returns the Records the hook was fired with.
+func (h *RecordingHook) Records() []*log.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*log.Record(nil), h.records...)
+}
+
 // AssertTrue is a semantic test assertion for object truthiness.
 func AssertTrue(t *testing.T, object bool) {
 	t.Helper()
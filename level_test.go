@@ -0,0 +1,158 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+)
+
+func TestParseLevel(t *testing.T) {
+	for str, want := range map[string]log.Level{
+		"TRACE":     log.TRACE,
+		"debug":     log.DEBUG,
+		" Info ":    log.INFO,
+		"WARN":      log.WARN,
+		"warning":   log.WARN,
+		"ERROR":     log.ERROR,
+		"err":       log.ERROR,
+		"PANIC":     log.PANIC,
+		"crit":      log.PANIC,
+		"fatal":     log.FATAL,
+		"FATAL":     log.FATAL,
+		"\tTRACE\n": log.TRACE,
+	} {
+		lvl, err := log.ParseLevel(str)
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, want, lvl)
+		testutils.AssertEqual(t, want, log.LevelFromString(str))
+	}
+
+	for _, str := range []string{"", "WARNNING", "verbose", "0"} {
+		lvl, err := log.ParseLevel(str)
+		testutils.AssertNotNil(t, err)
+		testutils.AssertEqual(t, log.INFO, lvl)
+		testutils.AssertStringContains(t, "unknown level", err.Error())
+		testutils.AssertEqual(t, log.INFO, log.LevelFromString(str))
+	}
+}
+
+func TestLevel_Encoding(t *testing.T) {
+	for _, lvl := range log.AllLevels() {
+		parsed, err := log.ParseLevel(lvl.String())
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, lvl, parsed)
+	}
+	testutils.AssertEqual(t, "WARN", log.WARN.String())
+	testutils.AssertEqual(t, "Level(9)", log.Level(9).String())
+
+	t.Run("JSON", func(t *testing.T) {
+		var config struct {
+			Level log.Level `json:"level"`
+		}
+		testutils.AssertNil(t, json.Unmarshal([]byte(`{"level":"warning"}`), &config))
+		testutils.AssertEqual(t, log.WARN, config.Level)
+
+		byt, err := json.Marshal(config)
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, `{"level":"WARN"}`, string(byt))
+
+		byt, err = json.Marshal(map[log.Level]int{log.ERROR: 1})
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, `{"ERROR":1}`, string(byt))
+
+		err = json.Unmarshal([]byte(`{"level":"loud"}`), &config)
+		testutils.AssertStringContains(t, `unknown level "loud"`, err.Error())
+
+		_, err = json.Marshal(log.Level(9))
+		testutils.AssertStringContains(t, "log: Invalid level (9)", err.Error())
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		lvl := log.INFO
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Var(&lvl, "level", "log level")
+
+		testutils.AssertNil(t, fs.Parse([]string{"-level", "debug"}))
+		testutils.AssertEqual(t, log.DEBUG, lvl)
+		testutils.AssertEqual(t, "DEBUG", fs.Lookup("level").Value.String())
+		testutils.AssertNotNil(t, fs.Parse([]string{"-level", "loud"}))
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	for str, want := range map[string]log.LoggerFormat{
+		"json":     log.JSONFormat,
+		"JSON":     log.JSONFormat,
+		" logfmt ": log.LogfmtFormat,
+		"Console":  log.ConsoleFormat,
+		"default":  log.ImplementationDefaultFormat,
+		"0":        log.JSONFormat,
+		"1":        log.LogfmtFormat,
+		"2":        log.ConsoleFormat,
+		"-1":       log.ImplementationDefaultFormat,
+		"\tjson\n": log.JSONFormat,
+	} {
+		f, err := log.ParseFormat(str)
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, want, f)
+	}
+
+	for _, str := range []string{"", "xml", "42", "-2"} {
+		f, err := log.ParseFormat(str)
+		testutils.AssertNotNil(t, err)
+		testutils.AssertEqual(t, log.JSONFormat, f)
+		testutils.AssertStringContains(t, "unknown format", err.Error())
+	}
+}
+
+func TestLoggerFormat_Encoding(t *testing.T) {
+	testutils.AssertEqual(t, "logfmt", log.LogfmtFormat.String())
+	testutils.AssertEqual(t, "LoggerFormat(42)", log.LoggerFormat(42).String())
+
+	var config struct {
+		Format log.LoggerFormat `json:"format"`
+	}
+	testutils.AssertNil(t, json.Unmarshal([]byte(`{"format":"console"}`), &config))
+	testutils.AssertEqual(t, log.ConsoleFormat, config.Format)
+
+	byt, err := json.Marshal(config)
+	testutils.AssertNil(t, err)
+	testutils.AssertEqual(t, `{"format":"console"}`, string(byt))
+
+	_, err = json.Marshal(log.LoggerFormat(42))
+	testutils.AssertStringContains(t, "log: Invalid format (42)", err.Error())
+
+	f := log.JSONFormat
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&f, "format", "log format")
+	testutils.AssertNil(t, fs.Parse([]string{"-format", "logfmt"}))
+	testutils.AssertEqual(t, log.LogfmtFormat, f)
+	testutils.AssertNotNil(t, fs.Parse([]string{"-format", "xml"}))
+}
+
+func TestLevelCase(t *testing.T) {
+	for str, want := range map[string]log.LevelCase{
+		"lower":   log.LowerCaseLevels,
+		" UPPER ": log.UpperCaseLevels,
+	} {
+		c, err := log.ParseLevelCase(str)
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, want, c)
+	}
+	c, err := log.ParseLevelCase("title")
+	testutils.AssertEqual(t, log.LowerCaseLevels, c)
+	testutils.AssertStringContains(t, `unknown level case "title"`, err.Error())
+
+	testutils.AssertEqual(t, "warn", log.LowerCaseLevels.Name(log.WARN))
+	testutils.AssertEqual(t, "trace", log.LevelCase(0).Name(log.TRACE))
+	testutils.AssertEqual(t, "FATAL", log.UpperCaseLevels.Name(log.FATAL))
+	testutils.AssertEqual(t, "Level(9)", log.UpperCaseLevels.Name(log.Level(9)))
+	testutils.AssertEqual(t, "upper", log.UpperCaseLevels.String())
+	testutils.AssertEqual(t, "LevelCase(2)", log.LevelCase(2).String())
+}
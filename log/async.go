@@ -0,0 +1,202 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Flusher is implemented by Loggers, and their outputs, that buffer
+// entries. The Loggers in this module flush their output before
+// panicking at PANIC or exiting at FATAL; shutdown code should do the
+// same, or use Close if the Logger also implements io.Closer, eg:
+//
+//	defer func() {
+//	    if c, ok := logger.(io.Closer); ok {
+//	        _ = c.Close()
+//	    }
+//	}()
+type Flusher interface {
+	// Flush writes any buffered entries, returning the first error
+	// encountered since the last Flush.
+	Flush() error
+}
+
+// OverflowPolicy decides what an AsyncWriter does with a line written
+// while its queue is full.
+type OverflowPolicy int
+
+const (
+	// DropOnOverflow drops the line and counts it, see
+	// AsyncWriter.Dropped.
+	DropOnOverflow OverflowPolicy = iota
+
+	// BlockOnOverflow blocks until the queue has room for the line.
+	BlockOnOverflow
+)
+
+// DefaultAsyncQueueSize is the number of lines queued by an AsyncWriter
+// if AsyncConfig.QueueSize is zero or less.
+const DefaultAsyncQueueSize = 1024
+
+// AsyncConfig configures an AsyncWriter, and is set using Config.Async
+// to have Loggers write to their output through one.
+type AsyncConfig struct {
+	// QueueSize is the number of lines that may be waiting to be
+	// written; DefaultAsyncQueueSize is used if it is zero or less.
+	QueueSize int
+
+	// Overflow is used when a line is written to a full queue.
+	Overflow OverflowPolicy
+}
+
+var errAsyncWriterClosed = errors.New("log: AsyncWriter is closed")
+
+// AsyncWriter is an io.Writer that queues each line written to it and
+// writes them to another io.Writer from its own goroutine, so that a
+// slow output does not stall the code writing entries. It is safe for
+// concurrent use.
+type AsyncWriter struct {
+	dropped  uint64 // First for 64-bit alignment.
+	out      io.Writer
+	overflow OverflowPolicy
+	queue    chan []byte
+	stop     chan struct{}
+
+	// Lines are queued in the order of their sequence number, so that
+	// Flush only waits for those queued before it was called. Close
+	// holds it too, so that no line is queued once it has flushed.
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	drained *sync.Cond
+	queued  uint64 // Sequence number of the last line queued.
+	written uint64 // Sequence number of the last line written.
+	err     error
+	closed  bool
+}
+
+var _ Flusher = (*AsyncWriter)(nil)
+var _ io.WriteCloser = (*AsyncWriter)(nil)
+
+// NewAsyncWriter returns a new AsyncWriter writing to out, configured
+// by config, which may be nil. Close must be called to stop its
+// goroutine.
+func NewAsyncWriter(out io.Writer, config *AsyncConfig) *AsyncWriter {
+	if config == nil {
+		config = &AsyncConfig{}
+	}
+	size := config.QueueSize
+	if size <= 0 {
+		size = DefaultAsyncQueueSize
+	}
+
+	w := &AsyncWriter{
+		out:      out,
+		overflow: config.Overflow,
+		queue:    make(chan []byte, size),
+		stop:     make(chan struct{}),
+	}
+	w.drained = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p to be written. It never returns an error
+// unless the AsyncWriter is closed; errors from the underlying
+// io.Writer are returned by Flush.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	line := append([]byte(nil), p...)
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+
+	w.mu.Lock()
+	closed := w.closed
+	w.mu.Unlock()
+	if closed {
+		return 0, errAsyncWriterClosed
+	}
+
+	if w.overflow == BlockOnOverflow {
+		w.queue <- line
+	} else {
+		select {
+		case w.queue <- line:
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+			return len(p), nil
+		}
+	}
+
+	w.mu.Lock()
+	w.queued++
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+// Flush waits until every line queued before it was called is written.
+// Lines queued while it waits are not waited for, so that it returns
+// even if others keep writing.
+func (w *AsyncWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for seq := w.queued; w.written < seq; {
+		w.drained.Wait()
+	}
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// Close flushes the AsyncWriter and stops its goroutine; later writes
+// fail. The underlying io.Writer is not closed.
+func (w *AsyncWriter) Close() error {
+	w.sendMu.Lock()
+	w.mu.Lock()
+	closed := w.closed
+	w.closed = true
+	w.mu.Unlock()
+	w.sendMu.Unlock()
+	if closed {
+		return nil
+	}
+
+	err := w.Flush()
+	close(w.stop)
+	return err
+}
+
+// Dropped returns the number of lines dropped by DropOnOverflow. It
+// returns 0 for a nil AsyncWriter.
+func (w *AsyncWriter) Dropped() uint64 {
+	if w == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&w.dropped)
+}
+
+func (w *AsyncWriter) run() {
+	for {
+		select {
+		case line := <-w.queue:
+			_, err := w.out.Write(line)
+			w.done(err)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Marks the next queued line as written.
+func (w *AsyncWriter) done(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.written++
+	w.drained.Broadcast()
+}
@@ -1,18 +1,23 @@
 package log
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // These EnvKeys describe environmental variables used to set Config
 // variables.
 const (
 	// LogLevel is the env var representing the log level. Values should
-	// use our logger's representation: "TRACE", "DEBUG", etc.
+	// use our logger's representation: "TRACE", "DEBUG", etc. It may be
+	// followed by comma-separated overrides for named components, eg:
+	// "INFO,db=DEBUG,http.client=TRACE" (see Config.LevelOverrides).
 	LogLevel EnvKey = "LOG_LEVEL"
 
 	// LocalDevel is the env var representing the local debugging setting
@@ -21,8 +26,9 @@ const (
 	LocalDevel EnvKey = "LOG_LOCAL_DEV"
 
 	// Format is the env var representing the log format we want to use.
-	// Relevant values include: "0" (JSONFormat) and "-1"
-	// (ImplementationDefaultFormat).
+	// Relevant values include: "0" or "json" (JSONFormat), "-1" or
+	// "default" (ImplementationDefaultFormat), "1" or "logfmt"
+	// (LogfmtFormat), and "2" or "console" (ConsoleFormat).
 	Format EnvKey = "LOG_FORMAT"
 
 	// EnableErrStack is the env var representing whether we shall enable
@@ -31,8 +37,94 @@ const (
 	EnableErrStack EnvKey = "ERROR_STACK"
 
 	// Environment is the env var representing the current deployment
-	// environment. Values commonly used could be "dev", "prod", etc.
+	// environment. Values commonly used could be "dev", "prod", etc. It
+	// is set in the EnvironmentField of Config.StaticFields.
 	Environment EnvKey = "ENVIRONMENT"
+
+	// ServiceName is the env var representing the name of the service
+	// logging. It is set in the ServiceField of Config.StaticFields.
+	ServiceName EnvKey = "SERVICE_NAME"
+
+	// ServiceVersion is the env var representing the version of the
+	// service logging. It is set in the VersionField of
+	// Config.StaticFields.
+	ServiceVersion EnvKey = "SERVICE_VERSION"
+
+	// LogProcessFields is the env var representing whether the hostname
+	// and process ID are set in the HostField and PIDField of
+	// Config.StaticFields. Relevant values include: "true", "True",
+	// "TRUE".
+	LogProcessFields EnvKey = "LOG_PROCESS_FIELDS"
+
+	// LogFile is the env var representing the path of a file to write
+	// to instead of os.Stderr, using a FileWriter that reopens the file
+	// on SIGHUP. Loggers configured with the same path share the
+	// FileWriter.
+	LogFile EnvKey = "LOG_FILE"
+
+	// LogFileMaxSize is the env var representing the size in megabytes
+	// at which the LogFile is rotated, see FileConfig.MaxSize.
+	LogFileMaxSize EnvKey = "LOG_FILE_MAX_SIZE"
+
+	// LogFileInterval is the env var representing how often the LogFile
+	// is rotated, as a duration such as "24h", see FileConfig.Interval.
+	LogFileInterval EnvKey = "LOG_FILE_INTERVAL"
+
+	// LogFileMaxBackups is the env var representing the number of
+	// rotated LogFiles to keep, see FileConfig.MaxBackups.
+	LogFileMaxBackups EnvKey = "LOG_FILE_MAX_BACKUPS"
+
+	// LogFileCompress is the env var representing whether rotated
+	// LogFiles are gzipped. Relevant values include: "true", "True",
+	// "TRUE".
+	LogFileCompress EnvKey = "LOG_FILE_COMPRESS"
+
+	// OTelResourceAttributes is the OpenTelemetry env var representing
+	// resource attributes, as comma-separated key=value pairs with
+	// percent-encoded values, eg: "deployment.environment=prod" (see
+	// Config.Resource).
+	OTelResourceAttributes EnvKey = "OTEL_RESOURCE_ATTRIBUTES"
+
+	// OTelServiceName is the OpenTelemetry env var representing the
+	// "service.name" resource attribute. It takes precedence over
+	// OTelResourceAttributes.
+	OTelServiceName EnvKey = "OTEL_SERVICE_NAME"
+
+	// LogSchema selects the Config.Schema, as parsed by ParseSchema:
+	// "ecs" or "gcp".
+	LogSchema EnvKey = "LOG_SCHEMA"
+
+	// GoogleCloudProject is the ID of the Google Cloud project, used to
+	// write trace IDs as Cloud Logging links them when the Config.Schema
+	// is a GCPSchema; see GCPProjectSchema.
+	GoogleCloudProject EnvKey = "GOOGLE_CLOUD_PROJECT"
+)
+
+// Values of Config.TimeFormat. Besides these, TimeFormat may be any
+// layout accepted by time.Time.Format, such as time.RFC3339Nano.
+const (
+	// DefaultTimeFormat is the format of the time of entries if
+	// Config.TimeFormat is empty.
+	DefaultTimeFormat = time.RFC3339
+
+	// TimeFormatUnix writes times as the number of seconds since the
+	// Unix epoch.
+	TimeFormatUnix = "unix"
+
+	// TimeFormatUnixMs writes times as the number of milliseconds since
+	// the Unix epoch.
+	TimeFormatUnixMs = "unixms"
+
+	// TimeFormatUnixMicro writes times as the number of microseconds
+	// since the Unix epoch.
+	TimeFormatUnixMicro = "unixmicro"
+
+	// TimeFormatUnixNano writes times as the number of nanoseconds since
+	// the Unix epoch.
+	TimeFormatUnixNano = "unixnano"
+
+	// TimeFormatNone omits the time of entries.
+	TimeFormatNone = "none"
 )
 
 // EnvKey is a publicly documented string type for environment lookups
@@ -51,8 +143,13 @@ type Config struct {
 	// entry will cause the entry to not be logged.
 	Level Level
 
+	// LevelOverrides sets the level of Loggers created using Named, by
+	// component name. See LevelOverrides.
+	LevelOverrides LevelOverrides
+
 	// LocalDevel, may be used by some logger implementations for local
-	// debugging.
+	// debugging. For human-readable output across implementations use
+	// ConsoleFormat.
 	LocalDevel bool
 
 	// Format is the format the Logger should log in.
@@ -63,35 +160,320 @@ type Config struct {
 
 	// Output is the io.Writer the Logger will write messages to.
 	Output io.Writer
+
+	// FieldNames are the names of the fields holding the message,
+	// level, time, error, caller and stack trace of each entry, which
+	// are the same for every driver. See FieldNames.
+	FieldNames FieldNames
+
+	// LevelCase is the case levels are written in: LowerCaseLevels, the
+	// default, or UpperCaseLevels.
+	LevelCase LevelCase
+
+	// TimeFormat is the format of the time each entry is written: one
+	// of the TimeFormat… constants, or a layout accepted by
+	// time.Time.Format; DefaultTimeFormat if empty.
+	TimeFormat string
+
+	// UTC writes times in UTC rather than local time.
+	UTC bool
+
+	// Schema, if set, is a preset of the FieldNames, LevelCase,
+	// TimeFormat and UTC, and of the keys of other fields, that makes
+	// entries conform to a log schema, such as ECSSchema or GCPSchema.
+	// Non-empty FieldNames and TimeFormat take precedence over those of
+	// the Schema, as does UpperCaseLevels; UTC is used if set in either.
+	// See Schema.
+	Schema *Schema
+
+	// Sampler, if set, decides which enabled entries are written; see
+	// Sampler. Loggers that sample entries implement DroppedCounter.
+	Sampler Sampler
+
+	// Dedup, if set, suppresses duplicate entries and periodically
+	// writes a summary of them; see DedupConfig. It is supported by the
+	// zerolog, logrus and slog drivers.
+	Dedup *DedupConfig
+
+	// Redaction, if set, is applied to fields and messages before they
+	// are encoded; see RedactionPolicy and DefaultRedactionPolicy.
+	Redaction *RedactionPolicy
+
+	// Async, if set, has Loggers write to Output through an AsyncWriter,
+	// which they flush before panicking or exiting and close when they
+	// are closed; see Flusher. Lines it drops are counted by
+	// DroppedCounter. It is supported by the zerolog, logrus and slog
+	// drivers.
+	Async *AsyncConfig
+
+	// StaticFields are set on every entry written by Loggers, as if
+	// bound using With when they are created, so they cost nothing per
+	// entry. Fields bound using With, or set on an entry, replace them.
+	StaticFields map[string]interface{}
+
+	// Resource holds attributes describing the entity producing the
+	// logs, eg: "service.name", for drivers that export entries to a
+	// collector, such as the otlp driver. Other drivers ignore it.
+	Resource map[string]interface{}
+
+	envErr error       // The first invalid env value read by DefaultConfig.
+	file   *FileConfig // The LogFile read by DefaultConfig, opened by Open.
 }
 
 // DefaultConfig returns a Config instance with sane defaults. env is a
 // callback for looking up EnvKeys, it is set to os.Getenv if nil.
-// Fields and values returned by this function can be altered.
+// Fields and values returned by this function can be altered. Invalid
+// env values are skipped, and the first is reported by Config.Validate.
+// If LogFile is set, Output is left nil and the file is opened by Open,
+// so that DefaultConfig has no side effects on the file system. To read
+// a Config from a YAML, TOML or JSON file, see the config package.
 func DefaultConfig(env func(string) string) *Config {
-	config := new(Config)
 	if env == nil {
 		env = os.Getenv
 	}
 
 	// Level defaults to 0, ie INFO.
-	if lvlStr := env(LogLevel.String()); lvlStr != "" {
-		config.Level = LevelFromString(lvlStr)
+	config := &Config{Output: os.Stderr}
+	config.envErr = config.LoadEnv(env)
+
+	file := FileConfig{ReopenOnSIGHUP: true}
+	if err := file.LoadEnv(env); err != nil && config.envErr == nil {
+		config.envErr = err
+	}
+	if file.Path != "" {
+		config.Output = nil
+		config.file = &file
+	}
+	return config
+}
+
+// Validate returns an error describing the first invalid setting in
+// config, if any: an invalid env value read by DefaultConfig, an
+// unknown level, format or level case, an override without a name, or a
+// missing output. Open calls it before creating a Logger. Configs read
+// from a file using the config package are validated when read.
+func (c *Config) Validate() error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if c.Output == nil && c.file == nil {
+		return errors.New("log: Missing output")
 	}
-	if errStackStr := env(EnableErrStack.String()); errStackStr != "" {
-		config.EnableErrStack = strings.ToUpper(errStackStr) == "TRUE"
+	return nil
+}
+
+// validate is Validate less the output check, as Open allows a nil
+// Output, for which drivers write to os.Stderr.
+func (c *Config) validate() error {
+	if c.envErr != nil {
+		return c.envErr
 	}
-	if localDevel := env(LocalDevel.String()); localDevel != "" {
-		config.LocalDevel = strings.ToUpper(localDevel) == "TRUE"
+	if !c.Level.IsValid() {
+		return fmt.Errorf("log: Invalid level (%d)", c.Level)
 	}
-	if format := env(Format.String()); format != "" {
-		f, err := strconv.ParseInt(format, 10, 64)
-		if err == nil { // FIXME(PH): swallows errors...
-			config.Format = LoggerFormat(f)
+	for name, lvl := range c.LevelOverrides {
+		if name == "" {
+			return errors.New("log: Invalid level override: missing name")
+		}
+		if !lvl.IsValid() {
+			return fmt.Errorf("log: Invalid level (%d) for %s", lvl, name)
 		}
 	}
-	config.Output = os.Stderr // May not be set via environment.
-	return config
+	if !c.Format.IsValid() {
+		return fmt.Errorf("log: Invalid format (%d)", c.Format)
+	}
+	if !c.LevelCase.IsValid() {
+		return fmt.Errorf("log: Invalid level case (%d)", c.LevelCase)
+	}
+	return nil
+}
+
+// LoadEnv sets the settings of c given by the EnvKeys set in env, other
+// than those of LogFile, which are loaded by FileConfig.LoadEnv:
+// LogLevel replaces the level and adds its overrides, and the static
+// fields and resource attributes set by env vars are added to those of
+// c. Invalid values are skipped, and the first is returned. It is used
+// by DefaultConfig, and to have env vars take precedence over settings
+// read from elsewhere, such as a file.
+func (c *Config) LoadEnv(env func(string) string) error {
+	var first error
+	report := func(err error) {
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	if str := env(LogLevel.String()); str != "" {
+		lvl, overrides, err := ParseLevels(str)
+		if err != nil {
+			report(fmt.Errorf("log: Invalid %s (%s): %w", LogLevel, str, err))
+		} else {
+			c.Level = lvl
+			for name, lvl := range overrides {
+				if c.LevelOverrides == nil {
+					c.LevelOverrides = make(LevelOverrides)
+				}
+				c.LevelOverrides[name] = lvl
+			}
+		}
+	}
+	report(boolFromEnv(&c.LocalDevel, LocalDevel, env))
+	report(boolFromEnv(&c.EnableErrStack, EnableErrStack, env))
+	if str := env(Format.String()); str != "" {
+		f, err := ParseFormat(str)
+		if err != nil {
+			report(fmt.Errorf("log: Invalid %s (%s): %w", Format, str, err))
+		} else {
+			c.Format = f
+		}
+	}
+	if str := env(LogSchema.String()); str != "" {
+		schema, err := ParseSchema(str)
+		if err != nil {
+			report(fmt.Errorf("log: Invalid %s (%s): %w", LogSchema, str, err))
+		} else {
+			c.Schema = schema
+		}
+	}
+	if project := env(GoogleCloudProject.String()); project != "" && c.Schema != nil && c.Schema.Name == "gcp" {
+		schema := *c.Schema
+		schema.TraceIDFormat = GCPProjectSchema(project).TraceIDFormat
+		c.Schema = &schema
+	}
+
+	fields, err := staticFieldsFromEnv(env)
+	report(err)
+	c.StaticFields = mergeFields(c.StaticFields, fields)
+	resource, err := resourceFromEnv(env)
+	report(err)
+	c.Resource = mergeFields(c.Resource, resource)
+	return first
+}
+
+// Sets dst to the value of the key env var, if it is set.
+func boolFromEnv(dst *bool, key EnvKey, env func(string) string) error {
+	if str := env(key.String()); str != "" {
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return fmt.Errorf("log: Invalid %s (%s): %w", key, str, err)
+		}
+		*dst = b
+	}
+	return nil
+}
+
+// Sets the fields of src in dst, which is created if needed.
+func mergeFields(dst, src map[string]interface{}) map[string]interface{} {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]interface{}, len(src))
+	}
+	for key, val := range src {
+		dst[key] = val
+	}
+	return dst
+}
+
+// ParseLevels parses a level spec such as
+// "INFO,db=DEBUG,http.client=TRACE", as set in LogLevel: an optional
+// level, INFO if omitted, followed by overrides for named components.
+// It returns an error if the spec has an unknown level or an override
+// without a name.
+func ParseLevels(str string) (Level, LevelOverrides, error) {
+	var (
+		lvl       Level
+		overrides LevelOverrides
+		err       error
+	)
+	for _, part := range strings.Split(str, ",") {
+		name, lvlStr, ok := strings.Cut(part, "=")
+		if !ok {
+			if lvl, err = ParseLevel(part); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return 0, nil, fmt.Errorf("missing name in override %q", strings.TrimSpace(part))
+		}
+		if overrides == nil {
+			overrides = make(LevelOverrides)
+		}
+		if overrides[name], err = ParseLevel(lvlStr); err != nil {
+			return 0, nil, err
+		}
+	}
+	return lvl, overrides, nil
+}
+
+// Returns the static fields set by the Environment, ServiceName,
+// ServiceVersion and LogProcessFields env vars, or nil if there are
+// none.
+func staticFieldsFromEnv(env func(string) string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	for key, field := range map[EnvKey]string{
+		Environment:    EnvironmentField,
+		ServiceName:    ServiceField,
+		ServiceVersion: VersionField,
+	} {
+		if val := env(key.String()); val != "" {
+			fields[field] = val
+		}
+	}
+
+	var processFields bool
+	err := boolFromEnv(&processFields, LogProcessFields, env)
+	if processFields {
+		if host, err := os.Hostname(); err == nil {
+			fields[HostField] = host
+		}
+		fields[PIDField] = os.Getpid()
+	}
+
+	if len(fields) == 0 {
+		return nil, err
+	}
+	return fields, err
+}
+
+// Returns the resource attributes set by the OTelResourceAttributes and
+// OTelServiceName env vars, or nil if there are none. Invalid
+// attributes are skipped, and the first is reported.
+func resourceFromEnv(env func(string) string) (map[string]interface{}, error) {
+	var (
+		resource map[string]interface{}
+		first    error
+	)
+	set := func(key, val string) {
+		if resource == nil {
+			resource = make(map[string]interface{})
+		}
+		resource[key] = val
+	}
+
+	if attrs := env(OTelResourceAttributes.String()); attrs != "" {
+		for _, pair := range strings.Split(attrs, ",") {
+			key, val, ok := strings.Cut(pair, "=")
+			key = strings.TrimSpace(key)
+			var err error
+			if !ok || key == "" {
+				err = fmt.Errorf("invalid attribute %q, want key=value", strings.TrimSpace(pair))
+			} else if val, err = url.PathUnescape(strings.TrimSpace(val)); err == nil {
+				set(key, val)
+			}
+			if err != nil && first == nil {
+				first = fmt.Errorf("log: Invalid %s (%s): %w", OTelResourceAttributes, attrs, err)
+			}
+		}
+	}
+	if name := env(OTelServiceName.String()); name != "" {
+		set("service.name", name)
+	}
+	return resource, first
 }
 
 // NOTE(PH): increase as we add logger implementations.
@@ -102,7 +484,10 @@ var loggerFactories = make(map[string]newLoggerFn, 4)
 type newLoggerFn func(*Config, ...Option) (Logger, error)
 
 // Open returns a new instance of the selected Logger with config and
-// options.
+// options. config is set to DefaultConfig(nil) if nil. An error is
+// returned if config is invalid, see Config.Validate, though a nil
+// Output is allowed and drivers then write to os.Stderr. The LogFile
+// read by DefaultConfig is opened here if Output is still nil.
 func Open(name string, config *Config, opts ...Option) (Logger, error) {
 	nl, ok := loggerFactories[name]
 	if !ok {
@@ -112,6 +497,20 @@ func Open(name string, config *Config, opts ...Option) (Logger, error) {
 	if config == nil {
 		config = DefaultConfig(nil)
 	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if config.Output == nil && config.file != nil {
+		f, err := OpenSharedFile(*config.file)
+		if err != nil {
+			return nil, fmt.Errorf("log: Failed to open %s (%s): %w", LogFile, config.file.Path, err)
+		}
+
+		// Copy config so that the caller's Output is left unset.
+		c := *config
+		c.Output = f
+		config = &c
+	}
 
 	l, err := nl(config, opts...)
 	if err != nil && os.Getenv("SENTRY_DSN") != "" {
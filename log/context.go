@@ -1,6 +1,9 @@
 package log
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 // Avoid collisions by using package-scoped types for context keys.
 type ctxKey int
@@ -38,3 +41,66 @@ func EntryFromCtx(ctx context.Context) Entry {
 	e, _ := ctx.Value(EntryKey).(Entry)
 	return e
 }
+
+// CtxFieldsFunc returns fields describing ctx to add to the Loggers
+// returned by FromCtx, eg: the IDs of the trace span in ctx. It may
+// return nil.
+type CtxFieldsFunc func(ctx context.Context) map[string]interface{}
+
+var (
+	ctxFieldsMu sync.RWMutex
+	ctxFields   []*CtxFieldsFunc // Pointers, so that they can be removed.
+)
+
+// RegisterCtxFields registers fn to add fields to the Loggers returned
+// by FromCtx. Packages such as github.com/secureworks/logger/otel
+// register themselves when imported. The returned function unregisters
+// fn, eg: at the end of a test.
+func RegisterCtxFields(fn CtxFieldsFunc) (unregister func()) {
+	ctxFieldsMu.Lock()
+	defer ctxFieldsMu.Unlock()
+
+	p := &fn
+	ctxFields = append(ctxFields, p)
+	return func() {
+		ctxFieldsMu.Lock()
+		defer ctxFieldsMu.Unlock()
+
+		for i, f := range ctxFields {
+			if f == p {
+				ctxFields = append(ctxFields[:i:i], ctxFields[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// FromCtx returns the Logger in ctx, or a Noop Logger if none exists,
+// with the fields returned by the registered CtxFieldsFuncs bound to it
+// using With. Fields from later registered functions take precedence.
+// Their keys are mapped by the Schema of the Logger, if any.
+//
+//	log.FromCtx(ctx).Info().Msg("handled request")
+func FromCtx(ctx context.Context) Logger {
+	l := LoggerFromCtx(ctx)
+	if l == nil {
+		return Noop()
+	}
+
+	ctxFieldsMu.RLock()
+	defer ctxFieldsMu.RUnlock()
+
+	var fields map[string]interface{}
+	for _, fn := range ctxFields {
+		for k, v := range (*fn)(ctx) {
+			if fields == nil {
+				fields = make(map[string]interface{})
+			}
+			fields[k] = v
+		}
+	}
+	if fields == nil {
+		return l
+	}
+	return l.With(SchemaOf(l).Fields(fields))
+}
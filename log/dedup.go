@@ -0,0 +1,184 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keys for the fields of the summary entries written when duplicate
+// entries are suppressed, see DedupConfig.
+const (
+	// SuppressedMessage is a key for Logger data holding the message of
+	// the suppressed entries.
+	SuppressedMessage = "suppressed_msg"
+
+	// SuppressedCount is a key for Logger data holding the number of
+	// suppressed entries.
+	SuppressedCount = "suppressed_count"
+)
+
+// DedupConfig configures the suppression of duplicate entries, and is
+// set using Config.Dedup. Entries are duplicates if they have the same
+// level, message and values for each of Fields.
+//
+// The first entry is written and starts a window during which its
+// duplicates are suppressed. When the window ends, if any duplicates
+// were suppressed, a summary entry is written at the same level with
+// the message "suppressed N similar entries", the SuppressedMessage and
+// SuppressedCount fields, and the Fields of the entries. The next
+// duplicate after that is written and starts a new window. Flushing or
+// closing the Logger ends every window, writing the pending summaries.
+//
+// PANIC and FATAL entries are never suppressed.
+type DedupConfig struct {
+	// Window is how long duplicates of an entry are suppressed for. If it
+	// is zero or less entries are not suppressed.
+	Window time.Duration
+
+	// Fields are the keys of fields that, with the level and message,
+	// identify duplicate entries.
+	Fields []string
+}
+
+// DedupSummary describes the entries suppressed during a window, see
+// DedupConfig.
+type DedupSummary struct {
+	Level   Level
+	Message string
+	Fields  map[string]interface{}
+	Count   int
+}
+
+// Msg returns the message of the summary entry.
+func (s DedupSummary) Msg() string {
+	return fmt.Sprintf("suppressed %d similar entries", s.Count)
+}
+
+// Deduper is used by Logger implementations to apply a DedupConfig. It
+// should be shared by a Logger and its children. A nil *Deduper allows
+// every entry.
+type Deduper struct {
+	window  time.Duration
+	fields  []string
+	summary func(DedupSummary)
+
+	mu      sync.Mutex
+	windows map[string]*dedupWindow
+}
+
+// A window during which duplicates of an entry are suppressed.
+type dedupWindow struct {
+	DedupSummary
+	timer *time.Timer
+}
+
+// NewDeduper returns a Deduper for config, or nil if config is nil or
+// does not suppress entries. When a window ends with suppressed
+// entries, summary is called (from its own goroutine, or from Flush)
+// and should write the summary entry without passing it to the Deduper.
+func NewDeduper(config *DedupConfig, summary func(DedupSummary)) *Deduper {
+	if config == nil || config.Window <= 0 {
+		return nil
+	}
+	return &Deduper{
+		window:  config.Window,
+		fields:  config.Fields,
+		summary: summary,
+		windows: make(map[string]*dedupWindow),
+	}
+}
+
+// IsKeyField reports whether key is one of the DedupConfig.Fields.
+// Logger implementations that can not read back the fields of an entry
+// use it to decide which values to keep for Allow.
+func (d *Deduper) IsKeyField(key string) bool {
+	if d == nil {
+		return false
+	}
+	for _, f := range d.fields {
+		if f == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow reports whether an enabled Entry with the given level, message
+// and fields should be written. Only the values of the key fields are
+// read from fields.
+func (d *Deduper) Allow(lvl Level, msg string, fields map[string]interface{}) bool {
+	if d == nil || lvl >= PANIC {
+		return true
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d\x00%s", lvl, msg)
+	for _, f := range d.fields {
+		if val, ok := fields[f]; ok {
+			fmt.Fprintf(&sb, "\x00%v", val)
+		} else {
+			sb.WriteString("\x00\x01")
+		}
+	}
+	key := sb.String()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if w, ok := d.windows[key]; ok {
+		w.Count++
+		return false
+	}
+
+	w := &dedupWindow{DedupSummary: DedupSummary{Level: lvl, Message: msg}}
+	for _, f := range d.fields {
+		if val, ok := fields[f]; ok {
+			if w.Fields == nil {
+				w.Fields = make(map[string]interface{}, len(d.fields))
+			}
+			w.Fields[f] = val
+		}
+	}
+	d.windows[key] = w
+	w.timer = time.AfterFunc(d.window, func() { d.expire(key, w) })
+	return true
+}
+
+// Flush ends every window, writing the summaries of those with
+// suppressed entries before it returns. Logger implementations call it
+// when they are flushed or closed, so that no summary is lost or written
+// after the output is closed.
+func (d *Deduper) Flush() {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	windows := d.windows
+	d.windows = make(map[string]*dedupWindow)
+	d.mu.Unlock()
+
+	for _, w := range windows {
+		w.timer.Stop()
+		if w.Count > 0 && d.summary != nil {
+			d.summary(w.DedupSummary)
+		}
+	}
+}
+
+// Ends the window w, unless it was already ended by Flush.
+func (d *Deduper) expire(key string, w *dedupWindow) {
+	d.mu.Lock()
+	if d.windows[key] != w {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.windows, key)
+	d.mu.Unlock()
+
+	if w.Count > 0 && d.summary != nil {
+		d.summary(w.DedupSummary)
+	}
+}
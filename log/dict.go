@@ -0,0 +1,93 @@
+package log
+
+import (
+	"time"
+)
+
+// Dict adds the fields of a nested object, see Entry.WithDict and
+// ObjectMarshaler. Its methods return the Dict so calls may be chained,
+// eg:
+//
+//	entry.WithDict("http", func(d log.Dict) {
+//		d.Str("method", r.Method).Int("status", status)
+//	})
+//
+// Keys of nested fields are matched by a RedactionPolicy as the path of
+// the field, eg: "http.authorization".
+type Dict interface {
+	Str(key, val string) Dict
+	Bool(key string, val bool) Dict
+	Int(key string, val int) Dict
+	Int64(key string, val int64) Dict
+	Uint64(key string, val uint64) Dict
+	Float(key string, val float64) Dict
+	Dur(key string, val time.Duration) Dict
+	Time(key string, val time.Time) Dict
+
+	// Field adds a field of any type, as Entry.WithField does.
+	Field(key string, val interface{}) Dict
+
+	// Dict adds a nested object whose fields are added by fn.
+	Dict(key string, fn func(Dict)) Dict
+
+	// Object adds obj as a nested object, or null if obj is nil.
+	Object(key string, obj ObjectMarshaler) Dict
+}
+
+// ObjectMarshaler is implemented by types that log themselves as a
+// nested object, using Entry.WithObject or Dict.Object, eg:
+//
+//	func (u *User) MarshalLogObject(d log.Dict) {
+//		d.Str("id", u.ID).Str("email", u.Email)
+//	}
+//
+// Drivers with native support for nested objects, such as the zerolog
+// driver, encode them without reflection.
+type ObjectMarshaler interface {
+	MarshalLogObject(Dict)
+}
+
+// DictFields returns the fields added by fn, with nested objects as
+// maps. It is used by drivers without native support for nested
+// objects, which write them as a map[string]interface{} field.
+func DictFields(fn func(Dict)) map[string]interface{} {
+	d := make(mapDict)
+	if fn != nil {
+		fn(d)
+	}
+	return d
+}
+
+// ObjectFields returns the fields of obj as DictFields does, or nil if
+// obj is nil.
+func ObjectFields(obj ObjectMarshaler) map[string]interface{} {
+	if obj == nil {
+		return nil
+	}
+	return DictFields(obj.MarshalLogObject)
+}
+
+// mapDict implements Dict by setting fields in a map.
+type mapDict map[string]interface{}
+
+var _ Dict = mapDict(nil)
+
+func (d mapDict) Str(key, val string) Dict               { d[key] = val; return d }
+func (d mapDict) Bool(key string, val bool) Dict         { d[key] = val; return d }
+func (d mapDict) Int(key string, val int) Dict           { d[key] = val; return d }
+func (d mapDict) Int64(key string, val int64) Dict       { d[key] = val; return d }
+func (d mapDict) Uint64(key string, val uint64) Dict     { d[key] = val; return d }
+func (d mapDict) Float(key string, val float64) Dict     { d[key] = val; return d }
+func (d mapDict) Dur(key string, val time.Duration) Dict { d[key] = val; return d }
+func (d mapDict) Time(key string, val time.Time) Dict    { d[key] = val; return d }
+func (d mapDict) Field(key string, val interface{}) Dict { d[key] = val; return d }
+
+func (d mapDict) Dict(key string, fn func(Dict)) Dict {
+	d[key] = DictFields(fn)
+	return d
+}
+
+func (d mapDict) Object(key string, obj ObjectMarshaler) Dict {
+	d[key] = ObjectFields(obj)
+	return d
+}
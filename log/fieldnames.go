@@ -0,0 +1,113 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldNames are the names of the fields holding the core values of
+// each entry. Every driver writes them the same way, so that the logs
+// of services using different drivers share one schema, eg:
+//
+//	config.FieldNames = log.FieldNames{Message: "msg", Time: "ts"}
+//
+// Empty names are those of DefaultFieldNames.
+type FieldNames struct {
+	Message string
+	Level   string
+	Time    string
+	Error   string
+	Caller  string
+	Stack   string
+}
+
+// DefaultFieldNames returns the names used for each entry by default:
+// MessageField, LevelField, TimeField, ErrorField, CallerField and
+// StackField.
+func DefaultFieldNames() FieldNames {
+	return FieldNames{
+		Message: MessageField,
+		Level:   LevelField,
+		Time:    TimeField,
+		Error:   ErrorField,
+		Caller:  CallerField,
+		Stack:   StackField,
+	}
+}
+
+// WithDefaults returns the names with those that are empty set to the
+// DefaultFieldNames.
+func (names FieldNames) WithDefaults() FieldNames {
+	return FieldNames{
+		Message: nameOr(names.Message, MessageField),
+		Level:   nameOr(names.Level, LevelField),
+		Time:    nameOr(names.Time, TimeField),
+		Error:   nameOr(names.Error, ErrorField),
+		Caller:  nameOr(names.Caller, CallerField),
+		Stack:   nameOr(names.Stack, StackField),
+	}
+}
+
+// Returns name, or def if name is empty.
+func nameOr(name, def string) string {
+	if name == "" {
+		return def
+	}
+	return name
+}
+
+// LevelCase is the case Loggers write levels in, see Config.LevelCase.
+type LevelCase int
+
+const (
+	// LowerCaseLevels writes levels as "info", "warn" and so on.
+	LowerCaseLevels LevelCase = iota
+
+	// UpperCaseLevels writes levels as "INFO", "WARN" and so on, as
+	// Level.String does.
+	UpperCaseLevels
+)
+
+var lowerLevelNames = [...]string{"trace", "debug", "info", "warn", "error", "panic", "fatal"}
+
+// ParseLevelCase returns the LevelCase named by str, "lower" or
+// "upper", ignoring case and surrounding white space. For unknown names
+// it returns LowerCaseLevels with an error.
+func ParseLevelCase(str string) (LevelCase, error) {
+	switch strings.ToLower(strings.TrimSpace(str)) {
+	case "lower":
+		return LowerCaseLevels, nil
+	case "upper":
+		return UpperCaseLevels, nil
+	}
+	return LowerCaseLevels, fmt.Errorf("unknown level case %q, want lower or upper", str)
+}
+
+// String returns "lower" or "upper", or "LevelCase(n)" for invalid
+// values.
+func (c LevelCase) String() string {
+	switch c {
+	case LowerCaseLevels:
+		return "lower"
+	case UpperCaseLevels:
+		return "upper"
+	}
+	return fmt.Sprintf("LevelCase(%d)", int(c))
+}
+
+// IsValid reports whether c is LowerCaseLevels or UpperCaseLevels.
+func (c LevelCase) IsValid() bool {
+	return c == LowerCaseLevels || c == UpperCaseLevels
+}
+
+// Name returns the name of lvl as written by Loggers in the case c.
+// Invalid levels are named as by Level.String.
+func (c LevelCase) Name(lvl Level) string {
+	if !lvl.IsValid() {
+		return lvl.String()
+	}
+	if c == UpperCaseLevels {
+		return lvl.String()
+	}
+	return lowerLevelNames[lvl-TRACE]
+}
@@ -0,0 +1,391 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileConfig configures a FileWriter.
+type FileConfig struct {
+	// Path is the file written to. Its directory is created if needed.
+	Path string
+
+	// MaxSize is the size in bytes the file may reach before it is
+	// rotated. It is not rotated by size if MaxSize is zero or less.
+	MaxSize int64
+
+	// Interval is how often the file is rotated, aligned to multiples of
+	// Interval since the zero time, eg: at midnight UTC if it is 24h. It
+	// is not rotated by time if Interval is zero or less.
+	Interval time.Duration
+
+	// MaxBackups is the number of rotated files kept; older ones are
+	// removed. All are kept if MaxBackups is zero or less.
+	MaxBackups int
+
+	// Compress gzips rotated files.
+	Compress bool
+
+	// ReopenOnSIGHUP reopens the file when the process receives SIGHUP,
+	// for use with external tools such as logrotate. It is ignored on
+	// Windows.
+	ReopenOnSIGHUP bool
+}
+
+// FileWriter is an io.WriteCloser that writes to a file, rotating it by
+// size and by time. Rotated files are renamed with the time of the
+// rotation, eg: "agent.log" is rotated to
+// "agent-2006-01-02T15-04-05.000.log". It is safe for concurrent use,
+// including by Loggers with different drivers.
+type FileWriter struct {
+	config FileConfig
+	stop   func() // Stops reopening on SIGHUP.
+
+	mu     sync.Mutex
+	file   *os.File // Nil if it could not be reopened, see Write.
+	size   int64
+	rotate time.Time // When the file is rotated by time.
+	closed bool
+
+	cleanup sync.Mutex // Serializes compressing and removing backups.
+	wg      sync.WaitGroup
+}
+
+var _ io.WriteCloser = (*FileWriter)(nil)
+
+// OpenFile opens a new FileWriter for config, appending to the file if
+// it exists.
+func OpenFile(config FileConfig) (*FileWriter, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("log: FileConfig.Path is not set")
+	}
+
+	w := &FileWriter{config: config}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	w.stop = func() {}
+	if config.ReopenOnSIGHUP {
+		w.stop = reopenOnSIGHUP(w)
+	}
+	return w, nil
+}
+
+// Write writes p to the file, first rotating it if p would take it past
+// FileConfig.MaxSize or the rotation interval has passed. If the file
+// could not be opened when it was last rotated, it is opened again.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.needsRotation(int64(len(p))) {
+		if err := w.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file now.
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		return w.open()
+	}
+	return w.rotateLocked()
+}
+
+// Reopen reopens the file, eg: after it was moved by an external tool.
+// The old file is closed once the new one is open, and is kept if the
+// new one cannot be opened.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	old := w.file
+	if err := w.open(); err != nil {
+		return err
+	}
+	if old == nil {
+		return nil
+	}
+	return old.Close()
+}
+
+// Close closes the file, waiting for rotated files to be compressed.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+	}
+	w.file = nil
+	w.closed = true
+	w.mu.Unlock()
+
+	w.stop()
+	w.wg.Wait()
+
+	sharedFilesMu.Lock()
+	if sharedFiles[w.config.Path] == w {
+		delete(sharedFiles, w.config.Path)
+	}
+	sharedFilesMu.Unlock()
+	return err
+}
+
+// FileWriters opened by OpenSharedFile, by path.
+var (
+	sharedFilesMu sync.Mutex
+	sharedFiles   = make(map[string]*FileWriter)
+)
+
+// LoadEnv sets the fields of c from the LogFile… env vars set in env:
+// Path from LogFile, and the other fields from the others. Invalid
+// values are skipped, and the first is returned.
+func (c *FileConfig) LoadEnv(env func(string) string) error {
+	var first error
+	report := func(key EnvKey, str string, err error) {
+		if first == nil {
+			first = fmt.Errorf("log: Invalid %s (%s): %w", key, str, err)
+		}
+	}
+
+	if path := env(LogFile.String()); path != "" {
+		c.Path = path
+	}
+	if str := env(LogFileMaxSize.String()); str != "" {
+		if mb, err := strconv.ParseInt(str, 10, 64); err != nil {
+			report(LogFileMaxSize, str, err)
+		} else {
+			c.MaxSize = mb << 20
+		}
+	}
+	if str := env(LogFileInterval.String()); str != "" {
+		if d, err := time.ParseDuration(str); err != nil {
+			report(LogFileInterval, str, err)
+		} else {
+			c.Interval = d
+		}
+	}
+	if str := env(LogFileMaxBackups.String()); str != "" {
+		if n, err := strconv.Atoi(str); err != nil {
+			report(LogFileMaxBackups, str, err)
+		} else {
+			c.MaxBackups = n
+		}
+	}
+	if str := env(LogFileCompress.String()); str != "" {
+		if b, err := strconv.ParseBool(str); err != nil {
+			report(LogFileCompress, str, err)
+		} else {
+			c.Compress = b
+		}
+	}
+	return first
+}
+
+// OpenSharedFile returns the FileWriter shared by the Configs writing to
+// config.Path, such as those set by the LogFile env var, opening it
+// with config if it is not already open. Closing it closes it for all
+// of them.
+func OpenSharedFile(config FileConfig) (*FileWriter, error) {
+	sharedFilesMu.Lock()
+	defer sharedFilesMu.Unlock()
+
+	if w, ok := sharedFiles[config.Path]; ok {
+		return w, nil
+	}
+	w, err := OpenFile(config)
+	if err != nil {
+		return nil, err
+	}
+	sharedFiles[config.Path] = w
+	return w, nil
+}
+
+// Opens the file for appending.
+func (w *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.config.Path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	if w.config.Interval > 0 {
+		w.rotate = time.Now().Truncate(w.config.Interval).Add(w.config.Interval)
+	}
+	return nil
+}
+
+func (w *FileWriter) needsRotation(n int64) bool {
+	if w.config.MaxSize > 0 && w.size > 0 && w.size+n > w.config.MaxSize {
+		return true
+	}
+	return w.config.Interval > 0 && !time.Now().Before(w.rotate)
+}
+
+// Renames the file to a backup and opens a new one. The file is closed
+// first, as open files cannot be renamed on Windows, so if the new one
+// cannot be opened the file is set to nil and Write opens it again.
+func (w *FileWriter) rotateLocked() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return err
+	}
+	backup := w.backupName(time.Now())
+	renameErr := os.Rename(w.config.Path, backup)
+	if err := w.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		if os.IsNotExist(renameErr) {
+			return nil // Moved by an external tool.
+		}
+		return renameErr // The file is appended to.
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.cleanupBackups(backup)
+	}()
+	return nil
+}
+
+// Splits the path into the prefix and extension of backup names.
+func (w *FileWriter) backupParts() (prefix, ext string) {
+	ext = filepath.Ext(w.config.Path)
+	return strings.TrimSuffix(w.config.Path, ext) + "-", ext
+}
+
+// Returns an unused backup name for a rotation at t, moving t forward
+// if the file was already rotated within the same millisecond.
+func (w *FileWriter) backupName(t time.Time) string {
+	prefix, ext := w.backupParts()
+	for {
+		name := prefix + t.Format(backupTimeFormat) + ext
+		if !fileExists(name) && !fileExists(name+".gz") {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// Compresses the new backup, if configured, and removes old backups.
+func (w *FileWriter) cleanupBackups(backup string) {
+	w.cleanup.Lock()
+	defer w.cleanup.Unlock()
+
+	if w.config.Compress {
+		if err := gzipFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "log: Failed to compress %s: %v\n", backup, err)
+		}
+	}
+	if w.config.MaxBackups <= 0 {
+		return
+	}
+
+	prefix, ext := w.backupParts()
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return
+	}
+	var backups []string
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimSuffix(m[len(prefix):], ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	if len(backups) <= w.config.MaxBackups {
+		return
+	}
+
+	// The time format sorts chronologically.
+	sort.Strings(backups)
+	for _, old := range backups[:len(backups)-w.config.MaxBackups] {
+		_ = os.Remove(old)
+	}
+}
+
+// Replaces the file at path with a gzipped copy at path+".gz".
+func gzipFile(path string) (err error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	_ = in.Close() // Before removing it, for Windows.
+	return os.Remove(path)
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Reopens the file of w each time the process receives SIGHUP, until
+// the returned function is called.
+func reopenOnSIGHUP(w *FileWriter) (stop func()) {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sig, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-sig:
+				if err := w.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "log: Failed to reopen %s: %v\n", w.config.Path, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
@@ -0,0 +1,6 @@
+package log
+
+// SIGHUP is not supported on Windows.
+func reopenOnSIGHUP(_ *FileWriter) (stop func()) {
+	return func() {}
+}
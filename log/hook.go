@@ -0,0 +1,220 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Hook is called with each entry written at one of its Levels, and is
+// added to a Logger using the WithHooks Option. Unlike the hooks of the
+// logger implementations, Hooks work with any Logger that implements
+// HookLogger.
+//
+// Hooks are fired synchronously, in the order they were added, after an
+// Entry is enabled and passes deduplication and sampling, and before it
+// is written. Hooks on PANIC and FATAL entries are fired before the
+// Logger panics or exits. A Hook that does expensive work should pass
+// the Record to another goroutine.
+type Hook interface {
+	// Levels returns the levels the Hook is fired for.
+	Levels() []Level
+
+	// Fire is called with the Record of an entry. Errors are reported
+	// to the Hooks' error handler; they do not stop the entry or other
+	// Hooks.
+	Fire(*Record) error
+}
+
+// HookLogger is implemented by Loggers that support Hooks.
+type HookLogger interface {
+	// Hooks returns the Hooks of the Logger, shared with its children.
+	Hooks() *Hooks
+}
+
+// WithHooks returns an Option that adds the hooks to a Logger in the
+// given order. It returns an error if the Logger does not implement
+// HookLogger.
+func WithHooks(hooks ...Hook) Option {
+	return func(l interface{}) error {
+		hl, ok := l.(HookLogger)
+		if !ok {
+			return fmt.Errorf("log: Logger type (%T) does not support the HookLogger interface", l)
+		}
+		hl.Hooks().Add(hooks...)
+		return nil
+	}
+}
+
+// WithHookErrorHandler returns an Option that sets the function called
+// with the errors returned by Hooks. By default they are written to
+// os.Stderr. It returns an error if the Logger does not implement
+// HookLogger.
+func WithHookErrorHandler(handler func(Hook, error)) Option {
+	return func(l interface{}) error {
+		hl, ok := l.(HookLogger)
+		if !ok {
+			return fmt.Errorf("log: Logger type (%T) does not support the HookLogger interface", l)
+		}
+		hl.Hooks().SetErrorHandler(handler)
+		return nil
+	}
+}
+
+// Hooks is used by Logger implementations to hold and fire Hooks. It
+// is safe for concurrent use. A nil *Hooks has no Hooks.
+type Hooks struct {
+	mu      sync.RWMutex
+	hooks   []Hook
+	levels  [][]Level // Levels of each of hooks, read once when added.
+	onError func(Hook, error)
+}
+
+// Add adds hooks to be fired after those already added.
+func (h *Hooks) Add(hooks ...Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, hook := range hooks {
+		if hook == nil {
+			continue
+		}
+		h.hooks = append(h.hooks, hook)
+		h.levels = append(h.levels, hook.Levels())
+	}
+}
+
+// SetErrorHandler sets the function called with the errors returned by
+// Hooks. If handler is nil errors are written to os.Stderr.
+func (h *Hooks) SetErrorHandler(handler func(Hook, error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onError = handler
+}
+
+// Len returns the number of Hooks. Logger implementations that can not
+// read back the fields of an entry use it to decide whether to keep
+// them for the Record.
+func (h *Hooks) Len() int {
+	if h == nil {
+		return 0
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.hooks)
+}
+
+// Enabled reports whether any of the Hooks is fired for lvl.
+func (h *Hooks) Enabled(lvl Level) bool {
+	if h == nil {
+		return false
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, levels := range h.levels {
+		if hasLevel(levels, lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fire fires the Hooks for the level of r in the order they were added,
+// reporting any errors they return.
+func (h *Hooks) Fire(r *Record) {
+	if h == nil {
+		return
+	}
+	// The Hooks are called without holding the lock, so that they may log
+	// or add Hooks. Add only appends, so the slices read here do not
+	// change.
+	h.mu.RLock()
+	hooks, levels, onError := h.hooks, h.levels, h.onError
+	h.mu.RUnlock()
+
+	for i, hook := range hooks {
+		if !hasLevel(levels[i], r.level) {
+			continue
+		}
+		if err := hook.Fire(r); err != nil {
+			if onError != nil {
+				onError(hook, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "log: Failed to fire hook (%T): %v\n", hook, err)
+			}
+		}
+	}
+}
+
+func hasLevel(levels []Level, lvl Level) bool {
+	for _, l := range levels {
+		if l == lvl {
+			return true
+		}
+	}
+	return false
+}
+
+// Record is a read-only view of an entry, passed to Hooks. The fields
+// include those bound to the Logger, and have been redacted according
+// to Config.Redaction.
+type Record struct {
+	level  Level
+	msg    string
+	fields map[string]interface{}
+	err    error
+	caller []string
+	time   time.Time
+}
+
+// NewRecord is used by Logger implementations to create the Record of
+// an entry. The fields should not include the error or the callers,
+// and should not be modified once the Record is created.
+func NewRecord(lvl Level, msg string, fields map[string]interface{}, err error, caller []string, t time.Time) *Record {
+	return &Record{
+		level:  lvl,
+		msg:    msg,
+		fields: fields,
+		err:    err,
+		caller: caller,
+		time:   t,
+	}
+}
+
+// Level returns the level of the entry.
+func (r *Record) Level() Level { return r.level }
+
+// Message returns the message of the entry.
+func (r *Record) Message() string { return r.msg }
+
+// Err returns the error set using Entry.WithError, or nil. If several
+// errors were set they are combined into one.
+func (r *Record) Err() error { return r.err }
+
+// Time returns the time the entry was sent.
+func (r *Record) Time() time.Time { return r.time }
+
+// Field returns the value of the field key and whether it is set.
+func (r *Record) Field(key string) (interface{}, bool) {
+	val, ok := r.fields[key]
+	return val, ok
+}
+
+// Fields returns a copy of the fields of the entry.
+func (r *Record) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(r.fields))
+	for k, v := range r.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// Caller returns a copy of the callers added using Entry.Caller.
+func (r *Record) Caller() []string {
+	if len(r.caller) == 0 {
+		return nil
+	}
+	return append([]string(nil), r.caller...)
+}
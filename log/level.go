@@ -0,0 +1,110 @@
+package log
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// levelEnabler is implemented by all the Loggers in this module, but is
+// not part of the Logger interface.
+type levelEnabler interface {
+	IsLevelEnabled(Level) bool
+}
+
+// LevelController is implemented by Loggers whose level can be changed
+// at runtime, eg:
+//
+//	if lc, ok := logger.(log.LevelController); ok {
+//	    lc.SetLevel(log.DEBUG)
+//	}
+//
+// Implementations must be safe for concurrent use. A change applies to
+// every Entry sent after it, including Entries created before it, and
+// to child Loggers created using With.
+type LevelController interface {
+	// SetLevel changes the level at which the Logger is enabled.
+	SetLevel(Level)
+
+	// Level returns the level at which the Logger is enabled.
+	Level() Level
+}
+
+// AtomicLevel is a Level that can be read and changed concurrently. It
+// implements LevelController, and is useful for Logger implementations
+// that share a level between a Logger, its children and its Entries.
+// The zero value is INFO.
+type AtomicLevel struct {
+	lvl int32
+}
+
+var _ LevelController = (*AtomicLevel)(nil)
+
+// NewAtomicLevel returns an AtomicLevel set to lvl.
+func NewAtomicLevel(lvl Level) *AtomicLevel {
+	a := new(AtomicLevel)
+	a.SetLevel(lvl)
+	return a
+}
+
+// Level returns the current level.
+func (a *AtomicLevel) Level() Level {
+	return Level(atomic.LoadInt32(&a.lvl))
+}
+
+// SetLevel changes the current level.
+func (a *AtomicLevel) SetLevel(lvl Level) {
+	atomic.StoreInt32(&a.lvl, int32(lvl))
+}
+
+// LevelOverrides maps the names of components, as given to
+// Logger.Named, to the level of their Loggers. An override also applies
+// to the sub-components of its component: "http" applies to
+// "http.client" unless there is a more specific "http.client" override.
+type LevelOverrides map[string]Level
+
+// Match returns the most specific override for the named component:
+// either name itself or its longest dot-separated prefix. The key of
+// the override is returned with its level.
+func (o LevelOverrides) Match(name string) (key string, lvl Level, ok bool) {
+	for key = name; key != ""; {
+		if lvl, ok = o[key]; ok {
+			return key, lvl, true
+		}
+
+		i := strings.LastIndexByte(key, '.')
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return "", 0, false
+}
+
+// NamedLevel is used by Logger implementations to determine the level
+// of a Logger named name that is created from a Logger named parent. If
+// inherit is true the child should share the level of its parent, such
+// that LevelController changes to either apply to both; otherwise lvl
+// is the initial level of the child.
+func (o LevelOverrides) NamedLevel(parent, name string) (lvl Level, inherit bool) {
+	key, lvl, ok := o.Match(name)
+	if !ok {
+		return 0, true
+	}
+	if pkey, _, pok := o.Match(parent); pok && pkey == key {
+		return 0, true
+	}
+	return lvl, false
+}
+
+// JoinName returns the full name of a component named name within the
+// component parent, as used by Logger.Named.
+func JoinName(parent, name string) string {
+	switch {
+	case parent == "":
+		return name
+	case name == "":
+		return parent
+	default:
+		return parent + "." + name
+	}
+}
@@ -3,10 +3,22 @@
 // drivers, including Logrus and Zerolog, along with support for
 // reporting services including Sentry.
 //
+// A Config is built using DefaultConfig and env vars. Reading a Config
+// from a YAML, TOML or JSON file is done by the config package
+// (config.Load and config.Open) rather than by this package, so that
+// programs which do not read config files do not depend on the YAML
+// and TOML parsers.
+//
 package log
 
 import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"io"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -42,30 +54,87 @@ const (
 type Level int
 
 // LevelFromString parses str and returns the closest level. If one
-// isn't found the default level is returned.
+// isn't found the default level is returned; use ParseLevel to have an
+// error returned instead.
 func LevelFromString(str string) (lvl Level) {
+	lvl, _ = ParseLevel(str)
+	return
+}
+
+// ParseLevel parses the level name str, ignoring case and surrounding
+// space. Besides the names returned by Level.String it accepts the
+// aliases "warning" (WARN), "err" (ERROR) and "crit" (PANIC). If str is
+// not a known name the default level and an error are returned.
+func ParseLevel(str string) (Level, error) {
+	str = strings.TrimSpace(str)
 	switch strings.ToUpper(str) {
 	case "TRACE":
-		lvl = TRACE
+		return TRACE, nil
 	case "DEBUG":
-		lvl = DEBUG
+		return DEBUG, nil
 	case "INFO":
-		lvl = INFO
-	case "WARN":
-		lvl = WARN
-	case "ERROR":
-		lvl = ERROR
-	case "PANIC":
-		lvl = PANIC
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR", "ERR":
+		return ERROR, nil
+	case "PANIC", "CRIT":
+		return PANIC, nil
 	case "FATAL":
-		lvl = FATAL
+		return FATAL, nil
 	}
+	return INFO, fmt.Errorf("unknown level %q, want TRACE, DEBUG, INFO, WARN, ERROR, PANIC or FATAL", str)
+}
 
-	// Default case isn't needed, default is determined by enum zero
-	// value.
+var levelNames = [...]string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "PANIC", "FATAL"}
+
+var _ fmt.Stringer = Level(0)
+var _ encoding.TextMarshaler = Level(0)
+var _ encoding.TextUnmarshaler = (*Level)(nil)
+var _ json.Marshaler = Level(0)
+var _ flag.Value = (*Level)(nil)
+
+// String returns the name of the level, eg: "WARN", or "Level(n)" if it
+// is not valid.
+func (l Level) String() string {
+	if !l.IsValid() {
+		return "Level(" + strconv.Itoa(int(l)) + ")"
+	}
+	return levelNames[l-TRACE]
+}
+
+// MarshalText encodes the level as its name, returning an error if it
+// is not valid.
+func (l Level) MarshalText() ([]byte, error) {
+	if !l.IsValid() {
+		return nil, fmt.Errorf("log: Invalid level (%d)", l)
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a level name using ParseLevel. It is also used
+// to decode JSON strings.
+func (l *Level) UnmarshalText(text []byte) (err error) {
+	*l, err = ParseLevel(string(text))
 	return
 }
 
+// MarshalJSON encodes the level as a JSON string holding its name,
+// returning an error if it is not valid.
+func (l Level) MarshalJSON() ([]byte, error) {
+	text, err := l.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// Set sets the level from its name using ParseLevel, so that a *Level
+// can be used as a flag.Value.
+func (l *Level) Set(str string) error {
+	return l.UnmarshalText([]byte(str))
+}
+
 // IsValid checks if the current level is valid relative to known
 // values.
 func (l Level) IsValid() bool {
@@ -101,6 +170,17 @@ const (
 	// ImplementationDefaultFormat leaves the format up to the logger
 	// implementation default.
 	ImplementationDefaultFormat LoggerFormat = -1
+
+	// LogfmtFormat writes logfmt (key=value) lines. The Zerolog and Logrus
+	// drivers write identical lines: level, time and msg first, followed
+	// by the remaining fields sorted by key.
+	LogfmtFormat LoggerFormat = 1
+
+	// ConsoleFormat writes human-readable lines for local development:
+	// a short timestamp, a colorized level, the message and sorted
+	// fields, with stack traces rendered over multiple lines. Color is
+	// used when the output is a terminal.
+	ConsoleFormat LoggerFormat = 2
 )
 
 // LoggerFormat is the base type for logging formats supported by this
@@ -110,13 +190,91 @@ type LoggerFormat int
 // IsValid checks if a logger format is valid.
 func (l LoggerFormat) IsValid() bool {
 	switch l {
-	case ImplementationDefaultFormat, JSONFormat:
+	case ImplementationDefaultFormat, JSONFormat, LogfmtFormat, ConsoleFormat:
 		return true
 	default:
 		return false
 	}
 }
 
+// ParseFormat parses the LoggerFormat str, ignoring case and
+// surrounding space: either a name returned by LoggerFormat.String or
+// its integer value. If str is not a known format JSONFormat and an
+// error are returned.
+func ParseFormat(str string) (LoggerFormat, error) {
+	str = strings.TrimSpace(str)
+	switch strings.ToLower(str) {
+	case "json":
+		return JSONFormat, nil
+	case "default":
+		return ImplementationDefaultFormat, nil
+	case "logfmt":
+		return LogfmtFormat, nil
+	case "console":
+		return ConsoleFormat, nil
+	}
+
+	if f, err := strconv.Atoi(str); err == nil && LoggerFormat(f).IsValid() {
+		return LoggerFormat(f), nil
+	}
+	return JSONFormat, fmt.Errorf("unknown format %q, want json, logfmt, console or default", str)
+}
+
+var _ fmt.Stringer = LoggerFormat(0)
+var _ encoding.TextMarshaler = LoggerFormat(0)
+var _ encoding.TextUnmarshaler = (*LoggerFormat)(nil)
+var _ json.Marshaler = LoggerFormat(0)
+var _ flag.Value = (*LoggerFormat)(nil)
+
+// String returns the name of the format, eg: "logfmt", or
+// "LoggerFormat(n)" if it is not valid.
+func (l LoggerFormat) String() string {
+	switch l {
+	case JSONFormat:
+		return "json"
+	case ImplementationDefaultFormat:
+		return "default"
+	case LogfmtFormat:
+		return "logfmt"
+	case ConsoleFormat:
+		return "console"
+	default:
+		return "LoggerFormat(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// MarshalText encodes the format as its name, returning an error if it
+// is not valid.
+func (l LoggerFormat) MarshalText() ([]byte, error) {
+	if !l.IsValid() {
+		return nil, fmt.Errorf("log: Invalid format (%d)", l)
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a format using ParseFormat. It is also used to
+// decode JSON strings.
+func (l *LoggerFormat) UnmarshalText(text []byte) (err error) {
+	*l, err = ParseFormat(string(text))
+	return
+}
+
+// MarshalJSON encodes the format as a JSON string holding its name,
+// returning an error if it is not valid.
+func (l LoggerFormat) MarshalJSON() ([]byte, error) {
+	text, err := l.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// Set sets the format using ParseFormat, so that a *LoggerFormat can be
+// used as a flag.Value.
+func (l *LoggerFormat) Set(str string) error {
+	return l.UnmarshalText([]byte(str))
+}
+
 // Keys for standard logging fields. These keys can be used as map keys,
 // JSON field names, or logger-implementation specific identifiers. By
 // regularizing them we can make better assumptions about where to find
@@ -144,6 +302,22 @@ const (
 	// traces.
 	PanicValue = "panic_value"
 
+	// MessageField is a key for Logger data holding the message of an
+	// entry, see FieldNames.
+	MessageField = "message"
+
+	// LevelField is a key for Logger data holding the level of an entry,
+	// see FieldNames.
+	LevelField = "level"
+
+	// TimeField is a key for Logger data holding the time of an entry,
+	// see FieldNames.
+	TimeField = "time"
+
+	// ErrorField is a key for Logger data concerning errors and stack
+	// traces, see FieldNames.
+	ErrorField = "error"
+
 	// CallerField is a key for Logger data concerning errors and stack
 	// traces.
 	CallerField = "caller"
@@ -151,6 +325,42 @@ const (
 	// StackField is a key for Logger data concerning errors and stack
 	// traces.
 	StackField = "stack"
+
+	// LoggerNameField is a key for Logger data holding the name given to
+	// a Logger using Named.
+	LoggerNameField = "logger"
+
+	// TraceIDField is a key for Logger data holding the ID of the trace
+	// an entry was logged in, as a hex string.
+	TraceIDField = "trace_id"
+
+	// SpanIDField is a key for Logger data holding the ID of the span an
+	// entry was logged in, as a hex string.
+	SpanIDField = "span_id"
+
+	// TraceFlagsField is a key for Logger data holding the W3C trace
+	// flags of the span an entry was logged in, as a hex string.
+	TraceFlagsField = "trace_flags"
+
+	// EnvironmentField is a key for Logger data holding the deployment
+	// environment, see Config.StaticFields.
+	EnvironmentField = "environment"
+
+	// ServiceField is a key for Logger data holding the name of the
+	// service logging, see Config.StaticFields.
+	ServiceField = "service"
+
+	// VersionField is a key for Logger data holding the version of the
+	// service logging, see Config.StaticFields.
+	VersionField = "version"
+
+	// HostField is a key for Logger data holding the hostname, see
+	// Config.StaticFields.
+	HostField = "host"
+
+	// PIDField is a key for Logger data holding the process ID, see
+	// Config.StaticFields.
+	PIDField = "pid"
 )
 
 // Unified interface definitions.
@@ -177,6 +387,19 @@ type Logger interface {
 	// returns the Entry.
 	WithFields(fields map[string]interface{}) Entry
 
+	// With returns a child Logger that inserts the given set of fields
+	// into every Entry it creates. The receiver is not modified, and
+	// fields set on an Entry take precedence over those bound with With.
+	// Calls may be chained to build up a set of fields.
+	With(fields map[string]interface{}) Logger
+
+	// Named returns a child Logger for the named component. Names of
+	// nested calls are joined with dots, eg: "http" then "client" gives
+	// "http.client". The full name is written in the LoggerNameField, and
+	// the level of the child is taken from the most specific match in
+	// Config.LevelOverrides, or from the receiver if there is none.
+	Named(name string) Logger
+
 	// Entry returns a new Entry at the provided log level.
 	Entry(Level) Entry
 
@@ -277,6 +500,47 @@ type Entry interface {
 	// formatting may be dependant on configuration or logger choice.
 	WithTime(key string, ts ...time.Time) Entry
 
+	// WithFloat is a type-safe convenience for injecting a float64 (or
+	// float64s, how they are stored is implmentation-specific) field.
+	WithFloat(key string, fs ...float64) Entry
+
+	// WithInt64 is a type-safe convenience for injecting an int64 (or
+	// int64s, how they are stored is implmentation-specific) field.
+	WithInt64(key string, is ...int64) Entry
+
+	// WithUint64 is a type-safe convenience for injecting a uint64 (or
+	// uint64s, how they are stored is implmentation-specific) field.
+	WithUint64(key string, us ...uint64) Entry
+
+	// WithBytes injects the bytes as a string field, eg: text read from
+	// a file.
+	WithBytes(key string, b []byte) Entry
+
+	// WithHex injects the bytes as a hex-encoded string field, eg: a
+	// digest.
+	WithHex(key string, b []byte) Entry
+
+	// WithIP is a type-safe convenience for injecting an IP address (or
+	// addresses) field, written as a string (or strings).
+	WithIP(key string, ips ...net.IP) Entry
+
+	// WithStringer injects the result of val.String() as a string field,
+	// or null if val is nil.
+	WithStringer(key string, val fmt.Stringer) Entry
+
+	// WithJSON injects raw, which must be valid JSON, as the value of a
+	// field; it is written as is by the JSON formats. It is useful for
+	// values that are already encoded, eg: a request body.
+	WithJSON(key string, raw []byte) Entry
+
+	// WithDict injects a nested object field whose fields are added by
+	// fn, see Dict.
+	WithDict(key string, fn func(Dict)) Entry
+
+	// WithObject injects obj as a nested object field, or null if obj is
+	// nil, see ObjectMarshaler.
+	WithObject(key string, obj ObjectMarshaler) Entry
+
 	// Trace updates the Entry's level to TRACE.
 	Trace() Entry
 
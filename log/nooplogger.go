@@ -1,7 +1,9 @@
 package log
 
 import (
+	"fmt"
 	"io"
+	"net"
 	"time"
 )
 
@@ -26,12 +28,17 @@ func Noop() Logger {
 type noopLogger struct{}
 
 var _ Logger = (*noopLogger)(nil)
+var _ LevelController = (*noopLogger)(nil)
+var _ Flusher = (*noopLogger)(nil)
 
 func (noopLogger) IsLevelEnabled(lvl Level) bool             { return false }
 func (noopLogger) WithError(_ error) Entry                   { return noopEntry{} }
 func (noopLogger) WithField(_ string, _ interface{}) Entry   { return noopEntry{} }
 func (noopLogger) WithFields(_ map[string]interface{}) Entry { return noopEntry{} }
 
+func (n noopLogger) With(_ map[string]interface{}) Logger { return n }
+func (n noopLogger) Named(_ string) Logger                { return n }
+
 func (noopLogger) Entry(_ Level) Entry { return noopEntry{} }
 func (noopLogger) Trace() Entry        { return noopEntry{} }
 func (noopLogger) Debug() Entry        { return noopEntry{} }
@@ -43,11 +50,20 @@ func (noopLogger) Fatal() Entry        { return noopEntry{} }
 
 func (n noopLogger) WriteCloser(_ Level) io.WriteCloser { return n }
 
+// LevelController implementation; the level is always INFO.
+
+func (noopLogger) SetLevel(_ Level) {}
+func (noopLogger) Level() Level     { return INFO }
+
 // WriteCloser implementation.
 
 func (noopLogger) Write(p []byte) (int, error) { return len(p), nil }
 func (noopLogger) Close() error                { return nil }
 
+// Flusher implementation.
+
+func (noopLogger) Flush() error { return nil }
+
 // Entry implementation.
 
 type noopEntry struct{}
@@ -57,15 +73,25 @@ var _ Entry = (*noopEntry)(nil)
 func (n noopEntry) Async() Entry          { return n }
 func (n noopEntry) Caller(_ ...int) Entry { return n }
 
-func (n noopEntry) WithError(_ ...error) Entry                 { return n }
-func (n noopEntry) WithField(_ string, _ interface{}) Entry    { return n }
-func (n noopEntry) WithFields(_ map[string]interface{}) Entry  { return n }
-func (n noopEntry) WithBool(_ string, _ ...bool) Entry         { return n }
-func (n noopEntry) WithDur(_ string, _ ...time.Duration) Entry { return n }
-func (n noopEntry) WithInt(_ string, _ ...int) Entry           { return n }
-func (n noopEntry) WithUint(_ string, _ ...uint) Entry         { return n }
-func (n noopEntry) WithStr(_ string, _ ...string) Entry        { return n }
-func (n noopEntry) WithTime(_ string, _ ...time.Time) Entry    { return n }
+func (n noopEntry) WithError(_ ...error) Entry                   { return n }
+func (n noopEntry) WithField(_ string, _ interface{}) Entry      { return n }
+func (n noopEntry) WithFields(_ map[string]interface{}) Entry    { return n }
+func (n noopEntry) WithBool(_ string, _ ...bool) Entry           { return n }
+func (n noopEntry) WithDur(_ string, _ ...time.Duration) Entry   { return n }
+func (n noopEntry) WithInt(_ string, _ ...int) Entry             { return n }
+func (n noopEntry) WithUint(_ string, _ ...uint) Entry           { return n }
+func (n noopEntry) WithStr(_ string, _ ...string) Entry          { return n }
+func (n noopEntry) WithTime(_ string, _ ...time.Time) Entry      { return n }
+func (n noopEntry) WithFloat(_ string, _ ...float64) Entry       { return n }
+func (n noopEntry) WithInt64(_ string, _ ...int64) Entry         { return n }
+func (n noopEntry) WithUint64(_ string, _ ...uint64) Entry       { return n }
+func (n noopEntry) WithBytes(_ string, _ []byte) Entry           { return n }
+func (n noopEntry) WithHex(_ string, _ []byte) Entry             { return n }
+func (n noopEntry) WithIP(_ string, _ ...net.IP) Entry           { return n }
+func (n noopEntry) WithStringer(_ string, _ fmt.Stringer) Entry  { return n }
+func (n noopEntry) WithJSON(_ string, _ []byte) Entry            { return n }
+func (n noopEntry) WithDict(_ string, _ func(Dict)) Entry        { return n }
+func (n noopEntry) WithObject(_ string, _ ObjectMarshaler) Entry { return n }
 
 func (n noopEntry) Trace() Entry { return n }
 func (n noopEntry) Debug() Entry { return n }
@@ -0,0 +1,291 @@
+package log
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Redacted replaces the values of redacted fields and the secrets found
+// in values and messages.
+const Redacted = "[REDACTED]"
+
+// Redactable is implemented by types that redact themselves before they
+// are logged. Redact returns the value to log in their place, which is
+// itself subject to the RedactionPolicy.
+type Redactable interface {
+	Redact() interface{}
+}
+
+// RedactionPolicy describes the values that Loggers redact before they
+// are encoded, and is set using Config.Redaction. Field values, errors
+// set using Entry.WithError, values bound using Logger.With and messages
+// are redacted.
+//
+// A field is replaced with Redacted if its key matches one of Keys;
+// otherwise, string values, including those nested in maps and slices,
+// have the secrets matched by Scrubbers replaced. Values that implement
+// Redactable are replaced with the result of their Redact method first.
+//
+// Other values are redacted as they are encoded: errors, fmt.Stringers
+// and encoding.TextMarshalers are replaced with their scrubbed text if
+// it has secrets, and structs, pointers and json.Marshalers are
+// replaced with their JSON, decoded into maps and slices, if it has
+// denied keys or secrets. Values without anything to redact are logged
+// as they are, and so are values that fail to encode.
+//
+// A nil *RedactionPolicy redacts nothing.
+type RedactionPolicy struct {
+	// Keys are the denied field keys, matched case-insensitively. Keys
+	// containing dots are paths matched against the full path to nested
+	// map keys, eg: "headers.authorization" matches the "Authorization"
+	// key of the "headers" field; other keys match at any depth.
+	Keys []string
+
+	// Scrubbers find secrets in string values.
+	Scrubbers []Scrubber
+}
+
+// Scrubber finds secrets in string values.
+type Scrubber struct {
+	// Pattern matches the secrets.
+	Pattern *regexp.Regexp
+
+	// Valid, if set, reports whether a match is a secret, eg: by checking
+	// a checksum. Matches are secrets if it is nil.
+	Valid func(match string) bool
+}
+
+// Scrubbers used by DefaultRedactionPolicy.
+var (
+	// JWTScrubber finds JSON Web Tokens.
+	JWTScrubber = Scrubber{
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	}
+
+	// AWSKeyScrubber finds AWS access key IDs.
+	AWSKeyScrubber = Scrubber{
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AIDA|AROA)[0-9A-Z]{16}\b`),
+	}
+
+	// CreditCardScrubber finds credit card numbers of 13 to 19 digits,
+	// optionally separated by spaces or dashes, that pass the Luhn
+	// check.
+	CreditCardScrubber = Scrubber{
+		Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Valid:   luhnValid,
+	}
+)
+
+// DefaultRedactionPolicy returns a new RedactionPolicy that denies keys
+// commonly holding credentials, and scrubs JWTs, AWS access key IDs and
+// credit card numbers. Enable it with:
+//
+//	config.Redaction = log.DefaultRedactionPolicy()
+func DefaultRedactionPolicy() *RedactionPolicy {
+	return &RedactionPolicy{
+		Keys: []string{
+			"password", "passwd", "secret", "client_secret",
+			"token", "access_token", "refresh_token", "id_token",
+			"api_key", "apikey", "x-api-key", "private_key",
+			"authorization", "proxy-authorization", "x-auth-token",
+			"cookie", "set-cookie", "aws_secret_access_key",
+		},
+		Scrubbers: []Scrubber{JWTScrubber, AWSKeyScrubber, CreditCardScrubber},
+	}
+}
+
+// IsDenied reports whether the value of the field key is redacted.
+func (p *RedactionPolicy) IsDenied(key string) bool {
+	if p == nil {
+		return false
+	}
+	return p.isDenied(key, lastSegment(key))
+}
+
+// Redact returns the value to log for the field key in place of val.
+func (p *RedactionPolicy) Redact(key string, val interface{}) interface{} {
+	if p == nil {
+		return val
+	}
+	return p.redact(key, lastSegment(key), val)
+}
+
+// RedactFields returns a copy of fields with each value redacted, or
+// fields itself if p is nil.
+func (p *RedactionPolicy) RedactFields(fields map[string]interface{}) map[string]interface{} {
+	if p == nil || fields == nil {
+		return fields
+	}
+	out := make(map[string]interface{}, len(fields))
+	for k, val := range fields {
+		out[k] = p.Redact(k, val)
+	}
+	return out
+}
+
+// Scrub returns s with the secrets found by the Scrubbers replaced.
+func (p *RedactionPolicy) Scrub(s string) string {
+	if p == nil {
+		return s
+	}
+	for _, sc := range p.Scrubbers {
+		if sc.Pattern == nil {
+			continue
+		}
+		s = sc.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if sc.Valid != nil && !sc.Valid(match) {
+				return match
+			}
+			return Redacted
+		})
+	}
+	return s
+}
+
+func (p *RedactionPolicy) isDenied(path, key string) bool {
+	for _, k := range p.Keys {
+		if strings.Contains(k, ".") {
+			if strings.EqualFold(k, path) {
+				return true
+			}
+		} else if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RedactionPolicy) redact(path, key string, val interface{}) interface{} {
+	if p.isDenied(path, key) {
+		return Redacted
+	}
+	if r, ok := val.(Redactable); ok {
+		val = r.Redact()
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return val
+	}
+
+	switch v := val.(type) {
+	case nil, bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, float32, float64,
+		time.Time, time.Duration:
+		return v
+	case string:
+		return p.Scrub(v)
+	case []string:
+		if v == nil {
+			return v
+		}
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = p.Scrub(s)
+		}
+		return out
+	case error:
+		return p.scrubText(v, v.Error())
+	case json.Marshaler:
+		return p.redactJSON(path, key, v)
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return v
+		}
+		return p.scrubText(v, string(text))
+	case fmt.Stringer:
+		return p.scrubText(v, v.String())
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return p.scrubText(val, rv.String())
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String || rv.IsNil() {
+			return val
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			out[k] = p.redact(path+"."+k, k, iter.Value().Interface())
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && (rv.IsNil() || rv.Type().Elem().Kind() == reflect.Uint8) {
+			return val // nil or []byte
+		}
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			out[i] = p.redact(path, key, rv.Index(i).Interface())
+		}
+		return out
+	case reflect.Struct, reflect.Ptr:
+		return p.redactJSON(path, key, val)
+	}
+	return val
+}
+
+// Returns the scrubbed text of val if it has secrets, otherwise val.
+func (p *RedactionPolicy) scrubText(val interface{}, text string) interface{} {
+	if scrubbed := p.Scrub(text); scrubbed != text {
+		return scrubbed
+	}
+	return val
+}
+
+// Returns the JSON encoding of val, decoded and redacted, if that
+// redacts anything, otherwise val.
+func (p *RedactionPolicy) redactJSON(path, key string, val interface{}) interface{} {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return val
+	}
+	var decoded interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		return val
+	}
+
+	if redacted := p.redact(path, key, decoded); !reflect.DeepEqual(redacted, decoded) {
+		return redacted
+	}
+	return val
+}
+
+func lastSegment(path string) string {
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// Checks the digits of s, ignoring separators, using the Luhn
+// algorithm.
+func luhnValid(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler decides which entries are written, and is set using
+// Config.Sampler. Logger implementations consult it when an enabled
+// Entry is sent. Implementations must be safe for concurrent use.
+//
+// By default only levels below ERROR are sampled: a Config.Sampler that
+// is not a LevelSampler is used as if it were set in a LevelSampler for
+// each of TRACE, DEBUG, INFO and WARN. Use a LevelSampler to sample
+// ERROR entries. PANIC and FATAL entries are always written.
+type Sampler interface {
+	// Sample reports whether an Entry at the given level is written.
+	Sample(lvl Level) bool
+}
+
+// DroppedCounter is implemented by Loggers that count the entries
+// dropped by their Config.Sampler, and those dropped by the AsyncWriter
+// used for Config.Async with DropOnOverflow. Child Loggers share the
+// count of the Logger they were created from.
+type DroppedCounter interface {
+	// Dropped returns the number of entries dropped so far.
+	Dropped() uint64
+}
+
+// LevelSampler samples each level with its own Sampler; levels without
+// one are not sampled. For example, to write the first 100 DEBUG
+// entries each second and then every 10th, and half of the ERROR
+// entries:
+//
+//	config.Sampler = log.LevelSampler{
+//	    log.DEBUG: log.NewBurstSampler(100, 10, time.Second),
+//	    log.ERROR: log.NewRandomSampler(0.5),
+//	}
+type LevelSampler map[Level]Sampler
+
+// Sample implements Sampler.
+func (ls LevelSampler) Sample(lvl Level) bool {
+	s, ok := ls[lvl]
+	return !ok || s == nil || s.Sample(lvl)
+}
+
+// NewBurstSampler returns a Sampler that, for each level, writes the
+// first n entries in every interval and then every mth entry until the
+// interval ends. If m is zero or less no more entries are written in
+// the interval; if interval is zero or less it never ends.
+func NewBurstSampler(n, m int, interval time.Duration) Sampler {
+	return &burstSampler{n: n, m: m, interval: interval}
+}
+
+type burstSampler struct {
+	n, m     int
+	interval time.Duration
+
+	mu     sync.Mutex
+	counts [FATAL - TRACE + 1]burstCount
+}
+
+type burstCount struct {
+	resetAt time.Time
+	count   int
+}
+
+func (s *burstSampler) Sample(lvl Level) bool {
+	if lvl < TRACE || lvl > FATAL {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := &s.counts[lvl-TRACE]
+	if s.interval > 0 {
+		if now := time.Now(); !now.Before(c.resetAt) {
+			c.resetAt = now.Add(s.interval)
+			c.count = 0
+		}
+	}
+
+	c.count++
+	if c.count <= s.n {
+		return true
+	}
+	return s.m > 0 && (c.count-s.n)%s.m == 0
+}
+
+// NewRandomSampler returns a Sampler that writes each entry with the
+// probability rate, between 0 and 1.
+func NewRandomSampler(rate float64) Sampler {
+	return randomSampler(rate)
+}
+
+type randomSampler float64
+
+func (s randomSampler) Sample(_ Level) bool {
+	return rand.Float64() < float64(s)
+}
+
+// Sampling is used by Logger implementations to apply a Sampler and
+// count the entries it drops. It should be shared by a Logger and its
+// children. A nil *Sampling writes every entry.
+type Sampling struct {
+	sampler Sampler
+	dropped uint64
+}
+
+var _ DroppedCounter = (*Sampling)(nil)
+
+// NewSampling returns a Sampling for s, or nil if s is nil. See Sampler
+// for the levels s is used for.
+func NewSampling(s Sampler) *Sampling {
+	if s == nil {
+		return nil
+	}
+	if _, ok := s.(LevelSampler); !ok {
+		s = LevelSampler{TRACE: s, DEBUG: s, INFO: s, WARN: s}
+	}
+	return &Sampling{sampler: s}
+}
+
+// Sample reports whether an enabled Entry at the given level should be
+// written, and counts it if not. PANIC and FATAL entries are always
+// written.
+func (s *Sampling) Sample(lvl Level) bool {
+	if s == nil || lvl >= PANIC || s.sampler.Sample(lvl) {
+		return true
+	}
+	atomic.AddUint64(&s.dropped, 1)
+	return false
+}
+
+// Dropped returns the number of entries dropped so far.
+func (s *Sampling) Dropped() uint64 {
+	if s == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&s.dropped)
+}
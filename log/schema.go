@@ -0,0 +1,325 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a preset of the settings that make entries conform to a log
+// schema understood by a log management system, such as ECSSchema or
+// GCPSchema. Enable one with:
+//
+//	config.Schema = log.ECSSchema()
+//
+// or by setting the LogSchema env var. Settings of the Config take
+// precedence over those of its Schema; see Config.Schema.
+type Schema struct {
+	// Name is the name of the schema, as accepted by ParseSchema.
+	Name string
+
+	// FieldNames are the names of the fields holding the core values of
+	// each entry; empty names are those of DefaultFieldNames.
+	FieldNames FieldNames
+
+	// LevelCase is the case levels are written in, and Levels are names
+	// written for some levels in place of those of LevelCase.
+	LevelCase LevelCase
+	Levels    map[Level]string
+
+	// TimeFormat and UTC set how the time of each entry is written, as
+	// for Config.
+	TimeFormat string
+	UTC        bool
+
+	// Keys maps the keys of fields written by this module to those of
+	// the schema. It applies to Config.StaticFields, the fields bound by
+	// FromCtx, such as TraceIDField, and the Req… fields written by
+	// middleware.NewHTTPRequestMiddleware.
+	Keys map[string]string
+
+	// HTTPRequestKey, if set, is the key of an object holding the Req…
+	// fields written by middleware.NewHTTPRequestMiddleware, keyed as
+	// mapped by Keys, rather than writing them as separate fields.
+	HTTPRequestKey string
+
+	// DurationFormat is how ReqDuration is written: one of the
+	// DurationFormat… constants.
+	DurationFormat string
+
+	// TraceIDFormat, if set, is the format, for fmt.Sprintf with the ID
+	// as its single operand, that TraceIDField is written with, eg:
+	// "projects/my-project/traces/%s".
+	TraceIDFormat string
+
+	// TraceSampled writes TraceFlagsField as a bool reporting whether
+	// the sampled flag is set, rather than as hex flags.
+	TraceSampled bool
+}
+
+// Values of Schema.DurationFormat.
+const (
+	// DurationFormatString writes durations as time.Duration.String
+	// does, eg: "1.5ms".
+	DurationFormatString = ""
+
+	// DurationFormatNanos writes durations as a number of nanoseconds.
+	DurationFormatNanos = "nanos"
+
+	// DurationFormatSeconds writes durations as a number of seconds
+	// followed by "s", eg: "0.0015s", as protobuf Durations are written
+	// in JSON.
+	DurationFormatSeconds = "seconds"
+)
+
+// ECSSchema returns a new Schema for the Elastic Common Schema
+// (https://www.elastic.co/guide/en/ecs/current/index.html), eg:
+//
+//	{"@timestamp":"2020-01-02T03:04:05.678Z","log.level":"info","message":"handled request","http.request.method":"GET","url.original":"/","event.duration":1500000}
+func ECSSchema() *Schema {
+	return &Schema{
+		Name: "ecs",
+		FieldNames: FieldNames{
+			Message: "message",
+			Level:   "log.level",
+			Time:    "@timestamp",
+			Error:   "error.message",
+			Caller:  "log.origin.file.name",
+			Stack:   "error.stack_trace",
+		},
+		LevelCase:  LowerCaseLevels,
+		TimeFormat: time.RFC3339Nano,
+		UTC:        true,
+		Keys: map[string]string{
+			ReqMethod:        "http.request.method",
+			ReqPath:          "url.original",
+			ReqRemoteAddr:    "client.address",
+			ReqDuration:      "event.duration",
+			TraceIDField:     "trace.id",
+			SpanIDField:      "span.id",
+			EnvironmentField: "service.environment",
+			ServiceField:     "service.name",
+			VersionField:     "service.version",
+			HostField:        "host.hostname",
+			PIDField:         "process.pid",
+		},
+		DurationFormat: DurationFormatNanos,
+	}
+}
+
+// GCPSchema returns a new Schema for the structured logs of Google Cloud
+// Logging (https://cloud.google.com/logging/docs/structured-logging),
+// eg:
+//
+//	{"time":"2020-01-02T03:04:05.678Z","severity":"INFO","message":"handled request","httpRequest":{"requestMethod":"GET","requestUrl":"/","latency":"0.0015s"}}
+//
+// Levels are written as LogSeverity names: TRACE as "DEBUG", WARN as
+// "WARNING", PANIC as "ALERT" and FATAL as "EMERGENCY".
+//
+// Cloud Logging only links entries to traces whose IDs are written as
+// "projects/<PROJECT>/traces/<ID>", which needs the ID of the project:
+// use GCPProjectSchema, or set the GoogleCloudProject env var. Without
+// it the trace ID is written as is.
+func GCPSchema() *Schema {
+	return &Schema{
+		Name: "gcp",
+		FieldNames: FieldNames{
+			Message: "message",
+			Level:   "severity",
+			Time:    "time",
+			Error:   "error",
+			Caller:  "caller",
+			Stack:   "stack_trace",
+		},
+		LevelCase: UpperCaseLevels,
+		Levels: map[Level]string{
+			TRACE: "DEBUG",
+			WARN:  "WARNING",
+			PANIC: "ALERT",
+			FATAL: "EMERGENCY",
+		},
+		TimeFormat: time.RFC3339Nano,
+		UTC:        true,
+		Keys: map[string]string{
+			ReqMethod:       "requestMethod",
+			ReqPath:         "requestUrl",
+			ReqRemoteAddr:   "remoteIp",
+			ReqDuration:     "latency",
+			TraceIDField:    "logging.googleapis.com/trace",
+			SpanIDField:     "logging.googleapis.com/spanId",
+			TraceFlagsField: "logging.googleapis.com/trace_sampled",
+		},
+		HTTPRequestKey: "httpRequest",
+		DurationFormat: DurationFormatSeconds,
+		TraceSampled:   true,
+	}
+}
+
+// GCPProjectSchema returns a new GCPSchema that writes trace IDs as
+// "projects/<project>/traces/<ID>", so that Cloud Logging links entries
+// to their traces.
+func GCPProjectSchema(project string) *Schema {
+	s := GCPSchema()
+	s.TraceIDFormat = "projects/" + project + "/traces/%s"
+	return s
+}
+
+// ParseSchema returns a new Schema named by str: "ecs" for ECSSchema, or
+// "gcp" or "stackdriver" for GCPSchema, ignoring case and surrounding
+// white space. For unknown names it returns nil with an error.
+func ParseSchema(str string) (*Schema, error) {
+	switch strings.ToLower(strings.TrimSpace(str)) {
+	case "ecs":
+		return ECSSchema(), nil
+	case "gcp", "stackdriver":
+		return GCPSchema(), nil
+	}
+	return nil, fmt.Errorf("unknown schema %q, want ecs or gcp", str)
+}
+
+// Key returns the key of the schema for key, or key if the schema does
+// not map it. It returns key for a nil Schema.
+func (s *Schema) Key(key string) string {
+	if s == nil {
+		return key
+	}
+	if mapped, ok := s.Keys[key]; ok {
+		return mapped
+	}
+	return key
+}
+
+// Fields returns fields with their keys mapped by Key, and the values of
+// TraceIDField and TraceFlagsField written as set by TraceIDFormat and
+// TraceSampled. It returns fields itself if none are mapped.
+func (s *Schema) Fields(fields map[string]interface{}) map[string]interface{} {
+	var mapped map[string]interface{}
+	for key := range fields {
+		if s.Key(key) != key || s.mapsValue(key) {
+			mapped = make(map[string]interface{}, len(fields))
+			break
+		}
+	}
+	if mapped == nil {
+		return fields
+	}
+	for key, val := range fields {
+		mapped[s.Key(key)] = s.value(key, val)
+	}
+	return mapped
+}
+
+// Reports whether the schema writes the value of key differently.
+func (s *Schema) mapsValue(key string) bool {
+	if s == nil {
+		return false
+	}
+	return (key == TraceIDField && s.TraceIDFormat != "") ||
+		(key == TraceFlagsField && s.TraceSampled)
+}
+
+// Returns val as the schema writes it for key. Trace flags that are not
+// hex are written as is.
+func (s *Schema) value(key string, val interface{}) interface{} {
+	if !s.mapsValue(key) {
+		return val
+	}
+	if key == TraceIDField {
+		return fmt.Sprintf(s.TraceIDFormat, val)
+	}
+	flags, err := strconv.ParseUint(fmt.Sprint(val), 16, 8)
+	if err != nil {
+		return val
+	}
+	return flags&1 == 1
+}
+
+// Duration returns d as it is written in the DurationFormat of the
+// schema: an int64 for DurationFormatNanos, otherwise a string. It
+// returns d.String() for a nil Schema.
+func (s *Schema) Duration(d time.Duration) interface{} {
+	if s == nil {
+		return d.String()
+	}
+	switch s.DurationFormat {
+	case DurationFormatNanos:
+		return d.Nanoseconds()
+	case DurationFormatSeconds:
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+	}
+	return d.String()
+}
+
+// SchemaLogger is implemented by Loggers that write the fields of a
+// Schema, so that fields written by other packages, such as middleware,
+// can be keyed as the schema keys them.
+type SchemaLogger interface {
+	// Schema returns the Schema of the Logger, or nil if it has none.
+	Schema() *Schema
+}
+
+// SchemaOf returns the Schema of logger if it implements SchemaLogger,
+// otherwise nil. The methods of Schema may be called on nil.
+func SchemaOf(logger Logger) *Schema {
+	if sl, ok := logger.(SchemaLogger); ok {
+		return sl.Schema()
+	}
+	return nil
+}
+
+// NewFieldNames returns the FieldNames set by config: its FieldNames,
+// with those that are empty set to those of its Schema, then to the
+// DefaultFieldNames. It is used by the drivers so that they all name
+// fields the same way.
+func NewFieldNames(config *Config) FieldNames {
+	names := config.FieldNames
+	if s := config.Schema; s != nil {
+		names = FieldNames{
+			Message: nameOr(names.Message, s.FieldNames.Message),
+			Level:   nameOr(names.Level, s.FieldNames.Level),
+			Time:    nameOr(names.Time, s.FieldNames.Time),
+			Error:   nameOr(names.Error, s.FieldNames.Error),
+			Caller:  nameOr(names.Caller, s.FieldNames.Caller),
+			Stack:   nameOr(names.Stack, s.FieldNames.Stack),
+		}
+	}
+	return names.WithDefaults()
+}
+
+// LevelNames are the names Loggers write levels with, as set by the
+// LevelCase and Schema of a Config. It is used by the drivers so that
+// they all write levels the same way.
+type LevelNames struct {
+	names [FATAL - TRACE + 1]string
+}
+
+// NewLevelNames returns the LevelNames set by config: those of its
+// Schema's Levels, otherwise those of its LevelCase, or its Schema's
+// LevelCase if that is LowerCaseLevels.
+func NewLevelNames(config *Config) *LevelNames {
+	c := config.LevelCase
+	if config.Schema != nil && c == LowerCaseLevels {
+		c = config.Schema.LevelCase
+	}
+
+	n := new(LevelNames)
+	for lvl := TRACE; lvl <= FATAL; lvl++ {
+		n.names[lvl-TRACE] = c.Name(lvl)
+		if config.Schema != nil {
+			if name, ok := config.Schema.Levels[lvl]; ok {
+				n.names[lvl-TRACE] = name
+			}
+		}
+	}
+	return n
+}
+
+// Name returns the name of lvl. Invalid levels are named as by
+// Level.String.
+func (n *LevelNames) Name(lvl Level) string {
+	if !lvl.IsValid() {
+		return lvl.String()
+	}
+	return n.names[lvl-TRACE]
+}
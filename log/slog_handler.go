@@ -0,0 +1,243 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// NewSlogHandler returns a slog.Handler that writes slog records as
+// Entries on logger. This allows any Logger to be handed to libraries
+// that only accept a *slog.Logger:
+//
+//	slogger := slog.New(log.NewSlogHandler(logger))
+//
+// Top-level attributes are mapped to the typed With… methods matching
+// their slog.Kind, and values implementing fmt.Stringer are written
+// using WithStringer; groups are written as nested maps using WithField.
+// Attribute values of type error keyed "error" or "err" are attached
+// using WithError.
+//
+// Slog levels are mapped to the closest Level at or below them. Since
+// slog has no notion of panicking or exiting, levels above
+// slog.LevelError are written at ERROR.
+func NewSlogHandler(logger Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+// slogHandler implements slog.Handler. Attributes bound using WithAttrs
+// are stored with the group path that was open at the time.
+type slogHandler struct {
+	logger Logger
+	bound  []groupedAttr
+	groups []string
+}
+
+type groupedAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+var _ slog.Handler = (*slogHandler)(nil)
+
+func (h *slogHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	if le, ok := h.logger.(levelEnabler); ok {
+		return le.IsLevelEnabled(levelFromSlog(lvl))
+	}
+	return true
+}
+
+func (h *slogHandler) Handle(_ context.Context, rec slog.Record) error {
+	entry := h.logger.Entry(levelFromSlog(rec.Level))
+
+	// Top-level attributes are written directly; grouped attributes are
+	// collected into nested maps keyed by their outermost group.
+	var nested map[string]interface{}
+	add := func(groups []string, attr slog.Attr) {
+		attr.Value = attr.Value.Resolve()
+		if attr.Equal(slog.Attr{}) {
+			return
+		}
+		if len(groups) == 0 {
+			if attr.Key == "" && attr.Value.Kind() == slog.KindGroup {
+				for _, a := range attr.Value.Group() {
+					addSlogAttr(entry, a)
+				}
+				return
+			}
+			addSlogAttr(entry, attr)
+			return
+		}
+		if nested == nil {
+			nested = make(map[string]interface{})
+		}
+		setGroupedAttr(nested, groups, attr)
+	}
+
+	for _, ga := range h.bound {
+		add(ga.groups, ga.attr)
+	}
+	rec.Attrs(func(attr slog.Attr) bool {
+		add(h.groups, attr)
+		return true
+	})
+	for key, val := range nested {
+		entry.WithField(key, val)
+	}
+
+	entry.Msg(rec.Message)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := h.clone()
+	for _, attr := range attrs {
+		h2.bound = append(h2.bound, groupedAttr{groups: h.groups, attr: attr})
+	}
+	return h2
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	return h2
+}
+
+// Copies the handler such that appending to either slice does not
+// affect the original.
+func (h *slogHandler) clone() *slogHandler {
+	return &slogHandler{
+		logger: h.logger,
+		bound:  append(make([]groupedAttr, 0, len(h.bound)+1), h.bound...),
+		groups: append(make([]string, 0, len(h.groups)+1), h.groups...),
+	}
+}
+
+// Writes a top-level attribute to the entry using the typed With…
+// method matching the attribute value.
+func addSlogAttr(entry Entry, attr slog.Attr) {
+	val := attr.Value
+	switch val.Kind() {
+	case slog.KindString:
+		entry.WithStr(attr.Key, val.String())
+	case slog.KindBool:
+		entry.WithBool(attr.Key, val.Bool())
+	case slog.KindDuration:
+		entry.WithDur(attr.Key, val.Duration())
+	case slog.KindTime:
+		entry.WithTime(attr.Key, val.Time())
+	case slog.KindInt64:
+		entry.WithInt64(attr.Key, val.Int64())
+	case slog.KindUint64:
+		entry.WithUint64(attr.Key, val.Uint64())
+	case slog.KindFloat64:
+		entry.WithFloat(attr.Key, val.Float64())
+	case slog.KindGroup:
+		group := make(map[string]interface{}, len(val.Group()))
+		setGroupedAttr(group, nil, attr)
+		if g, ok := group[attr.Key]; ok {
+			entry.WithField(attr.Key, g)
+		}
+	default:
+		switch v := val.Any().(type) {
+		case error:
+			if attr.Key == "error" || attr.Key == "err" {
+				entry.WithError(v)
+			} else {
+				entry.WithStr(attr.Key, v.Error())
+			}
+		case fmt.Stringer:
+			entry.WithStringer(attr.Key, v)
+		default:
+			entry.WithField(attr.Key, v)
+		}
+	}
+}
+
+// Sets attr in the nested map m at the path given by groups, creating
+// intermediate maps as needed. Group-valued attributes become maps
+// themselves; empty groups are omitted, as slog.Handler requires.
+func setGroupedAttr(m map[string]interface{}, groups []string, attr slog.Attr) {
+	for _, g := range groups {
+		sub, ok := m[g].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			m[g] = sub
+		}
+		m = sub
+	}
+
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() != slog.KindGroup {
+		if !attr.Equal(slog.Attr{}) {
+			m[attr.Key] = slogValueToInterface(attr.Value)
+		}
+		return
+	}
+
+	members := attr.Value.Group()
+	if len(members) == 0 {
+		return
+	}
+	if attr.Key != "" {
+		groups = []string{attr.Key}
+	} else {
+		groups = nil
+	}
+	for _, a := range members {
+		setGroupedAttr(m, groups, a)
+	}
+}
+
+// Converts a (non-group) slog.Value into a value suitable for WithField.
+func slogValueToInterface(val slog.Value) interface{} {
+	switch val.Kind() {
+	case slog.KindString:
+		return val.String()
+	case slog.KindBool:
+		return val.Bool()
+	case slog.KindDuration:
+		return val.Duration()
+	case slog.KindTime:
+		return val.Time()
+	case slog.KindInt64:
+		return val.Int64()
+	case slog.KindUint64:
+		return val.Uint64()
+	case slog.KindFloat64:
+		return val.Float64()
+	default:
+		v := val.Any()
+		if err, ok := v.(error); ok {
+			return err.Error()
+		}
+		return v
+	}
+}
+
+// Maps a slog.Level to the closest Level at or below it.
+func levelFromSlog(lvl slog.Level) Level {
+	switch {
+	case lvl < slog.LevelDebug:
+		return TRACE
+	case lvl < slog.LevelInfo:
+		return DEBUG
+	case lvl < slog.LevelWarn:
+		return INFO
+	case lvl < slog.LevelError:
+		return WARN
+	default:
+		return ERROR
+	}
+}
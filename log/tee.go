@@ -0,0 +1,333 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// Emitter is implemented by Entries that can be written without
+// panicking at PANIC or exiting at FATAL, so that Tee can write an
+// entry to every Logger before it panics or exits once.
+type Emitter interface {
+	// Emit writes the Entry as Send does, without panicking or exiting.
+	Emit()
+}
+
+// Tee returns a Logger that writes each entry to all of loggers, in the
+// given order. Each Logger keeps its own configuration, so the level,
+// format and output (and even the driver) may differ, eg: to write
+// INFO entries as JSON to os.Stdout and DEBUG entries to a file:
+//
+//	stdout, _ := log.Open("zerolog", &log.Config{Level: log.INFO, Output: os.Stdout})
+//	file, _ := log.Open("logrus", &log.Config{Level: log.DEBUG, Output: f})
+//	logger := log.Tee(stdout, file)
+//
+// A level is enabled if it is enabled by any of loggers. PANIC and
+// FATAL entries are written to all of loggers before the returned
+// Logger panics or exits, once, if any of loggers enables the level.
+// Loggers whose Entries do not implement Emitter are written to last at
+// these levels, and a FATAL entry exits when it is written to the first
+// of them.
+func Tee(loggers ...Logger) Logger {
+	if len(loggers) == 1 {
+		return loggers[0]
+	}
+	return teeLogger(loggers)
+}
+
+// Logger implementation.
+
+type teeLogger []Logger
+
+var _ Logger = (teeLogger)(nil)
+var _ Flusher = (teeLogger)(nil)
+var _ io.Closer = (teeLogger)(nil)
+
+func (t teeLogger) IsLevelEnabled(lvl Level) bool {
+	for _, l := range t {
+		if le, ok := l.(levelEnabler); !ok || le.IsLevelEnabled(lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeLogger) WithError(err error) Entry {
+	return t.Error().WithError(err)
+}
+
+func (t teeLogger) WithField(key string, val interface{}) Entry {
+	return t.Entry(INFO).WithField(key, val)
+}
+
+func (t teeLogger) WithFields(fields map[string]interface{}) Entry {
+	return t.Entry(INFO).WithFields(fields)
+}
+
+func (t teeLogger) With(fields map[string]interface{}) Logger {
+	children := make(teeLogger, len(t))
+	for i, l := range t {
+		children[i] = l.With(fields)
+	}
+	return children
+}
+
+func (t teeLogger) Named(name string) Logger {
+	children := make(teeLogger, len(t))
+	for i, l := range t {
+		children[i] = l.Named(name)
+	}
+	return children
+}
+
+func (t teeLogger) Entry(lvl Level) Entry {
+	entries := make([]Entry, len(t))
+	for i, l := range t {
+		// Sinks hold their entries until the tee sends them.
+		entries[i] = l.Entry(lvl).Async()
+	}
+	return &teeEntry{logger: t, entries: entries, lvl: lvl}
+}
+
+func (t teeLogger) Trace() Entry { return t.Entry(TRACE) }
+func (t teeLogger) Debug() Entry { return t.Entry(DEBUG) }
+func (t teeLogger) Info() Entry  { return t.Entry(INFO) }
+func (t teeLogger) Warn() Entry  { return t.Entry(WARN) }
+func (t teeLogger) Error() Entry { return t.Entry(ERROR) }
+func (t teeLogger) Panic() Entry { return t.Entry(PANIC) }
+func (t teeLogger) Fatal() Entry { return t.Entry(FATAL) }
+
+func (t teeLogger) WriteCloser(lvl Level) io.WriteCloser {
+	return teeWriteCloser{log: t, lvl: lvl}
+}
+
+// Flush flushes each of the Loggers that implements Flusher, returning
+// the first error.
+func (t teeLogger) Flush() (err error) {
+	for _, l := range t {
+		if f, ok := l.(Flusher); ok {
+			if ferr := f.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+	}
+	return
+}
+
+// Close closes each of the Loggers that implements io.Closer, returning
+// the first error.
+func (t teeLogger) Close() (err error) {
+	for _, l := range t {
+		if c, ok := l.(io.Closer); ok {
+			if cerr := c.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	}
+	return
+}
+
+// WriteCloser implementation.
+
+type teeWriteCloser struct {
+	log Logger
+	lvl Level
+}
+
+func (wlc teeWriteCloser) Write(p []byte) (n int, err error) {
+	n = len(p)
+	if n > 0 && p[n-1] == '\n' {
+		// Trim CR added by stdlog.
+		p = p[0 : n-1]
+	}
+	wlc.log.Entry(wlc.lvl).Msg(string(p))
+	return
+}
+
+func (wlc teeWriteCloser) Close() error {
+	return nil
+}
+
+// Entry implementation.
+
+type teeEntry struct {
+	logger  teeLogger
+	entries []Entry
+	msg     string
+	lvl     Level
+	async   bool
+}
+
+var _ Entry = (*teeEntry)(nil)
+
+func (e *teeEntry) Async() Entry {
+	e.async = !e.async
+	return e
+}
+
+func (e *teeEntry) Caller(skip ...int) Entry {
+	sk := 1 // Skip this method.
+	if len(skip) > 0 {
+		sk += skip[0]
+	}
+	for _, ent := range e.entries {
+		ent.Caller(sk)
+	}
+	return e
+}
+
+func (e *teeEntry) each(fn func(Entry)) Entry {
+	for _, ent := range e.entries {
+		fn(ent)
+	}
+	return e
+}
+
+func (e *teeEntry) WithError(errs ...error) Entry {
+	return e.each(func(ent Entry) { ent.WithError(errs...) })
+}
+
+func (e *teeEntry) WithField(key string, val interface{}) Entry {
+	return e.each(func(ent Entry) { ent.WithField(key, val) })
+}
+
+func (e *teeEntry) WithFields(fields map[string]interface{}) Entry {
+	return e.each(func(ent Entry) { ent.WithFields(fields) })
+}
+
+func (e *teeEntry) WithBool(key string, bls ...bool) Entry {
+	return e.each(func(ent Entry) { ent.WithBool(key, bls...) })
+}
+
+func (e *teeEntry) WithDur(key string, durs ...time.Duration) Entry {
+	return e.each(func(ent Entry) { ent.WithDur(key, durs...) })
+}
+
+func (e *teeEntry) WithInt(key string, is ...int) Entry {
+	return e.each(func(ent Entry) { ent.WithInt(key, is...) })
+}
+
+func (e *teeEntry) WithUint(key string, us ...uint) Entry {
+	return e.each(func(ent Entry) { ent.WithUint(key, us...) })
+}
+
+func (e *teeEntry) WithStr(key string, strs ...string) Entry {
+	return e.each(func(ent Entry) { ent.WithStr(key, strs...) })
+}
+
+func (e *teeEntry) WithTime(key string, ts ...time.Time) Entry {
+	return e.each(func(ent Entry) { ent.WithTime(key, ts...) })
+}
+
+func (e *teeEntry) WithFloat(key string, fs ...float64) Entry {
+	return e.each(func(ent Entry) { ent.WithFloat(key, fs...) })
+}
+
+func (e *teeEntry) WithInt64(key string, is ...int64) Entry {
+	return e.each(func(ent Entry) { ent.WithInt64(key, is...) })
+}
+
+func (e *teeEntry) WithUint64(key string, us ...uint64) Entry {
+	return e.each(func(ent Entry) { ent.WithUint64(key, us...) })
+}
+
+func (e *teeEntry) WithBytes(key string, b []byte) Entry {
+	return e.each(func(ent Entry) { ent.WithBytes(key, b) })
+}
+
+func (e *teeEntry) WithHex(key string, b []byte) Entry {
+	return e.each(func(ent Entry) { ent.WithHex(key, b) })
+}
+
+func (e *teeEntry) WithIP(key string, ips ...net.IP) Entry {
+	return e.each(func(ent Entry) { ent.WithIP(key, ips...) })
+}
+
+func (e *teeEntry) WithStringer(key string, val fmt.Stringer) Entry {
+	return e.each(func(ent Entry) { ent.WithStringer(key, val) })
+}
+
+func (e *teeEntry) WithJSON(key string, raw []byte) Entry {
+	return e.each(func(ent Entry) { ent.WithJSON(key, raw) })
+}
+
+// The fields are added once and set on each Entry, so that fn is only
+// called once.
+func (e *teeEntry) WithDict(key string, fn func(Dict)) Entry {
+	fields := DictFields(fn)
+	return e.each(func(ent Entry) { ent.WithField(key, fields) })
+}
+
+func (e *teeEntry) WithObject(key string, obj ObjectMarshaler) Entry {
+	fields := ObjectFields(obj)
+	return e.each(func(ent Entry) { ent.WithField(key, fields) })
+}
+
+func (e *teeEntry) Trace() Entry { return e.setLevel(TRACE, Entry.Trace) }
+func (e *teeEntry) Debug() Entry { return e.setLevel(DEBUG, Entry.Debug) }
+func (e *teeEntry) Info() Entry  { return e.setLevel(INFO, Entry.Info) }
+func (e *teeEntry) Warn() Entry  { return e.setLevel(WARN, Entry.Warn) }
+func (e *teeEntry) Error() Entry { return e.setLevel(ERROR, Entry.Error) }
+func (e *teeEntry) Panic() Entry { return e.setLevel(PANIC, Entry.Panic) }
+func (e *teeEntry) Fatal() Entry { return e.setLevel(FATAL, Entry.Fatal) }
+
+func (e *teeEntry) setLevel(lvl Level, fn func(Entry) Entry) Entry {
+	e.lvl = lvl
+	return e.each(func(ent Entry) { fn(ent) })
+}
+
+func (e *teeEntry) Msgf(format string, vals ...interface{}) {
+	e.Msg(fmt.Sprintf(format, vals...))
+}
+
+func (e *teeEntry) Msg(msg string) {
+	e.msg = msg
+	e.each(func(ent Entry) { ent.Msg(msg) })
+	if !e.async {
+		e.Send()
+	}
+}
+
+// Send writes the entry to each Logger, then panics or exits if the
+// level is PANIC or FATAL and enabled by any of them.
+func (e *teeEntry) Send() {
+	entries := e.entries
+	e.entries = nil // Disables future method calls.
+
+	if e.lvl < PANIC {
+		for _, ent := range entries {
+			ent.Send()
+		}
+		return
+	}
+
+	var last []Entry
+	for _, ent := range entries {
+		if em, ok := ent.(Emitter); ok {
+			em.Emit()
+		} else {
+			last = append(last, ent)
+		}
+	}
+	for _, ent := range last {
+		sendRecovered(ent)
+	}
+	if !e.logger.IsLevelEnabled(e.lvl) {
+		return
+	}
+
+	_ = e.logger.Flush()
+	if e.lvl == PANIC {
+		panic(e.msg)
+	}
+	os.Exit(1)
+}
+
+// Sends ent, recovering the panic of a PANIC entry.
+func sendRecovered(ent Entry) {
+	defer func() { _ = recover() }()
+	ent.Send()
+}
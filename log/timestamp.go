@@ -0,0 +1,65 @@
+package log
+
+import (
+	"strconv"
+	"time"
+)
+
+// Timestamp writes the times of entries as set by the FieldNames,
+// TimeFormat, UTC and Schema of a Config. It is used by the drivers so
+// that they all write times the same way.
+type Timestamp struct {
+	Key    string // The name of the field holding the time.
+	Format string // A TimeFormat… constant or a layout, but not TimeFormatNone.
+	UTC    bool
+}
+
+// NewTimestamp returns the Timestamp set by config, or nil if times are
+// not written, ie: TimeFormat is TimeFormatNone.
+func NewTimestamp(config *Config) *Timestamp {
+	ts := &Timestamp{
+		Key:    NewFieldNames(config).Time,
+		Format: config.TimeFormat,
+		UTC:    config.UTC,
+	}
+	if config.Schema != nil {
+		ts.Format = nameOr(ts.Format, config.Schema.TimeFormat)
+		ts.UTC = ts.UTC || config.Schema.UTC
+	}
+	switch ts.Format {
+	case TimeFormatNone:
+		return nil
+	case "":
+		ts.Format = DefaultTimeFormat
+	}
+	return ts
+}
+
+// Value returns t as it is written in JSON: an int64 for the Unix
+// formats, otherwise a string.
+func (ts *Timestamp) Value(t time.Time) interface{} {
+	if ts.UTC {
+		t = t.UTC()
+	}
+	switch ts.Format {
+	case TimeFormatUnix:
+		return t.Unix()
+	case TimeFormatUnixMs:
+		return t.UnixNano() / int64(time.Millisecond)
+	case TimeFormatUnixMicro:
+		return t.UnixNano() / int64(time.Microsecond)
+	case TimeFormatUnixNano:
+		return t.UnixNano()
+	}
+	return t.Format(ts.Format)
+}
+
+// String returns t as it is written in the text formats.
+func (ts *Timestamp) String(t time.Time) string {
+	switch v := ts.Value(t).(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return v.(string)
+	}
+}
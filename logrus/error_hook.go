@@ -13,7 +13,9 @@ import (
 //
 // QUESTION(IB): Is this type necessary? There are tradeoffs doing it in
 // the event versus a hook.
-type errorHook struct{}
+type errorHook struct {
+	names *log.FieldNames
+}
 
 // Levels ensures the hook runs on all levels.
 func (errorHook) Levels() []logrus.Level {
@@ -23,15 +25,15 @@ func (errorHook) Levels() []logrus.Level {
 // Fire ensures that if the event does not have a stack trace field and
 // an error that implements StackTracer, put the error's stack trace in
 // the stack trace field.
-func (errorHook) Fire(entry *logrus.Entry) error {
-	if _, ok := entry.Data[log.StackField]; ok {
+func (h errorHook) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[h.names.Stack]; ok {
 		return nil
 	}
-	st, ok := entry.Data[logrus.ErrorKey].(common.StackTracer)
+	st, ok := entry.Data[h.names.Error].(common.StackTracer)
 	if !ok {
 		return nil
 	}
 
-	entry.Data[log.StackField] = st.StackTrace()
+	entry.Data[h.names.Stack] = st.StackTrace()
 	return nil
 }
@@ -0,0 +1,97 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/secureworks/logger/internal/format"
+	"github.com/secureworks/logger/log"
+)
+
+// textFormatter implements a Logrus formatter
+// (https://github.com/sirupsen/logrus#formatters) for the text formats
+// shared with the other drivers.
+type textFormatter struct {
+	encode format.Encoder
+	names  *log.FieldNames
+	levels *log.LevelNames
+	stamp  *log.Timestamp // Nil if times are not written.
+}
+
+// Format converts the entry into a format.Record, normalizing the field
+// values so they render the same as the other drivers.
+func (f textFormatter) Format(ent *logrus.Entry) ([]byte, error) {
+	rec := &format.Record{
+		Level:      f.levels.Name(lvlFromLogrus(ent.Level)),
+		Message:    ent.Message,
+		Fields:     make(map[string]interface{}, len(ent.Data)),
+		LevelKey:   f.names.Level,
+		MessageKey: f.names.Message,
+		StackKey:   f.names.Stack,
+	}
+	if f.stamp != nil {
+		rec.Time = f.stamp.String(ent.Time)
+		rec.TimeKey = f.stamp.Key
+	}
+	for k, v := range ent.Data {
+		rec.Fields[k] = format.Normalize(v)
+	}
+
+	buf := f.encode(make([]byte, 0, 256), rec)
+	return append(buf, '\n'), nil
+}
+
+// jsonFormatter implements a Logrus formatter writing JSON the way the
+// other drivers do, with the core values named and formatted as set by
+// the log.Config: logrus.JSONFormatter writes the names Logrus uses for
+// levels, and only supports time layouts in local time.
+type jsonFormatter struct {
+	names  *log.FieldNames
+	levels *log.LevelNames
+	stamp  *log.Timestamp // Nil if times are not written.
+	pretty bool
+}
+
+// Format writes the fields of the entry and its core values, renaming
+// fields that clash with those as Logrus does. Errors are written as
+// their messages, as logrus.JSONFormatter does.
+func (f jsonFormatter) Format(ent *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(ent.Data)+3)
+	for k, v := range ent.Data {
+		switch k {
+		case f.names.Message, f.names.Level:
+			k = "fields." + k
+		default:
+			if f.stamp != nil && k == f.stamp.Key {
+				k = "fields." + k
+			}
+		}
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	data[f.names.Level] = f.levels.Name(lvlFromLogrus(ent.Level))
+	if ent.Message != "" {
+		data[f.names.Message] = ent.Message
+	}
+	if f.stamp != nil {
+		data[f.stamp.Key] = f.stamp.Value(ent.Time)
+	}
+
+	buf := ent.Buffer
+	if buf == nil {
+		buf = new(bytes.Buffer)
+	}
+	enc := json.NewEncoder(buf)
+	if f.pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+	}
+	return buf.Bytes(), nil
+}
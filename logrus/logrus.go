@@ -4,8 +4,11 @@
 package logrus
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"runtime"
 	"strings"
@@ -14,6 +17,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/secureworks/logger/internal/common"
+	"github.com/secureworks/logger/internal/format"
 	"github.com/secureworks/logger/log"
 )
 
@@ -30,23 +34,74 @@ func newLogger(config *log.Config, opts ...log.Option) (log.Logger, error) {
 	if config.Output == nil {
 		config.Output = os.Stderr
 	}
-	logrusLogger.SetOutput(config.Output)
-	logrusLogger.SetLevel(lvlToLogrus(config.Level))
+	output := config.Output
+	var async *log.AsyncWriter
+	if config.Async != nil {
+		async = log.NewAsyncWriter(output, config.Async)
+		output = async
+	}
+	logrusLogger.SetOutput(output)
+	// Levels are filtered by this driver, so that loggers created using
+	// Named may be enabled at lower levels than their parents.
+	logrusLogger.SetLevel(logrus.TraceLevel)
 	logrusLogger.SetNoLock()
 
-	if config.Format == log.JSONFormat {
-		jsonF := &logrus.JSONFormatter{
-			PrettyPrint: config.LocalDevel,
-		}
-		logrusLogger.SetFormatter(jsonF)
+	names := log.NewFieldNames(config)
+	levels := log.NewLevelNames(config)
+	stamp := log.NewTimestamp(config)
+	switch config.Format {
+	case log.JSONFormat:
+		logrusLogger.SetFormatter(jsonFormatter{
+			names:  &names,
+			levels: levels,
+			stamp:  stamp,
+			pretty: config.LocalDevel,
+		})
+	case log.LogfmtFormat:
+		logrusLogger.SetFormatter(textFormatter{
+			encode: format.AppendLogfmt,
+			names:  &names,
+			levels: levels,
+			stamp:  stamp,
+		})
+	case log.ConsoleFormat:
+		logrusLogger.SetFormatter(textFormatter{
+			encode: format.ConsoleEncoder(format.IsTerminal(config.Output)),
+			names:  &names,
+			levels: levels,
+			stamp:  stamp,
+		})
+	default:
+		// Keep Logrus' own text format, but with the configured names.
+		logrusLogger.SetFormatter(&logrus.TextFormatter{
+			DisableTimestamp: stamp == nil,
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyMsg:   names.Message,
+				logrus.FieldKeyLevel: names.Level,
+				logrus.FieldKeyTime:  names.Time,
+			},
+		})
 	}
 
 	if config.EnableErrStack {
-		logrusLogger.AddHook(errorHook{})
+		logrusLogger.AddHook(errorHook{names: &names})
 	}
 
 	// Init logger with Logrus and error stack flag and apply options.
-	logger := &logger{lg: logrusLogger, errStack: config.EnableErrStack}
+	logger := &logger{
+		lg:        logrusLogger,
+		lvl:       log.NewAtomicLevel(config.Level),
+		overrides: config.LevelOverrides,
+		sampling:  log.NewSampling(config.Sampler),
+		redact:    config.Redaction,
+		hooks:     new(log.Hooks),
+		async:     async,
+		names:     &names,
+		schema:    config.Schema,
+		errStack:  config.EnableErrStack,
+	}
+	logger.flusher, _ = output.(log.Flusher)
+	logger.dedup = log.NewDeduper(config.Dedup, logger.writeDedupSummary)
 
 	// Apply options.
 	for _, opt := range opts {
@@ -54,21 +109,45 @@ func newLogger(config *log.Config, opts ...log.Option) (log.Logger, error) {
 			return nil, err
 		}
 	}
+
+	// Bind the static fields as With does, so they cost nothing per
+	// entry.
+	if len(config.StaticFields) > 0 {
+		logger.bind(config.Schema.Fields(config.StaticFields))
+	}
 	return logger, nil
 }
 
 // Logger implementation.
 
 type logger struct {
-	lg       *logrus.Logger
-	errStack bool
+	lg        *logrus.Logger
+	base      *logrus.Entry // Set on child loggers, see With and Named.
+	lvl       *log.AtomicLevel
+	name      string
+	overrides log.LevelOverrides
+	sampling  *log.Sampling
+	dedup     *log.Deduper
+	redact    *log.RedactionPolicy
+	hooks     *log.Hooks
+	async     *log.AsyncWriter // Set if created for Config.Async.
+	flusher   log.Flusher      // Set if the output is a log.Flusher.
+	names     *log.FieldNames
+	schema    *log.Schema
+	errStack  bool
 }
 
 var _ log.Logger = (*logger)(nil)
 var _ log.UnderlyingLogger = (*logger)(nil)
+var _ log.LevelController = (*logger)(nil)
+var _ log.DroppedCounter = (*logger)(nil)
+var _ log.HookLogger = (*logger)(nil)
+var _ log.Flusher = (*logger)(nil)
+var _ log.SchemaLogger = (*logger)(nil)
+var _ io.Closer = (*logger)(nil)
 
 func (l *logger) IsLevelEnabled(lvl log.Level) bool {
-	return l.lg.IsLevelEnabled(lvlToLogrus(lvl))
+	return lvlToLogrus(lvl) <= lvlToLogrus(l.lvl.Level())
 }
 
 func (l *logger) WithError(err error) log.Entry {
@@ -86,6 +165,31 @@ func (l *logger) WithFields(fields map[string]interface{}) log.Entry {
 	return entry.WithFields(fields)
 }
 
+// With returns a child logger with a base *logrus.Entry holding the
+// fields; each new entry starts from a copy of it.
+func (l *logger) With(fields map[string]interface{}) log.Logger {
+	child := *l
+	child.base = l.baseEntry().WithFields(l.redact.RedactFields(fields))
+	return &child
+}
+
+// Binds fields to the receiver itself, rather than to a child as With
+// does, so that they are also written by entries such as dedup
+// summaries that the receiver logs.
+func (l *logger) bind(fields map[string]interface{}) {
+	*l = *l.With(fields).(*logger)
+}
+
+func (l *logger) Named(name string) log.Logger {
+	child := *l
+	child.name = log.JoinName(l.name, name)
+	child.base = l.baseEntry().WithField(log.LoggerNameField, child.name)
+	if lvl, inherit := l.overrides.NamedLevel(l.name, child.name); !inherit {
+		child.lvl = log.NewAtomicLevel(lvl)
+	}
+	return &child
+}
+
 func (l *logger) Entry(lvl log.Level) log.Entry {
 	return l.newEntry(lvlToLogrus(lvl))
 }
@@ -99,7 +203,66 @@ func (l *logger) Panic() log.Entry { return l.newEntry(logrus.PanicLevel) }
 func (l *logger) Fatal() log.Entry { return l.newEntry(logrus.FatalLevel) }
 
 func (l *logger) WriteCloser(lvl log.Level) io.WriteCloser {
-	return l.lg.WriterLevel(lvlToLogrus(lvl))
+	return writeLevelCloser{log: l, lvl: lvl}
+}
+
+// LevelController implementation.
+
+// SetLevel changes the level of the logger, its children and any of
+// their entries that have not been sent yet. The level of the
+// underlying logrus.Logger is not changed: it is left at
+// logrus.TraceLevel and entries are filtered by this driver.
+func (l *logger) SetLevel(lvl log.Level) {
+	l.lvl.SetLevel(lvl)
+}
+
+func (l *logger) Level() log.Level {
+	return l.lvl.Level()
+}
+
+// DroppedCounter implementation.
+
+// Dropped returns the number of entries dropped by Config.Sampler, and
+// by the log.AsyncWriter used for Config.Async.
+func (l *logger) Dropped() uint64 {
+	return l.sampling.Dropped() + l.async.Dropped()
+}
+
+// Flusher and io.Closer implementation.
+
+// Flush writes the pending dedup summaries, and flushes the output if
+// it is a log.Flusher, such as the log.AsyncWriter used for
+// Config.Async.
+func (l *logger) Flush() error {
+	l.dedup.Flush()
+	if l.flusher == nil {
+		return nil
+	}
+	return l.flusher.Flush()
+}
+
+// Close writes the pending dedup summaries, flushes the output and
+// closes the log.AsyncWriter used for Config.Async, which is shared
+// with the parent and children of the logger. Other outputs are not
+// closed.
+func (l *logger) Close() error {
+	if l.async != nil {
+		l.dedup.Flush()
+		return l.async.Close()
+	}
+	return l.Flush()
+}
+
+// HookLogger implementation.
+
+func (l *logger) Hooks() *log.Hooks {
+	return l.hooks
+}
+
+// SchemaLogger implementation.
+
+func (l *logger) Schema() *log.Schema {
+	return l.schema
 }
 
 // UnderlyingLogger implementation.
@@ -109,25 +272,58 @@ func (l *logger) GetLogger() interface{} {
 }
 
 func (l *logger) SetLogger(v interface{}) {
-	if lg, ok := v.(*logrus.Logger); ok {
+	switch lg := v.(type) {
+	case *logrus.Logger:
 		l.lg = lg
-	}
-	if lg, ok := v.(logrus.Logger); ok { //nolint // Mutex is wrapped internally within a pointer.
+	case logrus.Logger: // Mutex is wrapped internally within a pointer.
 		l.lg = &lg
 	}
 }
 
 // Logger utility functions.
 
+// Returns the entry holding the fields bound to the logger.
+func (l *logger) baseEntry() *logrus.Entry {
+	if l.base == nil {
+		return logrus.NewEntry(l.lg)
+	}
+	return l.base
+}
+
 // Creates a new entry at the given level.
 func (l *logger) newEntry(lvl logrus.Level) *entry {
+	ent := logrus.NewEntry(l.lg)
+	if l.base != nil {
+		ent.Data = make(logrus.Fields, len(l.base.Data)+6)
+		for k, v := range l.base.Data {
+			ent.Data[k] = v
+		}
+	}
+
 	return &entry{
-		ent:      logrus.NewEntry(l.lg),
+		ent:      ent,
 		errStack: l.errStack,
+		loglvl:   l.lvl,
+		sampling: l.sampling,
+		dedup:    l.dedup,
+		redact:   l.redact,
+		hooks:    l.hooks,
+		flusher:  l.flusher,
+		names:    l.names,
 		lvl:      lvl,
 	}
 }
 
+// Writes the summary of entries suppressed by l.dedup.
+func (l *logger) writeDedupSummary(s log.DedupSummary) {
+	e := l.newEntry(lvlToLogrus(s.Level))
+	e.summary = true
+	e.WithFields(s.Fields).
+		WithStr(log.SuppressedMessage, s.Message).
+		WithInt(log.SuppressedCount, s.Count).
+		Msg(s.Msg())
+}
+
 // Map log.Level to internal Logrus log levels.
 func lvlToLogrus(lvl log.Level) logrus.Level {
 	switch lvl {
@@ -150,10 +346,63 @@ func lvlToLogrus(lvl log.Level) logrus.Level {
 	}
 }
 
+// Map internal Logrus log levels to log.Level.
+func lvlFromLogrus(lvl logrus.Level) log.Level {
+	switch lvl {
+	case logrus.TraceLevel:
+		return log.TRACE
+	case logrus.DebugLevel:
+		return log.DEBUG
+	case logrus.InfoLevel:
+		return log.INFO
+	case logrus.WarnLevel:
+		return log.WARN
+	case logrus.ErrorLevel:
+		return log.ERROR
+	case logrus.PanicLevel:
+		return log.PANIC
+	case logrus.FatalLevel:
+		return log.FATAL
+	default:
+		return log.INFO
+	}
+}
+
+// WriteCloser hook implementation.
+
+type writeLevelCloser struct {
+	log log.Logger
+	lvl log.Level
+}
+
+// An implementation of io.Writer that writes each call as an entry at
+// the given level.
+func (wlc writeLevelCloser) Write(p []byte) (n int, err error) {
+	n = len(p)
+	if n > 0 && p[n-1] == '\n' {
+		// Trim CR added by stdlog.
+		p = p[0 : n-1]
+	}
+	wlc.log.Entry(wlc.lvl).Msg(string(p))
+	return
+}
+
+func (wlc writeLevelCloser) Close() error {
+	return nil
+}
+
 // Entry implementation.
 
 type entry struct {
 	ent      *logrus.Entry
+	loglvl   *log.AtomicLevel
+	sampling *log.Sampling
+	dedup    *log.Deduper
+	redact   *log.RedactionPolicy
+	hooks    *log.Hooks
+	flusher  log.Flusher // Flushed before panicking or exiting.
+	summary  bool        // Set on dedup summaries.
+	names    *log.FieldNames
 	lvl      logrus.Level
 	async    bool
 	errStack bool
@@ -162,6 +411,7 @@ type entry struct {
 
 var _ log.Entry = (*entry)(nil)
 var _ log.UnderlyingLogger = (*entry)(nil)
+var _ log.Emitter = (*entry)(nil)
 
 func (e *entry) Async() log.Entry {
 	e.async = !e.async
@@ -184,9 +434,9 @@ func (e *entry) Caller(skip ...int) log.Entry {
 	}
 
 	// Not normal Logrus: append to existing field; nil won't panic.
-	cls, _ := e.ent.Data[log.CallerField].([]string)
+	cls, _ := e.ent.Data[e.names.Caller].([]string)
 	cls = append(cls, fmt.Sprintf("%s:%d", file, line))
-	e.ent.Data[log.CallerField] = cls
+	e.ent.Data[e.names.Caller] = cls
 
 	return e
 }
@@ -204,20 +454,20 @@ func (e *entry) WithError(errs ...error) log.Entry {
 	if e.errStack {
 		_, err = common.WithStackTrace(err, 3)
 	}
-	return e.WithField(logrus.ErrorKey, err)
+	return e.WithField(e.names.Error, err)
 }
 
 func (e *entry) WithField(key string, val interface{}) log.Entry {
 	// The deferred functions args are eval'd when defer is called not
 	// when the deferred function is run.
 	defer releaseEntry(e.ent.Logger, e.ent)
-	e.ent = e.ent.WithField(key, val)
+	e.ent = e.ent.WithField(key, e.redact.Redact(key, val))
 	return e
 }
 
 func (e *entry) WithFields(fields map[string]interface{}) log.Entry {
 	defer releaseEntry(e.ent.Logger, e.ent)
-	e.ent = e.ent.WithFields(fields)
+	e.ent = e.ent.WithFields(e.redact.RedactFields(fields))
 	return e
 }
 
@@ -297,6 +547,94 @@ func (e *entry) WithTime(key string, ts ...time.Time) log.Entry {
 	return e.WithField(key, i)
 }
 
+func (e *entry) WithFloat(key string, fs ...float64) log.Entry {
+	if e == nil || len(fs) == 0 {
+		return e
+	}
+
+	var i interface{} = fs[0]
+	if len(fs) > 1 {
+		i = fs
+	}
+	return e.WithField(key, i)
+}
+
+func (e *entry) WithInt64(key string, is ...int64) log.Entry {
+	if e == nil || len(is) == 0 {
+		return e
+	}
+
+	var i interface{} = is[0]
+	if len(is) > 1 {
+		i = is
+	}
+	return e.WithField(key, i)
+}
+
+func (e *entry) WithUint64(key string, us ...uint64) log.Entry {
+	if e == nil || len(us) == 0 {
+		return e
+	}
+
+	var i interface{} = us[0]
+	if len(us) > 1 {
+		i = us
+	}
+	return e.WithField(key, i)
+}
+
+func (e *entry) WithBytes(key string, b []byte) log.Entry {
+	if e == nil {
+		return e
+	}
+	return e.WithField(key, string(b))
+}
+
+func (e *entry) WithHex(key string, b []byte) log.Entry {
+	if e == nil {
+		return e
+	}
+	return e.WithField(key, hex.EncodeToString(b))
+}
+
+func (e *entry) WithIP(key string, ips ...net.IP) log.Entry {
+	if e == nil || len(ips) == 0 {
+		return e
+	}
+	return e.WithField(key, common.IPValue(ips))
+}
+
+func (e *entry) WithStringer(key string, val fmt.Stringer) log.Entry {
+	if e == nil {
+		return e
+	}
+	return e.WithField(key, common.StringerValue(val))
+}
+
+// The JSON formatter writes a json.RawMessage as is, and the text
+// formats decode it, see format.Normalize.
+func (e *entry) WithJSON(key string, raw []byte) log.Entry {
+	if e == nil {
+		return e
+	}
+	return e.WithField(key, json.RawMessage(raw))
+}
+
+// Nested objects are written as maps, see log.DictFields.
+func (e *entry) WithDict(key string, fn func(log.Dict)) log.Entry {
+	if e == nil {
+		return e
+	}
+	return e.WithField(key, log.DictFields(fn))
+}
+
+func (e *entry) WithObject(key string, obj log.ObjectMarshaler) log.Entry {
+	if e == nil {
+		return e
+	}
+	return e.WithField(key, log.ObjectFields(obj))
+}
+
 func (e *entry) Trace() log.Entry { e.lvl = logrus.TraceLevel; return e }
 func (e *entry) Debug() log.Entry { e.lvl = logrus.DebugLevel; return e }
 func (e *entry) Info() log.Entry  { e.lvl = logrus.InfoLevel; return e }
@@ -310,7 +648,7 @@ func (e *entry) Msgf(format string, vals ...interface{}) {
 }
 
 func (e *entry) Msg(msg string) {
-	e.msg = msg
+	e.msg = e.redact.Scrub(msg)
 
 	if !e.async {
 		e.Send()
@@ -323,12 +661,21 @@ func (e *entry) Send() {
 	}
 
 	defer releaseEntry(e.ent.Logger, e.ent)
+	if e.lvl > lvlToLogrus(e.loglvl.Level()) || !e.allowed() {
+		e.ent = nil
+		return
+	}
+	e.fireHooks()
 
 	switch e.lvl {
 	case logrus.PanicLevel:
+		defer e.flush() // Logrus panics once the entry is written.
 		e.ent.Panic(e.msg)
 	case logrus.FatalLevel:
-		e.ent.Fatal(e.msg)
+		// As logrus.Entry.Fatal does, but flushing before exiting.
+		e.ent.Log(logrus.FatalLevel, e.msg)
+		e.flush()
+		e.ent.Logger.Exit(1)
 	default:
 		e.ent.Log(e.lvl, e.msg)
 	}
@@ -336,6 +683,71 @@ func (e *entry) Send() {
 	e.ent = nil
 }
 
+// Emit writes the entry as Send does, using logrus.Entry.Log for every
+// level so that logrus does not exit, and recovering the panic logrus
+// raises after writing a PANIC entry.
+func (e *entry) Emit() {
+	if e == nil || e.ent == nil {
+		return
+	}
+
+	defer releaseEntry(e.ent.Logger, e.ent)
+	if e.lvl > lvlToLogrus(e.loglvl.Level()) || !e.allowed() {
+		e.ent = nil
+		return
+	}
+	e.fireHooks()
+
+	if e.lvl == logrus.PanicLevel {
+		defer func() {
+			if pv := recover(); pv != nil {
+				if _, ok := pv.(*logrus.Entry); !ok {
+					panic(pv)
+				}
+			}
+		}()
+	}
+
+	ent := e.ent
+	e.ent = nil
+	ent.Log(e.lvl, e.msg)
+}
+
+func (e *entry) flush() {
+	if e.flusher != nil {
+		_ = e.flusher.Flush()
+	}
+}
+
+// Applies deduplication and sampling to an enabled entry.
+func (e *entry) allowed() bool {
+	lvl := lvlFromLogrus(e.lvl)
+	if !e.summary && !e.dedup.Allow(lvl, e.msg, e.ent.Data) {
+		return false
+	}
+	return e.sampling.Sample(lvl)
+}
+
+// Fires the Hooks for the entry with the fields of the logrus.Entry.
+func (e *entry) fireHooks() {
+	lvl := lvlFromLogrus(e.lvl)
+	if !e.hooks.Enabled(lvl) {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(e.ent.Data))
+	for k, v := range e.ent.Data {
+		fields[k] = v
+	}
+	caller, _ := fields[e.names.Caller].([]string)
+	delete(fields, e.names.Caller)
+	err, ok := fields[e.names.Error].(error)
+	if ok {
+		delete(fields, e.names.Error)
+	}
+	e.hooks.Fire(log.NewRecord(lvl, e.msg, fields, err, caller, time.Now()))
+}
+
 // UnderlyingLogger implementation.
 
 func (e *entry) GetLogger() interface{} {
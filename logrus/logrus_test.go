@@ -64,7 +64,7 @@ func TestLogrus_Logging(t *testing.T) {
 	var fields struct {
 		Level   string    `json:"level"`
 		Meta    string    `json:"meta"`
-		Message string    `json:"msg"`
+		Message string    `json:"message"`
 		Time    time.Time `json:"time"`
 	}
 	err = json.Unmarshal(out.Bytes(), &fields)
@@ -87,7 +87,7 @@ func TestLogrus_Errors(t *testing.T) {
 		Error   string `json:"error"`
 		Level   string `json:"level"`
 		Meta    string `json:"meta"`
-		Message string `json:"msg"`
+		Message string `json:"message"`
 		Stack   []struct {
 			File string `json:"file"`
 			Line int    `json:"line"`
@@ -109,3 +109,7 @@ func TestLogrus_Errors(t *testing.T) {
 	// Metadata fields.
 	testutils.AssertEqual(t, testFieldValue, fields.Meta)
 }
+
+func TestLogrus_Driver(t *testing.T) {
+	testutils.RunDriverTests(t, "logrus")
+}
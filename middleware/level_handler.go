@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/secureworks/logger/log"
+)
+
+// Maximum size of a PUT request body accepted by the level handler.
+const maxLevelBodySize = 1 << 10
+
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+type errorPayload struct {
+	Error string `json:"error"`
+}
+
+// NewLevelHandler returns an http.Handler that reports and changes the
+// level of lc at runtime. GET responds with the current level, and PUT
+// sets it from the request body; both use the same JSON document:
+//
+//	{"level":"DEBUG"}
+//
+// Level names are parsed with log.ParseLevel. Invalid requests are answered with
+// 400 Bad Request and a JSON document holding an "error" message, and
+// other methods with 405 Method Not Allowed.
+//
+// The handler has no access control of its own, so it should only be
+// served on an internal or otherwise protected listener:
+//
+//	logger, err := log.Open("zerolog", nil)
+//	if lc, ok := logger.(log.LevelController); ok {
+//	    mux.Handle("/log/level", middleware.NewLevelHandler(lc))
+//	}
+func NewLevelHandler(lc log.LevelController) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			lvl, err := decodeLevel(r.Body)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, errorPayload{Error: err.Error()})
+				return
+			}
+			lc.SetLevel(lvl)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeJSON(w, http.StatusMethodNotAllowed, errorPayload{Error: "method not allowed"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, levelPayload{Level: lc.Level().String()})
+	})
+}
+
+func decodeLevel(body io.Reader) (log.Level, error) {
+	var payload levelPayload
+	dec := json.NewDecoder(io.LimitReader(body, maxLevelBodySize))
+	if err := dec.Decode(&payload); err != nil {
+		return 0, fmt.Errorf("invalid request body: %w", err)
+	}
+	return log.ParseLevel(payload.Level)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
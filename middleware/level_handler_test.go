@@ -0,0 +1,63 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	"github.com/secureworks/logger/middleware"
+	"github.com/secureworks/logger/testlogger"
+)
+
+func TestLevelHandler(t *testing.T) {
+	logger, _ := testlogger.New(log.DefaultConfig(nil))
+	handler := middleware.NewLevelHandler(logger)
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/log/level", strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("get", func(t *testing.T) {
+		resp := serve(http.MethodGet, "")
+		testutils.AssertEqual(t, http.StatusOK, resp.Code)
+		testutils.AssertEqual(t, "application/json", resp.Header().Get("Content-Type"))
+		testutils.AssertEqual(t, `{"level":"INFO"}`+"\n", resp.Body.String())
+	})
+
+	t.Run("put", func(t *testing.T) {
+		resp := serve(http.MethodPut, `{"level":"debug"}`)
+		testutils.AssertEqual(t, http.StatusOK, resp.Code)
+		testutils.AssertEqual(t, `{"level":"DEBUG"}`+"\n", resp.Body.String())
+		testutils.AssertEqual(t, log.DEBUG, logger.Level())
+		testutils.AssertTrue(t, logger.IsLevelEnabled(log.DEBUG))
+
+		resp = serve(http.MethodGet, "")
+		testutils.AssertEqual(t, `{"level":"DEBUG"}`+"\n", resp.Body.String())
+	})
+
+	t.Run("put unknown level", func(t *testing.T) {
+		resp := serve(http.MethodPut, `{"level":"verbose"}`)
+		testutils.AssertEqual(t, http.StatusBadRequest, resp.Code)
+		testutils.AssertStringContains(t, `unknown level \"verbose\"`, resp.Body.String())
+		testutils.AssertEqual(t, log.DEBUG, logger.Level())
+	})
+
+	t.Run("put invalid body", func(t *testing.T) {
+		resp := serve(http.MethodPut, `DEBUG`)
+		testutils.AssertEqual(t, http.StatusBadRequest, resp.Code)
+		testutils.AssertStringContains(t, "invalid request body", resp.Body.String())
+	})
+
+	t.Run("other methods", func(t *testing.T) {
+		resp := serve(http.MethodPost, `{"level":"INFO"}`)
+		testutils.AssertEqual(t, http.StatusMethodNotAllowed, resp.Code)
+		testutils.AssertEqual(t, "GET, PUT", resp.Header().Get("Allow"))
+		testutils.AssertEqual(t, log.DEBUG, logger.Level())
+	})
+}
@@ -101,28 +101,46 @@ type HTTPRequestLogAttributes struct {
 // also insert an Async log.Entry into the request context such that
 // downstream handlers can use it. It will call entry.Send when done,
 // and capture panics. If lvl is invalid, the default level will be
-// used.
+// used. If the logger has a log.Schema the request fields are keyed and
+// written as it sets, eg: as an "httpRequest" object for log.GCPSchema.
 func NewHTTPRequestMiddleware(logger log.Logger, lvl log.Level, attrs *HTTPRequestLogAttributes) func(http.Handler) http.Handler {
 	if !lvl.IsValid() {
 		lvl = log.INFO
 	}
 
+	schema := log.SchemaOf(logger)
+
 	logEntry := func(w ResponseWriter, r *http.Request, entry log.Entry, start time.Time) {
+		var req map[string]interface{} // Set for Schema.HTTPRequestKey.
+		if schema != nil && schema.HTTPRequestKey != "" {
+			req = make(map[string]interface{}, 4)
+		}
+		set := func(key string, val interface{}) {
+			if req != nil {
+				req[schema.Key(key)] = val
+				return
+			}
+			entry.WithField(schema.Key(key), val)
+		}
+
 		if attrs == nil || attrs != nil && !attrs.SkipMethod {
-			entry.WithStr(log.ReqMethod, r.Method)
+			set(log.ReqMethod, r.Method)
 		}
 		if attrs == nil || attrs != nil && !attrs.SkipPath {
 			path := r.RequestURI
 			if path == "" {
 				path = r.URL.Path
 			}
-			entry.WithStr(log.ReqPath, path)
+			set(log.ReqPath, path)
 		}
 		if attrs == nil || attrs != nil && !attrs.SkipRemoteAddr {
-			entry.WithStr(log.ReqRemoteAddr, r.RemoteAddr)
+			set(log.ReqRemoteAddr, r.RemoteAddr)
 		}
 		if attrs == nil || attrs != nil && !attrs.SkipDuration {
-			entry.WithStr(log.ReqDuration, time.Since(start).String())
+			set(log.ReqDuration, schema.Duration(time.Since(start)))
+		}
+		if len(req) > 0 {
+			entry.WithField(schema.HTTPRequestKey, req)
 		}
 		if attrs != nil {
 			for _, header := range attrs.Headers {
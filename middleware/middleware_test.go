@@ -190,3 +190,70 @@ func runMiddlewareAround(
 
 	return resp, logger
 }
+
+func TestHTTPRequestMiddlewareRedaction(t *testing.T) {
+	config := log.DefaultConfig(nil)
+	config.Redaction = log.DefaultRedactionPolicy()
+	logger, _ := testlogger.New(config)
+
+	req := httptest.NewRequest(http.MethodGet, "/test/path", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Request-Id", "req-1")
+
+	attrs := &middleware.HTTPRequestLogAttributes{Headers: []string{"Authorization", "X-Request-Id"}}
+	h := middleware.NewHTTPRequestMiddleware(logger, log.INFO, attrs)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logger.GetEntries()
+	testutils.AssertEqual(t, 1, len(entries))
+	testutils.AssertEqual(t, log.Redacted, entries[0].StringField("authorization"))
+	testutils.AssertEqual(t, "req-1", entries[0].StringField("x-request-id"))
+}
+
+func TestHTTPRequestMiddlewareSchema(t *testing.T) {
+	run := func(t *testing.T, schema *log.Schema) *testlogger.Entry {
+		t.Helper()
+
+		config := log.DefaultConfig(nil)
+		config.Schema = schema
+		logger, _ := testlogger.New(config)
+
+		req := httptest.NewRequest(http.MethodGet, "/test/path?q=1", nil)
+		h := middleware.NewHTTPRequestMiddleware(logger, log.INFO, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(time.Millisecond)
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		entries := logger.GetEntries()
+		testutils.AssertEqual(t, 1, len(entries))
+		return entries[0]
+	}
+
+	t.Run("ecs", func(t *testing.T) {
+		entry := run(t, log.ECSSchema())
+
+		testutils.AssertEqual(t, http.MethodGet, entry.StringField("http.request.method"))
+		testutils.AssertEqual(t, "/test/path?q=1", entry.StringField("url.original"))
+		testutils.AssertEqual(t, "192.0.2.1:1234", entry.StringField("client.address"))
+		nanos, ok := entry.Field("event.duration").(int64)
+		testutils.AssertTrue(t, ok)
+		testutils.AssertTrue(t, nanos >= int64(time.Millisecond))
+		testutils.AssertFalse(t, entry.HasField(log.ReqMethod))
+	})
+
+	t.Run("gcp", func(t *testing.T) {
+		entry := run(t, log.GCPSchema())
+
+		req, ok := entry.Field("httpRequest").(map[string]interface{})
+		testutils.AssertTrue(t, ok)
+		testutils.AssertEqual(t, http.MethodGet, req["requestMethod"])
+		testutils.AssertEqual(t, "/test/path?q=1", req["requestUrl"])
+		testutils.AssertEqual(t, "192.0.2.1:1234", req["remoteIp"])
+		latency, _ := req["latency"].(string)
+		testutils.AssertTrue(t, strings.HasSuffix(latency, "s"))
+		d, err := time.ParseDuration(latency)
+		testutils.AssertNil(t, err)
+		testutils.AssertTrue(t, d >= time.Millisecond)
+		testutils.AssertFalse(t, entry.HasField(log.ReqMethod))
+	})
+}
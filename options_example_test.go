@@ -47,7 +47,7 @@ func ExampleCustomOption_singleValue() {
 	// see Option.
 	logger, _ := log.Open(
 		"zerolog",
-		&log.Config{Output: os.Stdout},
+		&log.Config{Output: os.Stdout, TimeFormat: log.TimeFormatNone},
 
 		// Zerolog's Logger.Hook method is chainable (returns a new loger with
 		// the given hook attached), so CustomOption will reset the underlying
@@ -67,7 +67,7 @@ func ExampleCustomOption_withPossibleError() {
 	// error bubbles up through log.Open.
 	loggerFailed, err := log.Open(
 		"zerolog",
-		&log.Config{Output: os.Stdout},
+		&log.Config{Output: os.Stdout, TimeFormat: log.TimeFormatNone},
 		log.CustomOption("Sample", func() (zerolog.Sampler, error) {
 			return &zerolog.BasicSampler{N: 2}, errors.New("custom option failed")
 		}),
@@ -81,7 +81,7 @@ func ExampleCustomOption_withPossibleError() {
 	// ignored and the named method receives the given value(s).
 	loggerSuccess, _ := log.Open(
 		"zerolog",
-		&log.Config{Output: os.Stdout},
+		&log.Config{Output: os.Stdout, TimeFormat: log.TimeFormatNone},
 		log.CustomOption("Sample", func() (zerolog.Sampler, error) {
 			return &zerolog.BasicSampler{N: 2}, nil
 		}),
@@ -0,0 +1,15 @@
+module github.com/secureworks/logger/otel
+
+go 1.21
+
+require (
+	github.com/secureworks/logger/internal v1.2.1
+	github.com/secureworks/logger/log v1.2.0
+	github.com/secureworks/logger/testlogger v1.2.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/secureworks/errors v0.1.2 // indirect
+	go.opentelemetry.io/otel v1.28.0 // indirect
+)
@@ -0,0 +1,42 @@
+// Package otel correlates log entries with OpenTelemetry traces. Once
+// imported, the Loggers returned by log.FromCtx include the trace_id,
+// span_id and trace_flags of the span in the context:
+//
+//	import _ "github.com/secureworks/logger/otel"
+//
+//	func handle(ctx context.Context) {
+//		ctx, span := tracer.Start(ctx, "handle")
+//		defer span.End()
+//
+//		log.FromCtx(ctx).Info().Msg("handling")
+//	}
+//
+// This works with any driver, as the fields are bound using Logger.With.
+package otel
+
+import (
+	"context"
+
+	"github.com/secureworks/logger/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Register the trace fields with log.FromCtx.
+func init() {
+	log.RegisterCtxFields(Fields)
+}
+
+// Fields returns the log.TraceIDField, log.SpanIDField and
+// log.TraceFlagsField of the span in ctx, or nil if ctx does not hold a
+// valid span context.
+func Fields(ctx context.Context) map[string]interface{} {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return map[string]interface{}{
+		log.TraceIDField:    sc.TraceID().String(),
+		log.SpanIDField:     sc.SpanID().String(),
+		log.TraceFlagsField: sc.TraceFlags().String(),
+	}
+}
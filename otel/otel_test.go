@@ -0,0 +1,53 @@
+package otel_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/otel"
+	"github.com/secureworks/logger/testlogger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestFromCtx(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	t.Run("adds the span context", func(t *testing.T) {
+		logger := testlogger.MustNew(nil)
+		ctx := log.CtxWithLogger(context.Background(), logger)
+		ctx = trace.ContextWithSpanContext(ctx, sc)
+
+		log.FromCtx(ctx).Info().Msg("traced")
+
+		entries := logger.GetEntries()
+		testutils.AssertEqual(t, 1, len(entries))
+		testutils.AssertEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", entries[0].StringField(log.TraceIDField))
+		testutils.AssertEqual(t, "00f067aa0ba902b7", entries[0].StringField(log.SpanIDField))
+		testutils.AssertEqual(t, "01", entries[0].StringField(log.TraceFlagsField))
+	})
+
+	t.Run("without a span", func(t *testing.T) {
+		logger := testlogger.MustNew(nil)
+		ctx := log.CtxWithLogger(context.Background(), logger)
+
+		log.FromCtx(ctx).Info().Msg("untraced")
+
+		entries := logger.GetEntries()
+		testutils.AssertEqual(t, 1, len(entries))
+		_, ok := entries[0].Fields[log.TraceIDField]
+		testutils.AssertFalse(t, ok)
+	})
+
+	t.Run("without a logger", func(t *testing.T) {
+		ctx := trace.ContextWithSpanContext(context.Background(), sc)
+		testutils.AssertNotPanics(t, func() {
+			log.FromCtx(ctx).Info().Msg("dropped")
+		})
+	})
+}
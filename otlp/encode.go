@@ -0,0 +1,498 @@
+package otlp
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/secureworks/logger/internal/format"
+	"github.com/secureworks/logger/log"
+)
+
+// This file encodes batches of records as OTLP ExportLogsServiceRequest
+// messages, in protobuf and in the OTLP JSON encoding. Only the parts of
+// the OTLP schema used by this driver are implemented; see
+// https://github.com/open-telemetry/opentelemetry-proto.
+
+// Kinds of value held by an anyValue.
+const (
+	stringKind = iota
+	boolKind
+	intKind
+	doubleKind
+	arrayKind
+	kvlistKind
+	bytesKind
+)
+
+// An OTLP AnyValue.
+type anyValue struct {
+	kind  int
+	str   string
+	num   int64
+	dbl   float64
+	bytes []byte
+	arr   []anyValue
+	kvs   []keyValue
+}
+
+// An OTLP KeyValue.
+type keyValue struct {
+	key   string
+	value anyValue
+}
+
+// An OTLP LogRecord.
+type record struct {
+	time         time.Time
+	severity     int
+	severityText string
+	body         string
+	attrs        []keyValue
+	traceID      []byte
+	spanID       []byte
+	flags        uint32
+}
+
+// Severity numbers of the OTLP log data model.
+const (
+	severityTrace = 1
+	severityDebug = 5
+	severityInfo  = 9
+	severityWarn  = 13
+	severityError = 17
+	severityFatal = 21
+)
+
+// SeverityNumber returns the OTLP severity number for lvl. PANIC uses
+// the lowest FATAL number and FATAL the highest.
+func SeverityNumber(lvl log.Level) int {
+	switch lvl {
+	case log.TRACE:
+		return severityTrace
+	case log.DEBUG:
+		return severityDebug
+	case log.WARN:
+		return severityWarn
+	case log.ERROR:
+		return severityError
+	case log.PANIC:
+		return severityFatal
+	case log.FATAL:
+		return severityFatal + 3
+	default:
+		return severityInfo
+	}
+}
+
+// Returns the record for an entry. The log.TraceIDField, log.SpanIDField
+// and log.TraceFlagsField fields, eg: bound using log.FromCtx, are used
+// as the trace context of the record if they are valid.
+func newRecord(t time.Time, lvl log.Level, msg string, fields map[string]interface{}) *record {
+	r := &record{
+		time:         t,
+		severity:     SeverityNumber(lvl),
+		severityText: lvl.String(),
+		body:         msg,
+	}
+
+	if id, ok := hexField(fields, log.TraceIDField, 16); ok {
+		if span, ok := hexField(fields, log.SpanIDField, 8); ok {
+			r.traceID, r.spanID = id, span
+			delete(fields, log.TraceIDField)
+			delete(fields, log.SpanIDField)
+			if flags, ok := hexField(fields, log.TraceFlagsField, 1); ok {
+				r.flags = uint32(flags[0])
+				delete(fields, log.TraceFlagsField)
+			}
+		}
+	}
+
+	r.attrs = keyValues(fields)
+	return r
+}
+
+// Decodes the hex string field key if it holds n bytes.
+func hexField(fields map[string]interface{}, key string, n int) ([]byte, bool) {
+	str, ok := fields[key].(string)
+	if !ok || len(str) != 2*n {
+		return nil, false
+	}
+	byt, err := hex.DecodeString(str)
+	return byt, err == nil
+}
+
+// Returns fields as KeyValues sorted by key.
+func keyValues(fields map[string]interface{}) []keyValue {
+	if len(fields) == 0 {
+		return nil
+	}
+	kvs := make([]keyValue, 0, len(fields))
+	for key, val := range fields {
+		kvs = append(kvs, keyValue{key: key, value: toAnyValue(val)})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].key < kvs[j].key })
+	return kvs
+}
+
+// Converts a field value into an AnyValue. The types set by the typed
+// With… methods are converted directly; others are normalized as the
+// text formats do, see format.Normalize.
+func toAnyValue(val interface{}) anyValue {
+	switch v := val.(type) {
+	case string:
+		return anyValue{kind: stringKind, str: v}
+	case bool:
+		if v {
+			return anyValue{kind: boolKind, num: 1}
+		}
+		return anyValue{kind: boolKind}
+	case int:
+		return anyValue{kind: intKind, num: int64(v)}
+	case int64:
+		return anyValue{kind: intKind, num: v}
+	case int32:
+		return anyValue{kind: intKind, num: int64(v)}
+	case uint:
+		return uintValue(uint64(v))
+	case uint64:
+		return uintValue(v)
+	case uint32:
+		return anyValue{kind: intKind, num: int64(v)}
+	case float64:
+		return doubleValue(v)
+	case float32:
+		return doubleValue(float64(v))
+	case []byte:
+		return anyValue{kind: bytesKind, bytes: v}
+	case time.Duration:
+		return anyValue{kind: doubleKind, dbl: float64(v) / float64(time.Millisecond)}
+	case time.Time:
+		return anyValue{kind: stringKind, str: v.Format(format.TimeFormat)}
+	case error:
+		return anyValue{kind: stringKind, str: v.Error()}
+	case []string:
+		arr := make([]anyValue, len(v))
+		for i, s := range v {
+			arr[i] = anyValue{kind: stringKind, str: s}
+		}
+		return anyValue{kind: arrayKind, arr: arr}
+	case []interface{}:
+		arr := make([]anyValue, len(v))
+		for i, elem := range v {
+			arr[i] = toAnyValue(elem)
+		}
+		return anyValue{kind: arrayKind, arr: arr}
+	case map[string]interface{}:
+		return anyValue{kind: kvlistKind, kvs: keyValues(v)}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return anyValue{kind: intKind, num: n}
+		}
+		if f, err := v.Float64(); err == nil {
+			return doubleValue(f)
+		}
+		return anyValue{kind: stringKind, str: v.String()}
+	case nil:
+		return anyValue{kind: stringKind}
+	}
+
+	// Slices of the other types above, structs, etc. Normalized values
+	// are of the types handled above.
+	return toAnyValue(format.Normalize(val))
+}
+
+// NaN and infinities, which JSON can not represent, are written as
+// strings.
+func doubleValue(f float64) anyValue {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return anyValue{kind: stringKind, str: strconv.FormatFloat(f, 'g', -1, 64)}
+	}
+	return anyValue{kind: doubleKind, dbl: f}
+}
+
+// Integers that do not fit an int64 are written as strings.
+func uintValue(u uint64) anyValue {
+	if u > math.MaxInt64 {
+		return anyValue{kind: stringKind, str: strconv.FormatUint(u, 10)}
+	}
+	return anyValue{kind: intKind, num: int64(u)}
+}
+
+// Protobuf encoding.
+
+// Field numbers of the messages in the OTLP schema.
+const (
+	fieldResourceLogs = 1 // ExportLogsServiceRequest.resource_logs
+
+	fieldResource  = 1 // ResourceLogs.resource
+	fieldScopeLogs = 2 // ResourceLogs.scope_logs
+
+	fieldResourceAttributes = 1 // Resource.attributes
+
+	fieldScope      = 1 // ScopeLogs.scope
+	fieldLogRecords = 2 // ScopeLogs.log_records
+
+	fieldScopeName = 1 // InstrumentationScope.name
+
+	fieldTimeUnixNano         = 1  // LogRecord.time_unix_nano
+	fieldSeverityNumber       = 2  // LogRecord.severity_number
+	fieldSeverityText         = 3  // LogRecord.severity_text
+	fieldBody                 = 5  // LogRecord.body
+	fieldAttributes           = 6  // LogRecord.attributes
+	fieldFlags                = 8  // LogRecord.flags
+	fieldTraceID              = 9  // LogRecord.trace_id
+	fieldSpanID               = 10 // LogRecord.span_id
+	fieldObservedTimeUnixNano = 11 // LogRecord.observed_time_unix_nano
+
+	fieldKey   = 1 // KeyValue.key
+	fieldValue = 2 // KeyValue.value
+
+	fieldValues = 1 // ArrayValue.values and KeyValueList.values
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Appends a batch of records as a protobuf ExportLogsServiceRequest.
+func appendProtoRequest(dst []byte, resource []keyValue, records []*record) []byte {
+	return appendProtoMessage(dst, fieldResourceLogs, func(dst []byte) []byte {
+		dst = appendProtoMessage(dst, fieldResource, func(dst []byte) []byte {
+			for _, kv := range resource {
+				dst = appendProtoMessage(dst, fieldResourceAttributes, kv.appendProto)
+			}
+			return dst
+		})
+		return appendProtoMessage(dst, fieldScopeLogs, func(dst []byte) []byte {
+			dst = appendProtoMessage(dst, fieldScope, func(dst []byte) []byte {
+				return appendProtoString(dst, fieldScopeName, ScopeName)
+			})
+			for _, r := range records {
+				dst = appendProtoMessage(dst, fieldLogRecords, r.appendProto)
+			}
+			return dst
+		})
+	})
+}
+
+func (r *record) appendProto(dst []byte) []byte {
+	nanos := uint64(r.time.UnixNano())
+	dst = appendProtoTag(dst, fieldTimeUnixNano, wireFixed64)
+	dst = binary.LittleEndian.AppendUint64(dst, nanos)
+	dst = appendProtoTag(dst, fieldSeverityNumber, wireVarint)
+	dst = binary.AppendUvarint(dst, uint64(r.severity))
+	dst = appendProtoString(dst, fieldSeverityText, r.severityText)
+	dst = appendProtoMessage(dst, fieldBody, anyValue{kind: stringKind, str: r.body}.appendProto)
+	for _, kv := range r.attrs {
+		dst = appendProtoMessage(dst, fieldAttributes, kv.appendProto)
+	}
+	if r.traceID != nil {
+		dst = appendProtoTag(dst, fieldFlags, wireFixed32)
+		dst = binary.LittleEndian.AppendUint32(dst, r.flags)
+		dst = appendProtoBytes(dst, fieldTraceID, r.traceID)
+		dst = appendProtoBytes(dst, fieldSpanID, r.spanID)
+	}
+	dst = appendProtoTag(dst, fieldObservedTimeUnixNano, wireFixed64)
+	return binary.LittleEndian.AppendUint64(dst, nanos)
+}
+
+func (kv keyValue) appendProto(dst []byte) []byte {
+	dst = appendProtoString(dst, fieldKey, kv.key)
+	return appendProtoMessage(dst, fieldValue, kv.value.appendProto)
+}
+
+// Appends the fields of an AnyValue. The field numbers of the oneof are
+// one more than the kinds.
+func (v anyValue) appendProto(dst []byte) []byte {
+	field := v.kind + 1
+	switch v.kind {
+	case stringKind:
+		return appendProtoString(dst, field, v.str)
+	case boolKind, intKind:
+		dst = appendProtoTag(dst, field, wireVarint)
+		return binary.AppendUvarint(dst, uint64(v.num))
+	case doubleKind:
+		dst = appendProtoTag(dst, field, wireFixed64)
+		return binary.LittleEndian.AppendUint64(dst, math.Float64bits(v.dbl))
+	case arrayKind:
+		return appendProtoMessage(dst, field, func(dst []byte) []byte {
+			for _, elem := range v.arr {
+				dst = appendProtoMessage(dst, fieldValues, elem.appendProto)
+			}
+			return dst
+		})
+	case kvlistKind:
+		return appendProtoMessage(dst, field, func(dst []byte) []byte {
+			for _, kv := range v.kvs {
+				dst = appendProtoMessage(dst, fieldValues, kv.appendProto)
+			}
+			return dst
+		})
+	default:
+		return appendProtoBytes(dst, field, v.bytes)
+	}
+}
+
+func appendProtoTag(dst []byte, field, wire int) []byte {
+	return binary.AppendUvarint(dst, uint64(field<<3|wire))
+}
+
+func appendProtoString(dst []byte, field int, s string) []byte {
+	dst = appendProtoTag(dst, field, wireBytes)
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	return append(dst, s...)
+}
+
+func appendProtoBytes(dst []byte, field int, b []byte) []byte {
+	dst = appendProtoTag(dst, field, wireBytes)
+	dst = binary.AppendUvarint(dst, uint64(len(b)))
+	return append(dst, b...)
+}
+
+// Appends the embedded message written by fn, prefixed by its length.
+func appendProtoMessage(dst []byte, field int, fn func([]byte) []byte) []byte {
+	msg := fn(nil)
+	dst = appendProtoTag(dst, field, wireBytes)
+	dst = binary.AppendUvarint(dst, uint64(len(msg)))
+	return append(dst, msg...)
+}
+
+// JSON encoding, see the OTLP specification: field names are lowerCamelCase,
+// 64-bit integers are strings and trace and span IDs are hex strings.
+
+type jsonRequest struct {
+	ResourceLogs []jsonResourceLogs `json:"resourceLogs"`
+}
+
+type jsonResourceLogs struct {
+	Resource  jsonResource    `json:"resource"`
+	ScopeLogs []jsonScopeLogs `json:"scopeLogs"`
+}
+
+type jsonResource struct {
+	Attributes []jsonKeyValue `json:"attributes,omitempty"`
+}
+
+type jsonScopeLogs struct {
+	Scope      jsonScope       `json:"scope"`
+	LogRecords []jsonLogRecord `json:"logRecords"`
+}
+
+type jsonScope struct {
+	Name string `json:"name"`
+}
+
+type jsonLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 jsonAnyValue   `json:"body"`
+	Attributes           []jsonKeyValue `json:"attributes,omitempty"`
+	Flags                uint32         `json:"flags,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+}
+
+type jsonKeyValue struct {
+	Key   string       `json:"key"`
+	Value jsonAnyValue `json:"value"`
+}
+
+type jsonAnyValue struct {
+	StringValue *string          `json:"stringValue,omitempty"`
+	BoolValue   *bool            `json:"boolValue,omitempty"`
+	IntValue    *string          `json:"intValue,omitempty"`
+	DoubleValue *float64         `json:"doubleValue,omitempty"`
+	ArrayValue  *jsonArrayValue  `json:"arrayValue,omitempty"`
+	KvlistValue *jsonKvlistValue `json:"kvlistValue,omitempty"`
+	BytesValue  *string          `json:"bytesValue,omitempty"`
+}
+
+type jsonArrayValue struct {
+	Values []jsonAnyValue `json:"values"`
+}
+
+type jsonKvlistValue struct {
+	Values []jsonKeyValue `json:"values"`
+}
+
+// Returns a batch of records as a JSON ExportLogsServiceRequest.
+func marshalJSONRequest(resource []keyValue, records []*record) ([]byte, error) {
+	logRecords := make([]jsonLogRecord, len(records))
+	for i, r := range records {
+		nanos := strconv.FormatInt(r.time.UnixNano(), 10)
+		logRecords[i] = jsonLogRecord{
+			TimeUnixNano:         nanos,
+			ObservedTimeUnixNano: nanos,
+			SeverityNumber:       r.severity,
+			SeverityText:         r.severityText,
+			Body:                 anyValue{kind: stringKind, str: r.body}.toJSON(),
+			Attributes:           jsonKeyValues(r.attrs),
+		}
+		if r.traceID != nil {
+			logRecords[i].Flags = r.flags
+			logRecords[i].TraceID = hex.EncodeToString(r.traceID)
+			logRecords[i].SpanID = hex.EncodeToString(r.spanID)
+		}
+	}
+
+	return json.Marshal(jsonRequest{ResourceLogs: []jsonResourceLogs{{
+		Resource: jsonResource{Attributes: jsonKeyValues(resource)},
+		ScopeLogs: []jsonScopeLogs{{
+			Scope:      jsonScope{Name: ScopeName},
+			LogRecords: logRecords,
+		}},
+	}}})
+}
+
+func jsonKeyValues(kvs []keyValue) []jsonKeyValue {
+	if len(kvs) == 0 {
+		return nil
+	}
+	out := make([]jsonKeyValue, len(kvs))
+	for i, kv := range kvs {
+		out[i] = jsonKeyValue{Key: kv.key, Value: kv.value.toJSON()}
+	}
+	return out
+}
+
+func (v anyValue) toJSON() jsonAnyValue {
+	switch v.kind {
+	case stringKind:
+		return jsonAnyValue{StringValue: &v.str}
+	case boolKind:
+		b := v.num != 0
+		return jsonAnyValue{BoolValue: &b}
+	case intKind:
+		s := strconv.FormatInt(v.num, 10)
+		return jsonAnyValue{IntValue: &s}
+	case doubleKind:
+		return jsonAnyValue{DoubleValue: &v.dbl}
+	case arrayKind:
+		vals := make([]jsonAnyValue, len(v.arr))
+		for i, elem := range v.arr {
+			vals[i] = elem.toJSON()
+		}
+		return jsonAnyValue{ArrayValue: &jsonArrayValue{Values: vals}}
+	case kvlistKind:
+		vals := jsonKeyValues(v.kvs)
+		if vals == nil {
+			vals = []jsonKeyValue{}
+		}
+		return jsonAnyValue{KvlistValue: &jsonKvlistValue{Values: vals}}
+	default:
+		s := base64.StdEncoding.EncodeToString(v.bytes)
+		return jsonAnyValue{BytesValue: &s}
+	}
+}
@@ -0,0 +1,469 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/secureworks/logger/log"
+)
+
+// These EnvKeys are the OpenTelemetry env vars used to set
+// ExporterConfig variables, see DefaultExporterConfig.
+const (
+	// Endpoint is the env var representing the base URL of the
+	// collector, to which "/v1/logs" is appended.
+	Endpoint log.EnvKey = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+	// LogsEndpoint is the env var representing the URL logs are exported
+	// to. It takes precedence over Endpoint.
+	LogsEndpoint log.EnvKey = "OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"
+
+	// Headers is the env var representing headers sent with each export
+	// request, as comma-separated key=value pairs with percent-encoded
+	// values.
+	Headers log.EnvKey = "OTEL_EXPORTER_OTLP_HEADERS"
+
+	// LogsHeaders is the env var representing headers sent with each
+	// export request, in addition to and taking precedence over Headers.
+	LogsHeaders log.EnvKey = "OTEL_EXPORTER_OTLP_LOGS_HEADERS"
+
+	// Protocol is the env var representing the Protocol. Relevant values
+	// include: "http/protobuf" and "http/json".
+	Protocol log.EnvKey = "OTEL_EXPORTER_OTLP_PROTOCOL"
+
+	// LogsProtocol is the env var representing the Protocol. It takes
+	// precedence over Protocol.
+	LogsProtocol log.EnvKey = "OTEL_EXPORTER_OTLP_LOGS_PROTOCOL"
+
+	// Timeout is the env var representing the timeout of each export
+	// request, in milliseconds.
+	Timeout log.EnvKey = "OTEL_EXPORTER_OTLP_TIMEOUT"
+
+	// LogsTimeout is the env var representing the timeout of each export
+	// request, in milliseconds. It takes precedence over Timeout.
+	LogsTimeout log.EnvKey = "OTEL_EXPORTER_OTLP_LOGS_TIMEOUT"
+)
+
+// Protocols supported by the exporter.
+const (
+	// ProtocolProtobuf exports protobuf-encoded requests over HTTP, and
+	// is the default.
+	ProtocolProtobuf = "http/protobuf"
+
+	// ProtocolJSON exports JSON-encoded requests over HTTP.
+	ProtocolJSON = "http/json"
+)
+
+// Defaults for ExporterConfig.
+const (
+	DefaultEndpoint       = "http://localhost:4318/v1/logs"
+	DefaultTimeout        = 10 * time.Second
+	DefaultBatchSize      = 512
+	DefaultBatchTimeout   = time.Second
+	DefaultQueueSize      = 2048
+	DefaultMaxElapsedTime = time.Minute
+	DefaultInitialBackoff = time.Second
+)
+
+// The longest backoff between retries.
+const maxBackoff = 30 * time.Second
+
+// ExporterConfig configures how entries are exported. Zero values are
+// replaced by the defaults above.
+type ExporterConfig struct {
+	// Endpoint is the URL logs are exported to.
+	Endpoint string
+
+	// Protocol is ProtocolProtobuf or ProtocolJSON.
+	Protocol string
+
+	// Headers are sent with each export request, eg: for authorization.
+	Headers map[string]string
+
+	// Timeout is the timeout of each export request.
+	Timeout time.Duration
+
+	// BatchSize is the most entries exported in one request.
+	BatchSize int
+
+	// BatchTimeout is the longest an entry waits for its batch to fill
+	// up before it is exported.
+	BatchTimeout time.Duration
+
+	// QueueSize is the number of entries that may wait to be exported.
+	// What happens to entries sent while the queue is full is decided by
+	// Overflow.
+	QueueSize int
+
+	// Overflow is the policy for entries sent while the queue is full.
+	// Dropped entries are counted, see log.DroppedCounter.
+	Overflow log.OverflowPolicy
+
+	// MaxElapsedTime is how long an export is retried for when it fails
+	// with a retryable error, ie a network error or a 429, 502, 503 or
+	// 504 status, after which its entries are dropped. Exports are not
+	// retried if it is negative.
+	MaxElapsedTime time.Duration
+
+	// InitialBackoff is the wait before the first retry, which doubles
+	// for each retry up to 30s, unless the collector asks for a
+	// different wait using the Retry-After header.
+	InitialBackoff time.Duration
+
+	// Client is the HTTP client used for export requests;
+	// http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// DefaultExporterConfig returns an ExporterConfig set from the
+// OpenTelemetry env vars. env is a callback for looking up EnvKeys, it
+// is set to os.Getenv if nil. It is used by Loggers opened without the
+// WithExporter option, which fail to open if it returns an error.
+// Invalid values are skipped, and the first is returned with the
+// config.
+func DefaultExporterConfig(env func(string) string) (ExporterConfig, error) {
+	if env == nil {
+		env = os.Getenv
+	}
+	var first error
+	report := func(key log.EnvKey, str string, err error) {
+		if first == nil {
+			first = fmt.Errorf("otlp: Invalid %s (%s): %w", key, str, err)
+		}
+	}
+	lookup := func(signal, general log.EnvKey) (log.EnvKey, string) {
+		if val := env(signal.String()); val != "" {
+			return signal, val
+		}
+		return general, env(general.String())
+	}
+
+	var config ExporterConfig
+	if endpoint := env(LogsEndpoint.String()); endpoint != "" {
+		config.Endpoint = endpoint
+	} else if endpoint := env(Endpoint.String()); endpoint != "" {
+		config.Endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/logs"
+	}
+	_, config.Protocol = lookup(LogsProtocol, Protocol)
+	if key, str := lookup(LogsTimeout, Timeout); str != "" {
+		ms, err := strconv.Atoi(str)
+		if err == nil && ms < 0 {
+			err = errors.New("negative timeout")
+		}
+		if err != nil {
+			report(key, str, err)
+		} else {
+			config.Timeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	for _, key := range []log.EnvKey{Headers, LogsHeaders} {
+		str := env(key.String())
+		if str == "" {
+			continue
+		}
+		for _, pair := range strings.Split(str, ",") {
+			name, val, ok := strings.Cut(pair, "=")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" {
+				report(key, str, fmt.Errorf("invalid header %q, want name=value", strings.TrimSpace(pair)))
+				continue
+			}
+			val, err := url.PathUnescape(strings.TrimSpace(val))
+			if err != nil {
+				report(key, str, err)
+				continue
+			}
+			if config.Headers == nil {
+				config.Headers = make(map[string]string)
+			}
+			config.Headers[name] = val
+		}
+	}
+	return config, first
+}
+
+// Returns config with zero values replaced by their defaults.
+func (config ExporterConfig) withDefaults() ExporterConfig {
+	if config.Endpoint == "" {
+		config.Endpoint = DefaultEndpoint
+	}
+	if config.Protocol == "" {
+		config.Protocol = ProtocolProtobuf
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.BatchTimeout <= 0 {
+		config.BatchTimeout = DefaultBatchTimeout
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	if config.MaxElapsedTime == 0 {
+		config.MaxElapsedTime = DefaultMaxElapsedTime
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = DefaultInitialBackoff
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	return config
+}
+
+// Batches records in a bounded queue and exports them from its own
+// goroutine.
+type exporter struct {
+	dropped uint64 // Accessed atomically; first for 64-bit alignment.
+	closed  int32  // Accessed atomically.
+
+	config   ExporterConfig
+	resource []keyValue
+
+	queue  chan *record
+	flushc chan chan error
+	stopc  chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	err error // The first export error since the last flush.
+}
+
+func newExporter(config ExporterConfig, resource map[string]interface{}) (*exporter, error) {
+	config = config.withDefaults()
+	if config.Protocol != ProtocolProtobuf && config.Protocol != ProtocolJSON {
+		return nil, fmt.Errorf("otlp: Unsupported protocol (%s)", config.Protocol)
+	}
+	if _, err := url.Parse(config.Endpoint); err != nil {
+		return nil, fmt.Errorf("otlp: Invalid endpoint (%s): %w", config.Endpoint, err)
+	}
+
+	x := &exporter{
+		config:   config,
+		resource: keyValues(resource),
+		queue:    make(chan *record, config.QueueSize),
+		flushc:   make(chan chan error),
+		stopc:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go x.run()
+	return x, nil
+}
+
+// Queues r for export, dropping it if the exporter is closed, or if the
+// queue is full and the overflow policy is to drop.
+func (x *exporter) enqueue(r *record) {
+	if atomic.LoadInt32(&x.closed) != 0 {
+		atomic.AddUint64(&x.dropped, 1)
+		return
+	}
+	if x.config.Overflow == log.BlockOnOverflow {
+		select {
+		case x.queue <- r:
+		case <-x.done:
+			atomic.AddUint64(&x.dropped, 1)
+		}
+		return
+	}
+	select {
+	case x.queue <- r:
+	default:
+		atomic.AddUint64(&x.dropped, 1)
+	}
+}
+
+// Exports the queued records, returning the first export error since
+// the last flush.
+func (x *exporter) flush() error {
+	ch := make(chan error, 1)
+	select {
+	case x.flushc <- ch:
+		return <-ch
+	case <-x.done:
+		return nil
+	}
+}
+
+// Exports the queued records and stops the exporter.
+func (x *exporter) close() error {
+	err := x.flush()
+	x.once.Do(func() {
+		atomic.StoreInt32(&x.closed, 1)
+		close(x.stopc)
+	})
+	<-x.done
+	return err
+}
+
+func (x *exporter) Dropped() uint64 {
+	return atomic.LoadUint64(&x.dropped)
+}
+
+func (x *exporter) run() {
+	defer close(x.done)
+
+	ticker := time.NewTicker(x.config.BatchTimeout)
+	defer ticker.Stop()
+
+	batch := make([]*record, 0, x.config.BatchSize)
+	for {
+		select {
+		case r := <-x.queue:
+			if batch = append(batch, r); len(batch) >= x.config.BatchSize {
+				batch = x.export(batch)
+			}
+		case <-ticker.C:
+			batch = x.export(batch)
+		case ch := <-x.flushc:
+			batch = x.export(x.drain(batch))
+			ch <- x.err
+			x.err = nil
+		case <-x.stopc:
+			batch = x.export(x.drain(batch))
+			x.reportErr()
+			return
+		}
+	}
+}
+
+// Appends the queued records to batch, exporting full batches.
+func (x *exporter) drain(batch []*record) []*record {
+	for {
+		select {
+		case r := <-x.queue:
+			if batch = append(batch, r); len(batch) >= x.config.BatchSize {
+				batch = x.export(batch)
+			}
+		default:
+			return batch
+		}
+	}
+}
+
+// Exports batch, retrying retryable failures, and returns it emptied.
+func (x *exporter) export(batch []*record) []*record {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	err := x.send(batch)
+	if err != nil {
+		atomic.AddUint64(&x.dropped, uint64(len(batch)))
+		if x.err == nil {
+			x.err = err
+		}
+	}
+	for i := range batch {
+		batch[i] = nil
+	}
+	return batch[:0]
+}
+
+// Reports an export error that was not returned by a flush.
+func (x *exporter) reportErr() {
+	if x.err != nil {
+		fmt.Fprintf(os.Stderr, "otlp: Failed to export entries: %v\n", x.err)
+		x.err = nil
+	}
+}
+
+func (x *exporter) send(batch []*record) error {
+	var (
+		body        []byte
+		contentType string
+	)
+	if x.config.Protocol == ProtocolJSON {
+		var err error
+		if body, err = marshalJSONRequest(x.resource, batch); err != nil {
+			return fmt.Errorf("otlp: Failed to encode %d entries: %w", len(batch), err)
+		}
+		contentType = "application/json"
+	} else {
+		body = appendProtoRequest(nil, x.resource, batch)
+		contentType = "application/x-protobuf"
+	}
+
+	deadline := time.Now().Add(x.config.MaxElapsedTime)
+	backoff := x.config.InitialBackoff
+	for {
+		wait, err := x.post(body, contentType)
+		if err == nil {
+			return nil
+		}
+		if wait < 0 || x.config.MaxElapsedTime < 0 {
+			return err
+		}
+
+		if wait == 0 {
+			wait = backoff
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-x.stopc:
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// Posts an export request. If it fails, the returned wait is negative
+// if the failure is not retryable, positive if the collector asked for
+// a wait before retrying, or zero.
+func (x *exporter) post(body []byte, contentType string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), x.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, x.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return -1, fmt.Errorf("otlp: Failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for name, val := range x.config.Headers {
+		req.Header.Set(name, val)
+	}
+
+	resp, err := x.config.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("otlp: Failed to export entries: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+	err = fmt.Errorf("otlp: Failed to export entries: %s: %s", resp.Status, bytes.TrimSpace(msg))
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if secs, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && secs > 0 {
+			return time.Duration(secs) * time.Second, err
+		}
+		return 0, err
+	default:
+		return -1, err
+	}
+}
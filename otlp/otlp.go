@@ -0,0 +1,666 @@
+// Package otlp implements a logger with an OpenTelemetry driver, which
+// exports entries to a collector as OTLP LogRecords over HTTP. See the
+// documentation associated with the Logger and Entry interfaces for
+// their respective methods.
+//
+// Entries are queued and exported in batches from a background
+// goroutine, so Loggers should be flushed or closed before the program
+// exits, see log.Flusher:
+//
+//	logger, err := log.Open("otlp", config, otlp.WithExporter(otlp.ExporterConfig{
+//		Endpoint: "https://collector:4318/v1/logs",
+//		Protocol: otlp.ProtocolJSON,
+//	}))
+//	if err != nil {
+//		// ...
+//	}
+//	defer logger.(io.Closer).Close()
+//
+// Without the WithExporter option the exporter is configured by the
+// OpenTelemetry env vars, see DefaultExporterConfig. Config.Resource is
+// exported as the resource of the LogRecords; Config.Output, Format and
+// Async are ignored. LogRecords have their own fields for the message,
+// level and time of entries, so only the error, caller and stack names
+// of Config.FieldNames are used. Of a Config.Schema, those names and the
+// keys of static fields are used; trace fields are exported as the
+// trace context of LogRecords, so they are not remapped.
+package otlp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/secureworks/logger/internal/common"
+	"github.com/secureworks/logger/log"
+)
+
+// ScopeName is the name of the instrumentation scope of exported
+// LogRecords.
+const ScopeName = "github.com/secureworks/logger/otlp"
+
+// Register logger.
+func init() {
+	log.Register("otlp", newLogger)
+}
+
+// WithExporter sets how entries are exported, instead of the
+// DefaultExporterConfig.
+func WithExporter(config ExporterConfig) log.Option {
+	return func(l interface{}) error {
+		ol, ok := l.(*logger)
+		if !ok {
+			return fmt.Errorf("otlp: Logger type (%T) is not an otlp Logger", l)
+		}
+		ol.exporterConfig = &config
+		return nil
+	}
+}
+
+// newLogger instantiates a new log.Logger with an OTLP driver using the
+// given configuration and options.
+func newLogger(config *log.Config, opts ...log.Option) (log.Logger, error) {
+	names := log.NewFieldNames(config)
+	logger := &logger{
+		lvl:       log.NewAtomicLevel(config.Level),
+		overrides: config.LevelOverrides,
+		sampling:  log.NewSampling(config.Sampler),
+		redact:    config.Redaction,
+		hooks:     new(log.Hooks),
+		names:     &names,
+		errStack:  config.EnableErrStack,
+	}
+	logger.dedup = log.NewDeduper(config.Dedup, logger.writeDedupSummary)
+
+	// Apply options.
+	for _, opt := range opts {
+		if err := opt(logger); err != nil {
+			return nil, err
+		}
+	}
+
+	if logger.exporterConfig == nil {
+		exporterConfig, err := DefaultExporterConfig(nil)
+		if err != nil {
+			return nil, err
+		}
+		logger.exporterConfig = &exporterConfig
+	}
+	exp, err := newExporter(*logger.exporterConfig, config.Resource)
+	if err != nil {
+		return nil, err
+	}
+	logger.exp = exp
+
+	// Bind the static fields as With does, so they cost nothing per
+	// entry.
+	if len(config.StaticFields) > 0 {
+		logger.bind(config.Schema.Fields(config.StaticFields))
+	}
+	return logger, nil
+}
+
+// Logger implementation.
+
+type logger struct {
+	exp            *exporter
+	exporterConfig *ExporterConfig // Set until the exporter is started.
+	lvl            *log.AtomicLevel
+	name           string
+	overrides      log.LevelOverrides
+	sampling       *log.Sampling
+	dedup          *log.Deduper
+	redact         *log.RedactionPolicy
+	hooks          *log.Hooks
+	fields         map[string]interface{} // Bound using With.
+	names          *log.FieldNames
+	errStack       bool
+}
+
+var _ log.Logger = (*logger)(nil)
+var _ log.LevelController = (*logger)(nil)
+var _ log.DroppedCounter = (*logger)(nil)
+var _ log.HookLogger = (*logger)(nil)
+var _ log.Flusher = (*logger)(nil)
+var _ io.Closer = (*logger)(nil)
+
+func (l *logger) IsLevelEnabled(lvl log.Level) bool {
+	if l.notValid() {
+		return false
+	}
+	return lvl.IsEnabled(l.lvl.Level())
+}
+
+func (l *logger) WithError(err error) log.Entry {
+	return l.Error().WithError(err)
+}
+
+func (l *logger) WithField(key string, val interface{}) log.Entry {
+	return l.Entry(log.INFO).WithField(key, val)
+}
+
+func (l *logger) WithFields(fields map[string]interface{}) log.Entry {
+	return l.Entry(log.INFO).WithFields(fields)
+}
+
+func (l *logger) With(fields map[string]interface{}) log.Logger {
+	if l.notValid() || len(fields) == 0 {
+		return l
+	}
+
+	child := *l
+	child.fields = make(map[string]interface{}, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		child.fields[k] = v
+	}
+	for k, v := range l.redact.RedactFields(fields) {
+		child.fields[k] = v
+	}
+	return &child
+}
+
+// Binds fields to the receiver itself, rather than to a child as With
+// does, so that they are also written by entries such as dedup
+// summaries that the receiver logs.
+func (l *logger) bind(fields map[string]interface{}) {
+	*l = *l.With(fields).(*logger)
+}
+
+func (l *logger) Named(name string) log.Logger {
+	if l.notValid() {
+		return l
+	}
+
+	child := *l
+	child.name = log.JoinName(l.name, name)
+	if lvl, inherit := l.overrides.NamedLevel(l.name, child.name); !inherit {
+		child.lvl = log.NewAtomicLevel(lvl)
+	}
+	return &child
+}
+
+func (l *logger) Entry(lvl log.Level) log.Entry {
+	if l.notValid() {
+		return (*entry)(nil)
+	}
+
+	fields := make(map[string]interface{}, len(l.fields)+4)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	return &entry{logger: l, fields: fields, lvl: lvl}
+}
+
+func (l *logger) Trace() log.Entry { return l.Entry(log.TRACE) }
+func (l *logger) Debug() log.Entry { return l.Entry(log.DEBUG) }
+func (l *logger) Info() log.Entry  { return l.Entry(log.INFO) }
+func (l *logger) Warn() log.Entry  { return l.Entry(log.WARN) }
+func (l *logger) Error() log.Entry { return l.Entry(log.ERROR) }
+func (l *logger) Panic() log.Entry { return l.Entry(log.PANIC) }
+func (l *logger) Fatal() log.Entry { return l.Entry(log.FATAL) }
+
+func (l *logger) WriteCloser(lvl log.Level) io.WriteCloser {
+	return writeLevelCloser{log: l, lvl: lvl}
+}
+
+// LevelController implementation.
+
+// SetLevel changes the level of the logger, its children and any of
+// their entries that have not been sent yet.
+func (l *logger) SetLevel(lvl log.Level) {
+	if l == nil || l.lvl == nil {
+		return
+	}
+	l.lvl.SetLevel(lvl)
+}
+
+func (l *logger) Level() log.Level {
+	if l == nil || l.lvl == nil {
+		return log.INFO
+	}
+	return l.lvl.Level()
+}
+
+// DroppedCounter implementation.
+
+// Dropped returns the number of entries dropped by Config.Sampler, and
+// those that could not be exported: because the queue was full, the
+// export failed or the logger was closed.
+func (l *logger) Dropped() uint64 {
+	if l.notValid() {
+		return 0
+	}
+	return l.sampling.Dropped() + l.exp.Dropped()
+}
+
+// Flusher and io.Closer implementation.
+
+// Flush writes the pending dedup summaries and exports the queued
+// entries, returning the first export error since the last flush.
+func (l *logger) Flush() error {
+	if l.notValid() {
+		return nil
+	}
+	l.dedup.Flush()
+	return l.exp.flush()
+}
+
+// Close writes the pending dedup summaries, exports the queued entries
+// and stops the exporter, which is shared with the parent and children
+// of the logger. Entries sent after it is closed are dropped.
+func (l *logger) Close() error {
+	if l.notValid() {
+		return nil
+	}
+	l.dedup.Flush()
+	return l.exp.close()
+}
+
+// HookLogger implementation.
+
+func (l *logger) Hooks() *log.Hooks {
+	if l == nil {
+		return nil
+	}
+	return l.hooks
+}
+
+// Logger utility functions.
+
+// Writes the summary of entries suppressed by l.dedup.
+func (l *logger) writeDedupSummary(s log.DedupSummary) {
+	e, _ := l.Entry(s.Level).(*entry)
+	if e.notValid() {
+		return
+	}
+	e.summary = true
+	e.WithFields(s.Fields).
+		WithStr(log.SuppressedMessage, s.Message).
+		WithInt(log.SuppressedCount, s.Count).
+		Msg(s.Msg())
+}
+
+func (l *logger) notValid() bool {
+	return l == nil || l.exp == nil
+}
+
+// WriteCloser hook implementation.
+
+type writeLevelCloser struct {
+	log log.Logger
+	lvl log.Level
+}
+
+func (wlc writeLevelCloser) Write(p []byte) (n int, err error) {
+	n = len(p)
+	if n > 0 && p[n-1] == '\n' {
+		// Trim CR added by stdlog.
+		p = p[0 : n-1]
+	}
+	wlc.log.Entry(wlc.lvl).Msg(string(p))
+	return
+}
+
+func (wlc writeLevelCloser) Close() error {
+	return nil
+}
+
+// Entry implementation.
+
+type entry struct {
+	logger  *logger // Set to nil once sent.
+	fields  map[string]interface{}
+	caller  []string
+	msg     string
+	err     error // Set using WithError, for Hooks.
+	async   bool
+	summary bool // Set on dedup summaries.
+	lvl     log.Level
+}
+
+var _ log.Entry = (*entry)(nil)
+var _ log.Emitter = (*entry)(nil)
+
+func (e *entry) Async() log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.async = !e.async
+	return e
+}
+
+func (e *entry) Caller(skip ...int) log.Entry {
+	if e.notValid() {
+		return e
+	}
+
+	sk := 1
+	if len(skip) > 0 {
+		sk += skip[0]
+	}
+
+	_, file, line, ok := runtime.Caller(sk)
+	if !ok {
+		return e
+	}
+
+	e.caller = append(e.caller, fmt.Sprintf("%s:%d", file, line))
+	return e
+}
+
+func (e *entry) WithError(errs ...error) log.Entry {
+	le := len(errs)
+	if e.notValid() || le == 0 {
+		return e
+	}
+
+	if le == 1 {
+		err := errs[0]
+		if e.logger.errStack && err != nil {
+			var st common.StackTracer
+			st, err = common.WithStackTrace(err, 3)
+			e.fields[e.logger.names.Stack] = st.StackTrace()
+		}
+		if err != nil {
+			e.fields[e.logger.names.Error] = err.Error()
+		}
+		e.err = err
+		return e
+	}
+
+	// Keep multiple errors consistent with the other drivers: a list of
+	// error messages.
+	msgs := make([]string, 0, le)
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	e.fields[e.logger.names.Error] = msgs
+	e.err = multiError{errs}
+	return e
+}
+
+func (e *entry) WithField(key string, val interface{}) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.fields[key] = val
+	return e
+}
+
+func (e *entry) WithFields(fields map[string]interface{}) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	for key, val := range fields {
+		e.fields[key] = val
+	}
+	return e
+}
+
+func (e *entry) WithBool(key string, bls ...bool) log.Entry {
+	if len(bls) == 1 {
+		return e.WithField(key, bls[0])
+	}
+	if len(bls) > 1 {
+		return e.WithField(key, bls)
+	}
+	return e
+}
+
+func (e *entry) WithDur(key string, durs ...time.Duration) log.Entry {
+	if len(durs) == 1 {
+		return e.WithField(key, durs[0])
+	}
+	if len(durs) > 1 {
+		return e.WithField(key, durs)
+	}
+	return e
+}
+
+func (e *entry) WithInt(key string, is ...int) log.Entry {
+	if len(is) == 1 {
+		return e.WithField(key, is[0])
+	}
+	if len(is) > 1 {
+		return e.WithField(key, is)
+	}
+	return e
+}
+
+func (e *entry) WithUint(key string, us ...uint) log.Entry {
+	if len(us) == 1 {
+		return e.WithField(key, us[0])
+	}
+	if len(us) > 1 {
+		return e.WithField(key, us)
+	}
+	return e
+}
+
+func (e *entry) WithStr(key string, strs ...string) log.Entry {
+	if len(strs) == 1 {
+		return e.WithField(key, strs[0])
+	}
+	if len(strs) > 1 {
+		return e.WithField(key, strs)
+	}
+	return e
+}
+
+func (e *entry) WithTime(key string, ts ...time.Time) log.Entry {
+	if len(ts) == 1 {
+		return e.WithField(key, ts[0])
+	}
+	if len(ts) > 1 {
+		return e.WithField(key, ts)
+	}
+	return e
+}
+
+func (e *entry) WithFloat(key string, fs ...float64) log.Entry {
+	if len(fs) == 1 {
+		return e.WithField(key, fs[0])
+	}
+	if len(fs) > 1 {
+		return e.WithField(key, fs)
+	}
+	return e
+}
+
+func (e *entry) WithInt64(key string, is ...int64) log.Entry {
+	if len(is) == 1 {
+		return e.WithField(key, is[0])
+	}
+	if len(is) > 1 {
+		return e.WithField(key, is)
+	}
+	return e
+}
+
+func (e *entry) WithUint64(key string, us ...uint64) log.Entry {
+	if len(us) == 1 {
+		return e.WithField(key, us[0])
+	}
+	if len(us) > 1 {
+		return e.WithField(key, us)
+	}
+	return e
+}
+
+func (e *entry) WithBytes(key string, b []byte) log.Entry {
+	return e.WithField(key, string(b))
+}
+
+func (e *entry) WithHex(key string, b []byte) log.Entry {
+	return e.WithField(key, hex.EncodeToString(b))
+}
+
+func (e *entry) WithIP(key string, ips ...net.IP) log.Entry {
+	if len(ips) == 0 {
+		return e
+	}
+	return e.WithField(key, common.IPValue(ips))
+}
+
+func (e *entry) WithStringer(key string, val fmt.Stringer) log.Entry {
+	return e.WithField(key, common.StringerValue(val))
+}
+
+// The JSON is exported as the AnyValue it decodes to.
+func (e *entry) WithJSON(key string, raw []byte) log.Entry {
+	return e.WithField(key, json.RawMessage(raw))
+}
+
+// Nested objects are exported as KeyValueLists.
+func (e *entry) WithDict(key string, fn func(log.Dict)) log.Entry {
+	return e.WithField(key, log.DictFields(fn))
+}
+
+func (e *entry) WithObject(key string, obj log.ObjectMarshaler) log.Entry {
+	return e.WithField(key, log.ObjectFields(obj))
+}
+
+func (e *entry) Trace() log.Entry { return e.setLevel(log.TRACE) }
+func (e *entry) Debug() log.Entry { return e.setLevel(log.DEBUG) }
+func (e *entry) Info() log.Entry  { return e.setLevel(log.INFO) }
+func (e *entry) Warn() log.Entry  { return e.setLevel(log.WARN) }
+func (e *entry) Error() log.Entry { return e.setLevel(log.ERROR) }
+func (e *entry) Panic() log.Entry { return e.setLevel(log.PANIC) }
+func (e *entry) Fatal() log.Entry { return e.setLevel(log.FATAL) }
+
+func (e *entry) Msgf(format string, vals ...interface{}) {
+	e.Msg(fmt.Sprintf(format, vals...))
+}
+
+func (e *entry) Msg(msg string) {
+	if e.notValid() {
+		return
+	}
+
+	e.msg = e.logger.redact.Scrub(msg)
+	if !e.async {
+		e.Send()
+	}
+}
+
+// Send queues the entry for export. PANIC and FATAL entries are
+// exported before panicking or exiting.
+func (e *entry) Send() {
+	if e.notValid() {
+		return
+	}
+	l := e.logger
+	if !e.emit() {
+		return
+	}
+
+	switch e.lvl {
+	case log.PANIC:
+		_ = l.Flush()
+		panic(e.msg)
+	case log.FATAL:
+		_ = l.Flush()
+		os.Exit(1)
+	}
+}
+
+func (e *entry) Emit() {
+	e.emit()
+}
+
+// Queues the entry for export, reporting whether it was queued.
+func (e *entry) emit() bool {
+	if e.notValid() {
+		return false
+	}
+
+	// Nil out the logger as we're done with it. This disables future
+	// method calls on this type.
+	l := e.logger
+	e.logger = nil
+
+	if !e.lvl.IsEnabled(l.lvl.Level()) {
+		return false
+	}
+	if l.redact != nil {
+		for key, val := range e.fields {
+			e.fields[key] = l.redact.Redact(key, val)
+		}
+	}
+	if l.name != "" {
+		e.fields[log.LoggerNameField] = l.name
+	}
+	if !e.allowed(l) {
+		return false
+	}
+	e.fireHooks(l)
+
+	if len(e.caller) > 0 {
+		e.fields[l.names.Caller] = e.caller
+	}
+	l.exp.enqueue(newRecord(time.Now(), e.lvl, e.msg, e.fields))
+	return true
+}
+
+// Applies deduplication and sampling to an enabled entry.
+func (e *entry) allowed(l *logger) bool {
+	if !e.summary && !l.dedup.Allow(e.lvl, e.msg, e.fields) {
+		return false
+	}
+	return l.sampling.Sample(e.lvl)
+}
+
+// Fires the Hooks for the entry.
+func (e *entry) fireHooks(l *logger) {
+	if !l.hooks.Enabled(e.lvl) {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(e.fields))
+	for k, v := range e.fields {
+		fields[k] = v
+	}
+	if e.err != nil {
+		delete(fields, l.names.Error)
+	}
+	l.hooks.Fire(log.NewRecord(e.lvl, e.msg, fields, e.err, e.caller, time.Now()))
+}
+
+// Entry utility functions.
+
+func (e *entry) notValid() bool {
+	return e == nil || e.logger == nil
+}
+
+func (e *entry) setLevel(lvl log.Level) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.lvl = lvl
+	return e
+}
+
+// Multi-error utility implementation, for the Record of an entry with
+// several errors.
+type multiError struct {
+	errs []error
+}
+
+func (me multiError) Error() string {
+	sb := new(strings.Builder)
+	sb.Grow(len(me.errs) * 32)
+
+	for _, e := range me.errs {
+		fmt.Fprintf(sb, "%v\n", e)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,438 @@
+package otlp_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	"github.com/secureworks/logger/otlp"
+)
+
+// A collector stand-in that records the requests it receives and
+// responds with the statuses it is given, then 200s.
+type collector struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	statuses []int
+}
+
+func newCollector(t *testing.T, statuses ...int) *collector {
+	t.Helper()
+
+	c := &collector{statuses: statuses}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if len(c.statuses) > 0 {
+			status := c.statuses[0]
+			c.statuses = c.statuses[1:]
+			w.WriteHeader(status)
+			return
+		}
+		c.requests = append(c.requests, r)
+		c.bodies = append(c.bodies, body)
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *collector) received() ([]*http.Request, [][]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests, c.bodies
+}
+
+func newLogger(t *testing.T, c *collector, exp otlp.ExporterConfig, opts ...log.Option) log.Logger {
+	t.Helper()
+
+	exp.Endpoint = c.URL + "/v1/logs"
+	config := log.DefaultConfig(func(string) string { return "" })
+	config.Level = log.DEBUG
+	config.Resource = map[string]interface{}{"service.name": "agent"}
+	config.StaticFields = map[string]interface{}{log.VersionField: "1.0"}
+	logger, err := log.Open("otlp", config, append(opts, otlp.WithExporter(exp))...)
+	testutils.AssertNil(t, err)
+	t.Cleanup(func() { _ = logger.(io.Closer).Close() })
+	return logger
+}
+
+// OTLP JSON request, as far as the tests read it.
+type jsonRequest struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []jsonKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			LogRecords []struct {
+				TimeUnixNano   string                 `json:"timeUnixNano"`
+				SeverityNumber int                    `json:"severityNumber"`
+				SeverityText   string                 `json:"severityText"`
+				Body           map[string]interface{} `json:"body"`
+				Attributes     []jsonKeyValue         `json:"attributes"`
+				Flags          uint32                 `json:"flags"`
+				TraceID        string                 `json:"traceId"`
+				SpanID         string                 `json:"spanId"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type jsonKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func attrs(kvs []jsonKeyValue) map[string]map[string]interface{} {
+	m := make(map[string]map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestOTLP_JSON(t *testing.T) {
+	c := newCollector(t)
+	logger := newLogger(t, c, otlp.ExporterConfig{
+		Protocol: otlp.ProtocolJSON,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+	})
+
+	logger.With(map[string]interface{}{
+		log.TraceIDField:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		log.SpanIDField:     "00f067aa0ba902b7",
+		log.TraceFlagsField: "01",
+	}).Warn().
+		WithStr("str", "value").
+		WithStr("strs", "a", "b").
+		WithInt("int", 42).
+		WithBool("bool", true).
+		WithDur("dur", 1500*time.Microsecond).
+		WithField("map", map[string]interface{}{"nested": 1.5}).
+		WithError(errors.New("failed")).
+		Msg("exported")
+	logger.Debug().Msg("second")
+	testutils.AssertNil(t, logger.(log.Flusher).Flush())
+
+	requests, bodies := c.received()
+	testutils.AssertEqual(t, 1, len(requests))
+	testutils.AssertEqual(t, "application/json", requests[0].Header.Get("Content-Type"))
+	testutils.AssertEqual(t, "Bearer token", requests[0].Header.Get("Authorization"))
+	testutils.AssertEqual(t, "/v1/logs", requests[0].URL.Path)
+
+	var req jsonRequest
+	testutils.AssertNil(t, json.Unmarshal(bodies[0], &req))
+	testutils.AssertEqual(t, 1, len(req.ResourceLogs))
+	rl := req.ResourceLogs[0]
+	testutils.AssertEqual(t, map[string]interface{}{"stringValue": "agent"}, attrs(rl.Resource.Attributes)["service.name"])
+	testutils.AssertEqual(t, otlp.ScopeName, rl.ScopeLogs[0].Scope.Name)
+
+	records := rl.ScopeLogs[0].LogRecords
+	testutils.AssertEqual(t, 2, len(records))
+	rec := records[0]
+	testutils.AssertEqual(t, 13, rec.SeverityNumber)
+	testutils.AssertEqual(t, "WARN", rec.SeverityText)
+	testutils.AssertEqual(t, map[string]interface{}{"stringValue": "exported"}, rec.Body)
+	testutils.AssertEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", rec.TraceID)
+	testutils.AssertEqual(t, "00f067aa0ba902b7", rec.SpanID)
+	testutils.AssertEqual(t, uint32(1), rec.Flags)
+	testutils.AssertTrue(t, rec.TimeUnixNano != "")
+
+	got := attrs(rec.Attributes)
+	testutils.AssertEqual(t, map[string]interface{}{"stringValue": "value"}, got["str"])
+	testutils.AssertEqual(t, map[string]interface{}{"intValue": "42"}, got["int"])
+	testutils.AssertEqual(t, map[string]interface{}{"boolValue": true}, got["bool"])
+	testutils.AssertEqual(t, map[string]interface{}{"doubleValue": 1.5}, got["dur"])
+	testutils.AssertEqual(t, map[string]interface{}{"stringValue": "failed"}, got["error"])
+	testutils.AssertEqual(t, map[string]interface{}{"stringValue": "1.0"}, got[log.VersionField])
+	testutils.AssertEqual(t, map[string]interface{}{"arrayValue": map[string]interface{}{"values": []interface{}{
+		map[string]interface{}{"stringValue": "a"},
+		map[string]interface{}{"stringValue": "b"},
+	}}}, got["strs"])
+	testutils.AssertEqual(t, map[string]interface{}{"kvlistValue": map[string]interface{}{"values": []interface{}{
+		map[string]interface{}{"key": "nested", "value": map[string]interface{}{"doubleValue": 1.5}},
+	}}}, got["map"])
+	_, ok := got[log.TraceIDField]
+	testutils.AssertFalse(t, ok)
+
+	testutils.AssertEqual(t, 5, records[1].SeverityNumber)
+	testutils.AssertEqual(t, "", records[1].TraceID)
+}
+
+// A decoded protobuf message: the values of each field by number, as
+// uint64s for numeric wire types and []byte for the others.
+type protoMessage map[int][]interface{}
+
+func decodeProto(t *testing.T, b []byte) protoMessage {
+	t.Helper()
+
+	msg := make(protoMessage)
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		testutils.AssertTrue(t, n > 0)
+		b = b[n:]
+
+		field := int(tag >> 3)
+		switch tag & 7 {
+		case 0:
+			v, n := binary.Uvarint(b)
+			testutils.AssertTrue(t, n > 0)
+			msg[field] = append(msg[field], v)
+			b = b[n:]
+		case 1:
+			msg[field] = append(msg[field], binary.LittleEndian.Uint64(b))
+			b = b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			testutils.AssertTrue(t, n > 0)
+			msg[field] = append(msg[field], b[n:n+int(l)])
+			b = b[n+int(l):]
+		case 5:
+			msg[field] = append(msg[field], uint64(binary.LittleEndian.Uint32(b)))
+			b = b[4:]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+	}
+	return msg
+}
+
+func (m protoMessage) message(t *testing.T, field, i int) protoMessage {
+	t.Helper()
+	return decodeProto(t, m[field][i].([]byte))
+}
+
+func (m protoMessage) str(field int) string {
+	return string(m[field][0].([]byte))
+}
+
+// Returns the attributes in the KeyValues of field, by key.
+func (m protoMessage) attrs(t *testing.T, field int) map[string]protoMessage {
+	t.Helper()
+
+	out := make(map[string]protoMessage)
+	for i := range m[field] {
+		kv := m.message(t, field, i)
+		out[kv.str(1)] = kv.message(t, 2, 0)
+	}
+	return out
+}
+
+func TestOTLP_Protobuf(t *testing.T) {
+	c := newCollector(t)
+	logger := newLogger(t, c, otlp.ExporterConfig{})
+
+	logger.Named("db").Error().
+		WithStr("str", "value").
+		WithInt("neg", -7).
+		WithUint("big", math.MaxUint64).
+		WithBool("bool", false).
+		WithField("float", 2.25).
+		WithInt64("int64", -1<<40).
+		WithHex("hex", []byte{0xca, 0xfe}).
+		WithIP("ip", net.IPv4(192, 0, 2, 1)).
+		Msg("exported")
+	testutils.AssertNil(t, logger.(log.Flusher).Flush())
+
+	requests, bodies := c.received()
+	testutils.AssertEqual(t, 1, len(requests))
+	testutils.AssertEqual(t, "application/x-protobuf", requests[0].Header.Get("Content-Type"))
+
+	req := decodeProto(t, bodies[0])
+	rl := req.message(t, 1, 0)
+	resource := rl.message(t, 1, 0)
+	testutils.AssertEqual(t, "agent", resource.attrs(t, 1)["service.name"].str(1))
+
+	sl := rl.message(t, 2, 0)
+	testutils.AssertEqual(t, otlp.ScopeName, sl.message(t, 1, 0).str(1))
+	testutils.AssertEqual(t, 1, len(sl[2]))
+
+	rec := sl.message(t, 2, 0)
+	testutils.AssertTrue(t, rec[1][0].(uint64) > 0)
+	testutils.AssertEqual(t, uint64(17), rec[2][0])
+	testutils.AssertEqual(t, "ERROR", rec.str(3))
+	testutils.AssertEqual(t, "exported", rec.message(t, 5, 0).str(1))
+	testutils.AssertEqual(t, 0, len(rec[9])) // No trace context.
+
+	got := rec.attrs(t, 6)
+	testutils.AssertEqual(t, "value", got["str"].str(1))
+	testutils.AssertEqual(t, "db", got[log.LoggerNameField].str(1))
+	testutils.AssertEqual(t, int64(-7), int64(got["neg"][3][0].(uint64)))
+	testutils.AssertEqual(t, "18446744073709551615", got["big"].str(1))
+	testutils.AssertEqual(t, uint64(0), got["bool"][2][0])
+	testutils.AssertEqual(t, 2.25, math.Float64frombits(got["float"][4][0].(uint64)))
+	testutils.AssertEqual(t, int64(-1<<40), int64(got["int64"][3][0].(uint64)))
+	testutils.AssertEqual(t, "cafe", got["hex"].str(1))
+	testutils.AssertEqual(t, "192.0.2.1", got["ip"].str(1))
+}
+
+func TestOTLP_Export(t *testing.T) {
+	t.Run("batches entries", func(t *testing.T) {
+		c := newCollector(t)
+		logger := newLogger(t, c, otlp.ExporterConfig{Protocol: otlp.ProtocolJSON, BatchSize: 2})
+
+		for i := 0; i < 5; i++ {
+			logger.Info().WithInt("i", i).Msg("batched")
+		}
+		testutils.AssertNil(t, logger.(log.Flusher).Flush())
+
+		_, bodies := c.received()
+		testutils.AssertEqual(t, 3, len(bodies))
+		var req jsonRequest
+		testutils.AssertNil(t, json.Unmarshal(bodies[2], &req))
+		testutils.AssertEqual(t, 1, len(req.ResourceLogs[0].ScopeLogs[0].LogRecords))
+	})
+
+	t.Run("exports after the batch timeout", func(t *testing.T) {
+		c := newCollector(t)
+		logger := newLogger(t, c, otlp.ExporterConfig{BatchTimeout: 10 * time.Millisecond})
+
+		logger.Info().Msg("timed")
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, bodies := c.received(); len(bodies) > 0 {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatal("entry was not exported")
+	})
+
+	t.Run("retries retryable failures", func(t *testing.T) {
+		c := newCollector(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+		logger := newLogger(t, c, otlp.ExporterConfig{InitialBackoff: time.Millisecond})
+
+		logger.Info().Msg("retried")
+		testutils.AssertNil(t, logger.(log.Flusher).Flush())
+
+		_, bodies := c.received()
+		testutils.AssertEqual(t, 1, len(bodies))
+		testutils.AssertEqual(t, uint64(0), logger.(log.DroppedCounter).Dropped())
+	})
+
+	t.Run("drops entries on other failures", func(t *testing.T) {
+		c := newCollector(t, http.StatusBadRequest)
+		logger := newLogger(t, c, otlp.ExporterConfig{InitialBackoff: time.Millisecond})
+
+		logger.Info().Msg("rejected")
+		err := logger.(log.Flusher).Flush()
+		testutils.AssertNotNil(t, err)
+		testutils.AssertStringContains(t, "400 Bad Request", err.Error())
+		testutils.AssertEqual(t, uint64(1), logger.(log.DroppedCounter).Dropped())
+
+		logger.Info().Msg("accepted")
+		testutils.AssertNil(t, logger.(log.Flusher).Flush())
+		_, bodies := c.received()
+		testutils.AssertEqual(t, 1, len(bodies))
+	})
+
+	t.Run("bounds the queue", func(t *testing.T) {
+		release := make(chan struct{})
+		c := newCollector(t)
+		c.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		})
+		logger := newLogger(t, c, otlp.ExporterConfig{BatchSize: 1, QueueSize: 2})
+
+		for i := 0; i < 10; i++ {
+			logger.Info().Msg("queued")
+		}
+		close(release)
+		testutils.AssertNil(t, logger.(log.Flusher).Flush())
+		testutils.AssertTrue(t, logger.(log.DroppedCounter).Dropped() >= 7)
+	})
+
+	t.Run("drops entries once closed", func(t *testing.T) {
+		c := newCollector(t)
+		logger := newLogger(t, c, otlp.ExporterConfig{})
+
+		logger.Info().Msg("before")
+		testutils.AssertNil(t, logger.(io.Closer).Close())
+		logger.Info().Msg("after")
+
+		_, bodies := c.received()
+		testutils.AssertEqual(t, 1, len(bodies))
+		testutils.AssertEqual(t, uint64(1), logger.(log.DroppedCounter).Dropped())
+	})
+}
+
+func TestOTLP_Logger(t *testing.T) {
+	c := newCollector(t)
+	hook := &testutils.RecordingHook{Lvls: []log.Level{log.WARN}}
+	logger := newLogger(t, c, otlp.ExporterConfig{Protocol: otlp.ProtocolJSON}, log.WithHooks(hook))
+
+	logger.Trace().Msg("disabled")
+	logger.(log.LevelController).SetLevel(log.WARN)
+	logger.Info().Msg("disabled")
+	logger.Warn().WithError(errors.New("hooked")).Msg("enabled")
+	testutils.AssertNil(t, logger.(log.Flusher).Flush())
+
+	_, bodies := c.received()
+	testutils.AssertEqual(t, 1, len(bodies))
+	var req jsonRequest
+	testutils.AssertNil(t, json.Unmarshal(bodies[0], &req))
+	testutils.AssertEqual(t, 1, len(req.ResourceLogs[0].ScopeLogs[0].LogRecords))
+
+	records := hook.Records()
+	testutils.AssertEqual(t, 1, len(records))
+	testutils.AssertEqual(t, "enabled", records[0].Message())
+	testutils.AssertEqual(t, "hooked", records[0].Err().Error())
+
+	testutils.AssertEqual(t, log.WARN, logger.(log.LevelController).Level())
+}
+
+func TestDefaultExporterConfig(t *testing.T) {
+	env := map[string]string{
+		otlp.Endpoint.String():     "https://collector:4318/",
+		otlp.LogsProtocol.String(): otlp.ProtocolJSON,
+		otlp.Protocol.String():     otlp.ProtocolProtobuf,
+		otlp.Timeout.String():      "2500",
+		otlp.Headers.String():      "authorization=Bearer%20token, x-team=a",
+		otlp.LogsHeaders.String():  "x-team=b",
+	}
+	config, err := otlp.DefaultExporterConfig(func(key string) string { return env[key] })
+	testutils.AssertNil(t, err)
+
+	testutils.AssertEqual(t, "https://collector:4318/v1/logs", config.Endpoint)
+	testutils.AssertEqual(t, otlp.ProtocolJSON, config.Protocol)
+	testutils.AssertEqual(t, 2500*time.Millisecond, config.Timeout)
+	testutils.AssertEqual(t, map[string]string{"authorization": "Bearer token", "x-team": "b"}, config.Headers)
+
+	env[otlp.LogsEndpoint.String()] = "http://other/logs"
+	config, err = otlp.DefaultExporterConfig(func(key string) string { return env[key] })
+	testutils.AssertNil(t, err)
+	testutils.AssertEqual(t, "http://other/logs", config.Endpoint)
+
+	env[otlp.LogsTimeout.String()] = "soon"
+	env[otlp.LogsHeaders.String()] = "x-team=b,invalid"
+	config, err = otlp.DefaultExporterConfig(func(key string) string { return env[key] })
+	testutils.AssertEqual(t, `otlp: Invalid OTEL_EXPORTER_OTLP_LOGS_TIMEOUT (soon): strconv.Atoi: parsing "soon": invalid syntax`, err.Error())
+	testutils.AssertEqual(t, time.Duration(0), config.Timeout)
+	testutils.AssertEqual(t, map[string]string{"authorization": "Bearer token", "x-team": "b"}, config.Headers)
+
+	t.Setenv(otlp.LogsHeaders.String(), "invalid")
+	_, err = log.Open("otlp", nil)
+	testutils.AssertEqual(t, `otlp: Invalid OTEL_EXPORTER_OTLP_LOGS_HEADERS (invalid): invalid header "invalid", want name=value`, err.Error())
+
+	_, err = log.Open("otlp", nil, otlp.WithExporter(otlp.ExporterConfig{Protocol: "grpc"}))
+	testutils.AssertStringContains(t, "Unsupported protocol", err.Error())
+	testutils.AssertTrue(t, strings.HasPrefix(err.Error(), "otlp: "))
+}
@@ -0,0 +1,95 @@
+package logger_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	"github.com/secureworks/logger/testlogger"
+)
+
+func TestBurstSampler(t *testing.T) {
+	t.Run("first n then every mth", func(t *testing.T) {
+		s := log.NewBurstSampler(2, 3, time.Hour)
+
+		var written []int
+		for i := 1; i <= 10; i++ {
+			if s.Sample(log.DEBUG) {
+				written = append(written, i)
+			}
+		}
+		testutils.AssertEqual(t, []int{1, 2, 5, 8}, written)
+
+		// Levels are counted separately.
+		testutils.AssertTrue(t, s.Sample(log.INFO))
+	})
+
+	t.Run("interval resets counts", func(t *testing.T) {
+		s := log.NewBurstSampler(1, 0, 10*time.Millisecond)
+		testutils.AssertTrue(t, s.Sample(log.INFO))
+		testutils.AssertFalse(t, s.Sample(log.INFO))
+
+		time.Sleep(20 * time.Millisecond)
+		testutils.AssertTrue(t, s.Sample(log.INFO))
+	})
+
+}
+
+func TestRandomSampler(t *testing.T) {
+	testutils.AssertFalse(t, log.NewRandomSampler(0).Sample(log.INFO))
+	testutils.AssertTrue(t, log.NewRandomSampler(1).Sample(log.INFO))
+}
+
+func TestLevelSampler(t *testing.T) {
+	s := log.LevelSampler{
+		log.DEBUG: log.NewRandomSampler(0),
+		log.ERROR: log.NewBurstSampler(0, 0, 0),
+	}
+	testutils.AssertFalse(t, s.Sample(log.DEBUG))
+	testutils.AssertTrue(t, s.Sample(log.INFO))
+	testutils.AssertFalse(t, s.Sample(log.ERROR))
+}
+
+func TestSampling(t *testing.T) {
+	config := log.DefaultConfig(func(string) string { return "" })
+	config.Level = log.TRACE
+	config.Sampler = log.LevelSampler{
+		log.DEBUG: log.NewBurstSampler(1, 0, 0),
+		log.PANIC: log.NewRandomSampler(0), // Ignored: PANIC is always written.
+	}
+	logger := testlogger.MustNew(config)
+	child := logger.With(map[string]interface{}{"key": "value"})
+
+	logger.Debug().Msg("written")
+	child.Debug().Msg("dropped")
+	child.Info().Msg("written")
+	func() {
+		defer func() { testutils.AssertNotNil(t, recover()) }()
+		logger.Panic().Msg("written")
+	}()
+
+	entries := logger.GetEntries()
+	testutils.AssertEqual(t, 4, len(entries))
+	testutils.AssertTrue(t, entries[0].Sent)
+	testutils.AssertTrue(t, entries[1].Dropped)
+	testutils.AssertFalse(t, entries[1].Sent)
+	testutils.AssertTrue(t, entries[2].Sent)
+	testutils.AssertTrue(t, entries[3].Sent)
+
+	testutils.AssertEqual(t, uint64(1), logger.Dropped())
+	testutils.AssertEqual(t, uint64(1), child.(log.DroppedCounter).Dropped())
+
+	var nilSampling *log.Sampling
+	testutils.AssertTrue(t, nilSampling.Sample(log.TRACE))
+	testutils.AssertEqual(t, uint64(0), nilSampling.Dropped())
+
+	// Errors are not sampled unless set in a LevelSampler.
+	sampling := log.NewSampling(log.NewRandomSampler(0))
+	testutils.AssertFalse(t, sampling.Sample(log.WARN))
+	testutils.AssertTrue(t, sampling.Sample(log.ERROR))
+
+	sampling = log.NewSampling(log.LevelSampler{log.ERROR: log.NewRandomSampler(0)})
+	testutils.AssertTrue(t, sampling.Sample(log.WARN))
+	testutils.AssertFalse(t, sampling.Sample(log.ERROR))
+}
@@ -0,0 +1,183 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/secureworks/errors"
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/logrus"
+	"github.com/secureworks/logger/testlogger"
+	_ "github.com/secureworks/logger/zerolog"
+)
+
+func TestSchema(t *testing.T) {
+	render := func(t *testing.T, driver string, config *log.Config) map[string]interface{} {
+		t.Helper()
+
+		out := new(bytes.Buffer)
+		config.Level = log.INFO
+		config.Output = out
+		config.EnableErrStack = true
+		config.StaticFields = map[string]interface{}{log.ServiceField: "agent"}
+		logger, err := log.Open(driver, config)
+		testutils.AssertNil(t, err)
+
+		logger.Warn().
+			Caller().
+			WithError(errors.New("boom")).
+			Msg("message")
+
+		fields := make(map[string]interface{})
+		testutils.AssertNil(t, json.Unmarshal(out.Bytes(), &fields))
+		return fields
+	}
+	assertUTC := func(t *testing.T, val interface{}) {
+		t.Helper()
+
+		str, _ := val.(string)
+		ts, err := time.Parse(time.RFC3339Nano, str)
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, time.UTC, ts.Location())
+	}
+
+	for _, driver := range []string{"zerolog", "logrus"} {
+		t.Run(driver, func(t *testing.T) {
+			t.Run("ecs", func(t *testing.T) {
+				fields := render(t, driver, &log.Config{Schema: log.ECSSchema()})
+
+				testutils.AssertEqual(t,
+					[]string{
+						"@timestamp", "error.message", "error.stack_trace", "log.level",
+						"log.origin.file.name", "message", "service.name",
+					},
+					sortedKeys(fields),
+				)
+				testutils.AssertEqual(t, "warn", fields["log.level"])
+				testutils.AssertEqual(t, "boom", fields["error.message"])
+				testutils.AssertEqual(t, "agent", fields["service.name"])
+				assertUTC(t, fields["@timestamp"])
+			})
+
+			t.Run("gcp", func(t *testing.T) {
+				fields := render(t, driver, &log.Config{Schema: log.GCPSchema()})
+
+				testutils.AssertEqual(t,
+					[]string{"caller", "error", "message", "service", "severity", "stack_trace", "time"},
+					sortedKeys(fields),
+				)
+				testutils.AssertEqual(t, "WARNING", fields["severity"])
+				assertUTC(t, fields["time"])
+			})
+
+			t.Run("config takes precedence", func(t *testing.T) {
+				fields := render(t, driver, &log.Config{
+					Schema:     log.GCPSchema(),
+					FieldNames: log.FieldNames{Level: "lvl"},
+					TimeFormat: log.TimeFormatUnix,
+				})
+
+				testutils.AssertEqual(t, "WARNING", fields["lvl"])
+				testutils.AssertEqual(t, nil, fields["severity"])
+				_, ok := fields["time"].(float64)
+				testutils.AssertTrue(t, ok)
+			})
+		})
+	}
+
+	t.Run("testlogger", func(t *testing.T) {
+		logger, err := testlogger.New(&log.Config{
+			Level:        log.INFO,
+			Schema:       log.GCPSchema(),
+			StaticFields: map[string]interface{}{log.TraceIDField: "abc"},
+		})
+		testutils.AssertNil(t, err)
+		logger.Warn().WithError(errors.New("boom")).Msg("message")
+
+		testutils.AssertEqual(t,
+			`{"error":"boom","logging.googleapis.com/trace":"abc","message":"message","severity":"WARNING"}`,
+			logger.Config.Output.(*bytes.Buffer).String(),
+		)
+		testutils.AssertEqual(t, log.GCPSchema(), log.SchemaOf(logger))
+	})
+
+	t.Run("gcp trace", func(t *testing.T) {
+		logger, err := testlogger.New(&log.Config{
+			Level:  log.INFO,
+			Schema: log.GCPProjectSchema("proj"),
+			StaticFields: map[string]interface{}{
+				log.TraceIDField:    "abc",
+				log.SpanIDField:     "def",
+				log.TraceFlagsField: "01",
+			},
+		})
+		testutils.AssertNil(t, err)
+		logger.Info().Msg("message")
+
+		testutils.AssertEqual(t,
+			`{"logging.googleapis.com/spanId":"def","logging.googleapis.com/trace":"projects/proj/traces/abc","logging.googleapis.com/trace_sampled":true,"message":"message","severity":"INFO"}`,
+			logger.Config.Output.(*bytes.Buffer).String(),
+		)
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		config := log.DefaultConfig(func(key string) string {
+			if key == log.LogSchema.String() {
+				return "ECS"
+			}
+			return ""
+		})
+		testutils.AssertEqual(t, log.ECSSchema(), config.Schema)
+
+		config = log.DefaultConfig(func(key string) string {
+			switch key {
+			case log.LogSchema.String():
+				return "gcp"
+			case log.GoogleCloudProject.String():
+				return "proj"
+			}
+			return ""
+		})
+		testutils.AssertEqual(t, log.GCPProjectSchema("proj"), config.Schema)
+
+		config = log.DefaultConfig(func(key string) string {
+			if key == log.LogSchema.String() {
+				return "splunk"
+			}
+			return ""
+		})
+		testutils.AssertNil(t, config.Schema)
+	})
+}
+
+func TestSchema_Duration(t *testing.T) {
+	d := 1500 * time.Microsecond
+
+	testutils.AssertEqual(t, "1.5ms", (*log.Schema)(nil).Duration(d))
+	testutils.AssertEqual(t, "1.5ms", (&log.Schema{}).Duration(d))
+	testutils.AssertEqual(t, int64(1500000), log.ECSSchema().Duration(d))
+	testutils.AssertEqual(t, "0.0015s", log.GCPSchema().Duration(d))
+}
+
+func TestNewLevelNames(t *testing.T) {
+	for _, tc := range []struct {
+		config *log.Config
+		want   []string
+	}{
+		{&log.Config{}, []string{"trace", "debug", "info", "warn", "error", "panic", "fatal"}},
+		{&log.Config{LevelCase: log.UpperCaseLevels}, []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "PANIC", "FATAL"}},
+		{&log.Config{Schema: log.ECSSchema()}, []string{"trace", "debug", "info", "warn", "error", "panic", "fatal"}},
+		{&log.Config{Schema: log.GCPSchema()}, []string{"DEBUG", "DEBUG", "INFO", "WARNING", "ERROR", "ALERT", "EMERGENCY"}},
+	} {
+		names := log.NewLevelNames(tc.config)
+		got := make([]string, 0, len(tc.want))
+		for lvl := log.TRACE; lvl <= log.FATAL; lvl++ {
+			got = append(got, names.Name(lvl))
+		}
+		testutils.AssertEqual(t, tc.want, got)
+		testutils.AssertEqual(t, "Level(9)", names.Name(log.Level(9)))
+	}
+}
@@ -0,0 +1,1020 @@
+// Package slog implements a logger with a log/slog driver. See the
+// documentation associated with the Logger, Entry and UnderlyingLogger
+// interfaces for their respective methods.
+package slog
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/secureworks/logger/internal/common"
+	"github.com/secureworks/logger/internal/format"
+	"github.com/secureworks/logger/log"
+)
+
+// Levels supported by this package that log/slog does not define. They
+// are spaced the same way log/slog spaces its own levels.
+const (
+	// LevelTrace is the slog.Level used for log.TRACE.
+	LevelTrace = slog.LevelDebug - 4
+
+	// LevelPanic is the slog.Level used for log.PANIC.
+	LevelPanic = slog.LevelError + 4
+
+	// LevelFatal is the slog.Level used for log.FATAL.
+	LevelFatal = slog.LevelError + 8
+)
+
+// Register logger.
+func init() {
+	log.Register("slog", newLogger)
+}
+
+// newLogger instantiates a new log.Logger with a log/slog driver using
+// the given configuration and options. JSONFormat uses a
+// slog.JSONHandler, and ImplementationDefaultFormat a slog.TextHandler,
+// which writes its own flavor of logfmt. LogfmtFormat and ConsoleFormat
+// are rendered from the lines of a slog.JSONHandler, as the other
+// drivers render them.
+func newLogger(config *log.Config, opts ...log.Option) (log.Logger, error) {
+	output := config.Output
+	if output == nil {
+		output = os.Stderr
+	}
+	var async *log.AsyncWriter
+	// Detect terminals before output is wrapped by an AsyncWriter.
+	terminal := format.IsTerminal(output)
+	if config.Async != nil {
+		async = log.NewAsyncWriter(output, config.Async)
+		output = async
+	}
+
+	var encode format.Encoder
+	switch config.Format {
+	case log.LogfmtFormat:
+		encode = format.AppendLogfmt
+	case log.ConsoleFormat:
+		encode = format.ConsoleEncoder(terminal)
+	}
+
+	names := log.NewFieldNames(config)
+	replacer := attrReplacer{
+		names:  &names,
+		levels: log.NewLevelNames(config),
+		stamp:  log.NewTimestamp(config),
+		text:   encode != nil,
+	}
+	// Levels are filtered by this driver, so that loggers created using
+	// Named may be enabled at lower levels than their parents.
+	hopts := &slog.HandlerOptions{
+		Level:       LevelTrace,
+		ReplaceAttr: replacer.replace,
+	}
+
+	var handler slog.Handler
+	switch {
+	case encode != nil:
+		w := &format.Writer{
+			Out:        output,
+			Encode:     encode,
+			LevelKey:   names.Level,
+			MessageKey: names.Message,
+			StackKey:   names.Stack,
+		}
+		if replacer.stamp != nil {
+			w.TimeKey = replacer.stamp.Key
+		}
+		handler = slog.NewJSONHandler(w, hopts)
+	case config.Format == log.ImplementationDefaultFormat:
+		handler = slog.NewTextHandler(output, hopts)
+	default:
+		handler = slog.NewJSONHandler(output, hopts)
+	}
+
+	logger := &logger{
+		lg:        slog.New(handler),
+		lvl:       log.NewAtomicLevel(config.Level),
+		overrides: config.LevelOverrides,
+		sampling:  log.NewSampling(config.Sampler),
+		redact:    config.Redaction,
+		hooks:     new(log.Hooks),
+		async:     async,
+		names:     &names,
+		schema:    config.Schema,
+		errStack:  config.EnableErrStack,
+	}
+	logger.flusher, _ = output.(log.Flusher)
+	logger.dedup = log.NewDeduper(config.Dedup, logger.writeDedupSummary)
+
+	// Apply options.
+	for _, opt := range opts {
+		if err := opt(logger); err != nil {
+			return nil, err
+		}
+	}
+
+	// Bind the static fields as With does, so that fields with the same
+	// keys replace them.
+	if len(config.StaticFields) > 0 {
+		logger.bind(config.Schema.Fields(config.StaticFields))
+	}
+	return logger, nil
+}
+
+// Logger implementation.
+
+type logger struct {
+	lg        *slog.Logger
+	lvl       *log.AtomicLevel
+	name      string
+	overrides log.LevelOverrides
+	sampling  *log.Sampling
+	dedup     *log.Deduper
+	redact    *log.RedactionPolicy
+	hooks     *log.Hooks
+	fields    map[string]interface{} // Fields bound using With.
+	attrs     []slog.Attr            // The fields, sorted by key, see emit.
+	async     *log.AsyncWriter       // Set if created for Config.Async.
+	flusher   log.Flusher            // Set if the output is a log.Flusher.
+	names     *log.FieldNames
+	schema    *log.Schema
+	errStack  bool
+}
+
+var _ log.Logger = (*logger)(nil)
+var _ log.UnderlyingLogger = (*logger)(nil)
+var _ log.LevelController = (*logger)(nil)
+var _ log.DroppedCounter = (*logger)(nil)
+var _ log.HookLogger = (*logger)(nil)
+var _ log.Flusher = (*logger)(nil)
+var _ log.SchemaLogger = (*logger)(nil)
+var _ io.Closer = (*logger)(nil)
+
+func (l *logger) IsLevelEnabled(lvl log.Level) bool {
+	if l.notValid() {
+		return false
+	}
+	slvl := lvlToSlog(lvl)
+	return slvl >= lvlToSlog(l.lvl.Level()) && l.lg.Enabled(context.Background(), slvl)
+}
+
+func (l *logger) WithError(err error) log.Entry {
+	return l.Error().WithError(err)
+}
+
+func (l *logger) WithField(key string, val interface{}) log.Entry {
+	return l.Entry(0).WithField(key, val)
+}
+
+func (l *logger) WithFields(fields map[string]interface{}) log.Entry {
+	return l.Entry(0).WithFields(fields)
+}
+
+// With returns a child logger with the fields bound to it. They are
+// added to the record of each entry, rather than bound using
+// slog.Logger.With, so that fields set on the entry replace them instead
+// of being written twice.
+func (l *logger) With(fields map[string]interface{}) log.Logger {
+	if l.notValid() || len(fields) == 0 {
+		return l
+	}
+
+	child := *l
+	child.fields = make(map[string]interface{}, len(l.fields)+len(fields))
+	for key, val := range l.fields {
+		child.fields[key] = val
+	}
+	for key, val := range l.redact.RedactFields(fields) {
+		child.fields[key] = val
+	}
+
+	// Sort keys: slog writes attributes in the order given.
+	keys := make([]string, 0, len(child.fields))
+	for key := range child.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	child.attrs = make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		child.attrs = append(child.attrs, slog.Any(key, child.fields[key]))
+	}
+	return &child
+}
+
+// Binds fields to the receiver itself, rather than to a child as With
+// does, so that they are also written by entries such as dedup
+// summaries that the receiver logs.
+func (l *logger) bind(fields map[string]interface{}) {
+	*l = *l.With(fields).(*logger)
+}
+
+func (l *logger) Named(name string) log.Logger {
+	if l.notValid() {
+		return l
+	}
+
+	child := *l
+	child.name = log.JoinName(l.name, name)
+	if lvl, inherit := l.overrides.NamedLevel(l.name, child.name); !inherit {
+		child.lvl = log.NewAtomicLevel(lvl)
+	}
+	return &child
+}
+
+func (l *logger) Entry(lvl log.Level) log.Entry {
+	return l.newEntry(lvlToSlog(lvl))
+}
+
+func (l *logger) Trace() log.Entry { return l.newEntry(LevelTrace) }
+func (l *logger) Debug() log.Entry { return l.newEntry(slog.LevelDebug) }
+func (l *logger) Info() log.Entry  { return l.newEntry(slog.LevelInfo) }
+func (l *logger) Warn() log.Entry  { return l.newEntry(slog.LevelWarn) }
+func (l *logger) Error() log.Entry { return l.newEntry(slog.LevelError) }
+func (l *logger) Panic() log.Entry { return l.newEntry(LevelPanic) }
+func (l *logger) Fatal() log.Entry { return l.newEntry(LevelFatal) }
+
+func (l *logger) WriteCloser(lvl log.Level) io.WriteCloser {
+	return writeLevelCloser{log: l, lvl: lvl}
+}
+
+// LevelController implementation.
+
+// SetLevel changes the level of the logger, its children and any of
+// their entries that have not been sent yet. The handler created by
+// this driver is enabled for every level, and entries are filtered by
+// this driver; a handler set using SetLogger may filter them further.
+func (l *logger) SetLevel(lvl log.Level) {
+	if l == nil || l.lvl == nil {
+		return
+	}
+	l.lvl.SetLevel(lvl)
+}
+
+func (l *logger) Level() log.Level {
+	if l == nil || l.lvl == nil {
+		return log.INFO
+	}
+	return l.lvl.Level()
+}
+
+// DroppedCounter implementation.
+
+// Dropped returns the number of entries dropped by Config.Sampler, and
+// by the log.AsyncWriter used for Config.Async.
+func (l *logger) Dropped() uint64 {
+	if l == nil {
+		return 0
+	}
+	return l.sampling.Dropped() + l.async.Dropped()
+}
+
+// Flusher and io.Closer implementation.
+
+// Flush writes the pending dedup summaries, and flushes the output if
+// it is a log.Flusher, such as the log.AsyncWriter used for
+// Config.Async.
+func (l *logger) Flush() error {
+	if l == nil {
+		return nil
+	}
+	l.dedup.Flush()
+	if l.flusher == nil {
+		return nil
+	}
+	return l.flusher.Flush()
+}
+
+// Close writes the pending dedup summaries, flushes the output and
+// closes the log.AsyncWriter used for Config.Async, which is shared
+// with the parent and children of the logger. Other outputs are not
+// closed.
+func (l *logger) Close() error {
+	if l != nil && l.async != nil {
+		l.dedup.Flush()
+		return l.async.Close()
+	}
+	return l.Flush()
+}
+
+// HookLogger implementation.
+
+func (l *logger) Hooks() *log.Hooks {
+	if l == nil {
+		return nil
+	}
+	return l.hooks
+}
+
+// SchemaLogger implementation.
+
+func (l *logger) Schema() *log.Schema {
+	if l == nil {
+		return nil
+	}
+	return l.schema
+}
+
+// UnderlyingLogger implementation.
+
+// GetLogger returns the underlying *slog.Logger.
+func (l *logger) GetLogger() interface{} {
+	if l.notValid() {
+		return nil
+	}
+	return l.lg
+}
+
+// SetLogger replaces the underlying *slog.Logger. A slog.Handler is
+// also accepted, in which case a new *slog.Logger is created with it.
+func (l *logger) SetLogger(iface interface{}) {
+	if l == nil {
+		return
+	}
+	switch v := iface.(type) {
+	case *slog.Logger:
+		if v != nil {
+			l.lg = v
+		}
+	case slog.Handler:
+		l.lg = slog.New(v)
+	}
+}
+
+// Logger utility functions.
+
+// Creates a new entry at the given level.
+func (l *logger) newEntry(lvl slog.Level) log.Entry {
+	if l.notValid() {
+		return (*entry)(nil)
+	}
+
+	return &entry{
+		lg:       l.lg,
+		attrs:    make([]slog.Attr, 0, 4),
+		name:     l.name,
+		names:    l.names,
+		errStack: l.errStack,
+		loglvl:   l.lvl,
+		sampling: l.sampling,
+		dedup:    l.dedup,
+		redact:   l.redact,
+		hooks:    l.hooks,
+		flusher:  l.flusher,
+		bound:    l.fields,
+		battrs:   l.attrs,
+		lvl:      lvl,
+	}
+}
+
+// Writes the summary of entries suppressed by l.dedup.
+func (l *logger) writeDedupSummary(s log.DedupSummary) {
+	e, _ := l.newEntry(lvlToSlog(s.Level)).(*entry)
+	if e.notValid() {
+		return
+	}
+	e.summary = true
+	e.WithFields(s.Fields).
+		WithStr(log.SuppressedMessage, s.Message).
+		WithInt(log.SuppressedCount, s.Count).
+		Msg(s.Msg())
+}
+
+func (l *logger) notValid() bool {
+	return l == nil || l.lg == nil
+}
+
+// Map slog levels to log.Level; levels in between are rounded down.
+func lvlFromSlog(lvl slog.Level) log.Level {
+	switch {
+	case lvl >= LevelFatal:
+		return log.FATAL
+	case lvl >= LevelPanic:
+		return log.PANIC
+	case lvl >= slog.LevelError:
+		return log.ERROR
+	case lvl >= slog.LevelWarn:
+		return log.WARN
+	case lvl >= slog.LevelInfo:
+		return log.INFO
+	case lvl >= slog.LevelDebug:
+		return log.DEBUG
+	default:
+		return log.TRACE
+	}
+}
+
+// Map log.Level to slog levels.
+func lvlToSlog(lvl log.Level) slog.Level {
+	switch lvl {
+	case log.TRACE:
+		return LevelTrace
+	case log.DEBUG:
+		return slog.LevelDebug
+	case log.INFO:
+		return slog.LevelInfo
+	case log.WARN:
+		return slog.LevelWarn
+	case log.ERROR:
+		return slog.LevelError
+	case log.PANIC:
+		return LevelPanic
+	case log.FATAL:
+		return LevelFatal
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// attrReplacer replaces the built-in attributes of records, so that
+// the core values are named and formatted as set by the log.Config, as
+// the other drivers do.
+type attrReplacer struct {
+	names  *log.FieldNames
+	levels *log.LevelNames
+	stamp  *log.Timestamp // Nil if times are not written.
+	text   bool           // Set for the formats rendered by internal/format.
+}
+
+// Used as slog.HandlerOptions.ReplaceAttr. The handlers ignore an
+// empty slog.Attr, which is returned for times that are not written and
+// empty messages. Levels log/slog does not know about would otherwise
+// be written as offsets, eg: "ERROR+4".
+func (r attrReplacer) replace(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+
+	switch attr.Key {
+	case slog.TimeKey:
+		if attr.Value.Kind() != slog.KindTime {
+			break
+		}
+		if r.stamp == nil {
+			return slog.Attr{}
+		}
+		switch t := r.stamp.Value(attr.Value.Time()).(type) {
+		case int64:
+			return slog.Int64(r.stamp.Key, t)
+		default:
+			return slog.String(r.stamp.Key, t.(string))
+		}
+	case slog.LevelKey:
+		if lvl, ok := attr.Value.Any().(slog.Level); ok {
+			return slog.String(r.names.Level, r.levels.Name(lvlFromSlog(lvl)))
+		}
+	case slog.MessageKey:
+		if attr.Value.Kind() != slog.KindString {
+			break
+		}
+		if attr.Value.String() == "" {
+			return slog.Attr{}
+		}
+		return slog.String(r.names.Message, attr.Value.String())
+	}
+	if r.text {
+		return r.textField(attr)
+	}
+	return attr
+}
+
+// Returns attr as the other drivers write it in the text formats:
+// values are normalized as Zerolog encodes them, and keys that collide
+// with the core values are prefixed, as internal/format prefixes them.
+// Otherwise log/slog would write the core values first, and the
+// colliding fields would replace them.
+func (r attrReplacer) textField(attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindDuration, slog.KindTime, slog.KindAny:
+		attr.Value = slog.AnyValue(format.Normalize(attr.Value.Any()))
+	}
+	if attr.Key == r.names.Level || attr.Key == r.names.Message ||
+		(r.stamp != nil && attr.Key == r.stamp.Key) {
+		attr.Key = "fields." + attr.Key
+	}
+	return attr
+}
+
+// WriteCloser hook implementation.
+
+type writeLevelCloser struct {
+	log log.Logger
+	lvl log.Level
+}
+
+func (wlc writeLevelCloser) Write(p []byte) (n int, err error) {
+	n = len(p)
+	if n > 0 && p[n-1] == '\n' {
+		// Trim CR added by stdlog.
+		p = p[0 : n-1]
+	}
+	wlc.log.Entry(wlc.lvl).Msg(string(p))
+	return
+}
+
+func (wlc writeLevelCloser) Close() error {
+	return nil
+}
+
+// Entry implementation.
+
+type entry struct {
+	lg       *slog.Logger
+	attrs    []slog.Attr
+	caller   []string
+	msg      string
+	async    bool
+	name     string // Written at Send, as slog.Logger.With can't replace attributes.
+	names    *log.FieldNames
+	errStack bool
+	loglvl   *log.AtomicLevel
+	sampling *log.Sampling
+	dedup    *log.Deduper
+	redact   *log.RedactionPolicy
+	hooks    *log.Hooks
+	flusher  log.Flusher            // Flushed before panicking or exiting.
+	bound    map[string]interface{} // Fields bound to the logger.
+	battrs   []slog.Attr            // The bound fields as attributes.
+	err      error                  // Set using WithError, for Hooks.
+	summary  bool                   // Set on dedup summaries.
+	lvl      slog.Level
+}
+
+var _ log.Entry = (*entry)(nil)
+var _ log.UnderlyingLogger = (*entry)(nil)
+var _ log.Emitter = (*entry)(nil)
+
+func (e *entry) Async() log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.async = !e.async
+	return e
+}
+
+func (e *entry) Caller(skip ...int) log.Entry {
+	if e.notValid() {
+		return e
+	}
+
+	sk := 1
+	if len(skip) > 0 {
+		sk += skip[0]
+	}
+
+	_, file, line, ok := runtime.Caller(sk)
+	if !ok {
+		return e
+	}
+
+	e.caller = append(e.caller, fmt.Sprintf("%s:%d", file, line))
+	return e
+}
+
+func (e *entry) WithError(errs ...error) log.Entry {
+	le := len(errs)
+	if e.notValid() || le == 0 {
+		return e
+	}
+
+	if le == 1 {
+		err := errs[0]
+		if err == nil {
+			return e
+		}
+		if e.errStack {
+			var st common.StackTracer
+			st, err = common.WithStackTrace(err, 3)
+			e.attrs = append(e.attrs, slog.Any(e.names.Stack, st.StackTrace()))
+		}
+		e.attrs = append(e.attrs, slog.Any(e.names.Error, err))
+		e.err = err
+		return e
+	}
+
+	// Keep multiple errors consistent with the other drivers: a list of
+	// error messages.
+	msgs := make([]string, 0, le)
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	e.attrs = append(e.attrs, slog.Any(e.names.Error, msgs))
+	e.err = multiError{errs}
+	return e
+}
+
+func (e *entry) WithField(key string, val interface{}) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.attrs = append(e.attrs, slog.Any(key, val))
+	return e
+}
+
+func (e *entry) WithFields(fields map[string]interface{}) log.Entry {
+	if e.notValid() || len(fields) == 0 {
+		return e
+	}
+	for key, val := range fields {
+		e.attrs = append(e.attrs, slog.Any(key, val))
+	}
+	return e
+}
+
+func (e *entry) WithBool(key string, bls ...bool) log.Entry {
+	lb := len(bls)
+	if e.notValid() || lb == 0 {
+		return e
+	}
+
+	if lb == 1 {
+		e.attrs = append(e.attrs, slog.Bool(key, bls[0]))
+	} else {
+		e.attrs = append(e.attrs, slog.Any(key, bls))
+	}
+	return e
+}
+
+func (e *entry) WithDur(key string, durs ...time.Duration) log.Entry {
+	ld := len(durs)
+	if e.notValid() || ld == 0 {
+		return e
+	}
+
+	if ld == 1 {
+		e.attrs = append(e.attrs, slog.Duration(key, durs[0]))
+	} else {
+		e.attrs = append(e.attrs, slog.Any(key, durs))
+	}
+	return e
+}
+
+func (e *entry) WithInt(key string, is ...int) log.Entry {
+	li := len(is)
+	if e.notValid() || li == 0 {
+		return e
+	}
+
+	if li == 1 {
+		e.attrs = append(e.attrs, slog.Int(key, is[0]))
+	} else {
+		e.attrs = append(e.attrs, slog.Any(key, is))
+	}
+	return e
+}
+
+func (e *entry) WithUint(key string, us ...uint) log.Entry {
+	lu := len(us)
+	if e.notValid() || lu == 0 {
+		return e
+	}
+
+	if lu == 1 {
+		e.attrs = append(e.attrs, slog.Uint64(key, uint64(us[0])))
+	} else {
+		e.attrs = append(e.attrs, slog.Any(key, us))
+	}
+	return e
+}
+
+func (e *entry) WithStr(key string, strs ...string) log.Entry {
+	ls := len(strs)
+	if e.notValid() || ls == 0 {
+		return e
+	}
+
+	if ls == 1 {
+		e.attrs = append(e.attrs, slog.String(key, strs[0]))
+	} else {
+		e.attrs = append(e.attrs, slog.Any(key, strs))
+	}
+	return e
+}
+
+func (e *entry) WithTime(key string, ts ...time.Time) log.Entry {
+	lt := len(ts)
+	if e.notValid() || lt == 0 {
+		return e
+	}
+
+	if lt == 1 {
+		e.attrs = append(e.attrs, slog.Time(key, ts[0]))
+	} else {
+		e.attrs = append(e.attrs, slog.Any(key, ts))
+	}
+	return e
+}
+
+func (e *entry) WithFloat(key string, fs ...float64) log.Entry {
+	lf := len(fs)
+	if e.notValid() || lf == 0 {
+		return e
+	}
+
+	if lf == 1 {
+		e.attrs = append(e.attrs, slog.Float64(key, fs[0]))
+	} else {
+		e.attrs = append(e.attrs, slog.Any(key, fs))
+	}
+	return e
+}
+
+func (e *entry) WithInt64(key string, is ...int64) log.Entry {
+	li := len(is)
+	if e.notValid() || li == 0 {
+		return e
+	}
+
+	if li == 1 {
+		e.attrs = append(e.attrs, slog.Int64(key, is[0]))
+	} else {
+		e.attrs = append(e.attrs, slog.Any(key, is))
+	}
+	return e
+}
+
+func (e *entry) WithUint64(key string, us ...uint64) log.Entry {
+	lu := len(us)
+	if e.notValid() || lu == 0 {
+		return e
+	}
+
+	if lu == 1 {
+		e.attrs = append(e.attrs, slog.Uint64(key, us[0]))
+	} else {
+		e.attrs = append(e.attrs, slog.Any(key, us))
+	}
+	return e
+}
+
+func (e *entry) WithBytes(key string, b []byte) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.attrs = append(e.attrs, slog.String(key, string(b)))
+	return e
+}
+
+func (e *entry) WithHex(key string, b []byte) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.attrs = append(e.attrs, slog.String(key, hex.EncodeToString(b)))
+	return e
+}
+
+func (e *entry) WithIP(key string, ips ...net.IP) log.Entry {
+	if e.notValid() || len(ips) == 0 {
+		return e
+	}
+	e.attrs = append(e.attrs, slog.Any(key, common.IPValue(ips)))
+	return e
+}
+
+func (e *entry) WithStringer(key string, val fmt.Stringer) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.attrs = append(e.attrs, slog.Any(key, common.StringerValue(val)))
+	return e
+}
+
+// The JSON handler writes a json.RawMessage as is; the text handler
+// writes it as a quoted string.
+func (e *entry) WithJSON(key string, raw []byte) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.attrs = append(e.attrs, slog.Any(key, json.RawMessage(raw)))
+	return e
+}
+
+// Nested objects are written as maps, see log.DictFields.
+func (e *entry) WithDict(key string, fn func(log.Dict)) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.attrs = append(e.attrs, slog.Any(key, log.DictFields(fn)))
+	return e
+}
+
+func (e *entry) WithObject(key string, obj log.ObjectMarshaler) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.attrs = append(e.attrs, slog.Any(key, log.ObjectFields(obj)))
+	return e
+}
+
+func (e *entry) Trace() log.Entry { return e.setLevel(LevelTrace) }
+func (e *entry) Debug() log.Entry { return e.setLevel(slog.LevelDebug) }
+func (e *entry) Info() log.Entry  { return e.setLevel(slog.LevelInfo) }
+func (e *entry) Warn() log.Entry  { return e.setLevel(slog.LevelWarn) }
+func (e *entry) Error() log.Entry { return e.setLevel(slog.LevelError) }
+func (e *entry) Panic() log.Entry { return e.setLevel(LevelPanic) }
+func (e *entry) Fatal() log.Entry { return e.setLevel(LevelFatal) }
+
+func (e *entry) Msgf(format string, vals ...interface{}) {
+	e.Msg(fmt.Sprintf(format, vals...))
+}
+
+func (e *entry) Msg(msg string) {
+	if e.notValid() {
+		return
+	}
+
+	e.msg = e.redact.Scrub(msg)
+	if !e.async {
+		e.Send()
+	}
+}
+
+func (e *entry) Send() {
+	if !e.emit() {
+		return
+	}
+
+	switch e.lvl {
+	case LevelPanic:
+		e.flush()
+		panic(e.msg)
+	case LevelFatal:
+		e.flush()
+		os.Exit(1)
+	}
+}
+
+func (e *entry) flush() {
+	if e.flusher != nil {
+		_ = e.flusher.Flush()
+	}
+}
+
+func (e *entry) Emit() {
+	e.emit()
+}
+
+// Writes the entry, reporting whether it was written.
+func (e *entry) emit() bool {
+	if e.notValid() {
+		return false
+	}
+
+	ctx := context.Background()
+	handler := e.lg.Handler()
+
+	// Nil out the slog.Logger as we're done with it. This disables
+	// future method calls on this type.
+	e.lg = nil
+
+	if e.lvl < lvlToSlog(e.loglvl.Level()) || !handler.Enabled(ctx, e.lvl) {
+		return false
+	}
+	if e.redact != nil {
+		for i, attr := range e.attrs {
+			e.attrs[i] = slog.Any(attr.Key, e.redact.Redact(attr.Key, attr.Value.Any()))
+		}
+	}
+	if !e.allowed() {
+		return false
+	}
+	e.fireHooks()
+
+	// Bound fields are written first, unless the entry sets them.
+	if len(e.battrs) > 0 {
+		attrs := make([]slog.Attr, 0, len(e.battrs)+len(e.attrs)+2)
+		for _, attr := range e.battrs {
+			if !e.sets(attr.Key) {
+				attrs = append(attrs, attr)
+			}
+		}
+		e.attrs = append(attrs, e.attrs...)
+	}
+	if len(e.caller) > 0 {
+		e.attrs = append(e.attrs, slog.Any(e.names.Caller, e.caller))
+	}
+	if e.name != "" {
+		e.attrs = append(e.attrs, slog.String(log.LoggerNameField, e.name))
+	}
+
+	rec := slog.NewRecord(time.Now(), e.lvl, e.msg, 0)
+	rec.AddAttrs(e.attrs...)
+	_ = handler.Handle(ctx, rec)
+	return true
+}
+
+// Reports whether the entry sets the field key.
+func (e *entry) sets(key string) bool {
+	for _, attr := range e.attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Applies deduplication and sampling to an enabled entry.
+func (e *entry) allowed() bool {
+	lvl := lvlFromSlog(e.lvl)
+	if e.dedup != nil && !e.summary {
+		fields := make(map[string]interface{}, len(e.bound)+1)
+		for k, v := range e.bound {
+			fields[k] = v
+		}
+		for _, attr := range e.attrs {
+			if e.dedup.IsKeyField(attr.Key) {
+				fields[attr.Key] = attr.Value.Any()
+			}
+		}
+		if e.name != "" {
+			fields[log.LoggerNameField] = e.name
+		}
+		if !e.dedup.Allow(lvl, e.msg, fields) {
+			return false
+		}
+	}
+	return e.sampling.Sample(lvl)
+}
+
+// Fires the Hooks for the entry.
+func (e *entry) fireHooks() {
+	lvl := lvlFromSlog(e.lvl)
+	if !e.hooks.Enabled(lvl) {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(e.bound)+len(e.attrs)+1)
+	for k, v := range e.bound {
+		fields[k] = v
+	}
+	for _, attr := range e.attrs {
+		fields[attr.Key] = attr.Value.Any()
+	}
+	if e.name != "" {
+		fields[log.LoggerNameField] = e.name
+	}
+	if e.err != nil {
+		delete(fields, e.names.Error)
+	}
+	e.hooks.Fire(log.NewRecord(lvl, e.msg, fields, e.err, e.caller, time.Now()))
+}
+
+// UnderlyingLogger implementation.
+
+// GetLogger returns the *slog.Logger the entry will be written to.
+func (e *entry) GetLogger() interface{} {
+	if e.notValid() {
+		return nil
+	}
+	return e.lg
+}
+
+// SetLogger replaces the *slog.Logger the entry will be written to.
+func (e *entry) SetLogger(l interface{}) {
+	if lg, ok := l.(*slog.Logger); ok && lg != nil && !e.notValid() {
+		e.lg = lg
+	}
+}
+
+// Entry utility functions.
+
+func (e *entry) notValid() bool {
+	return e == nil || e.lg == nil
+}
+
+func (e *entry) setLevel(lvl slog.Level) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	e.lvl = lvl
+	return e
+}
+
+// Multi-error utility implementation, for the Record of an entry with
+// several errors.
+type multiError struct {
+	errs []error
+}
+
+func (me multiError) Error() string {
+	sb := new(strings.Builder)
+	sb.Grow(len(me.errs) * 32)
+
+	for _, e := range me.errs {
+		fmt.Fprintf(sb, "%v\n", e)
+	}
+
+	return sb.String()
+}
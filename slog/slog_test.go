@@ -0,0 +1,355 @@
+package slog_test
+
+import (
+	"encoding/json"
+	"io"
+	stdslog "log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/secureworks/errors"
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/slog"
+)
+
+const (
+	testMessage    = "test message contents"
+	testFieldValue = "test-field-value"
+	testErrorValue = "new error message"
+)
+
+func TestSlog_New(t *testing.T) {
+	t.Run("log level too low does not log", func(t *testing.T) {
+		config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+
+		logger, err := log.Open("slog", config)
+		testutils.AssertNil(t, err)
+
+		logger.Debug().Msg(testMessage)
+
+		data, err := io.ReadAll(out)
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t, len(data), 0) // Nothing is logged for debug when at INFO.
+	})
+
+	t.Run("log level matches does log", func(t *testing.T) {
+		config, out := testutils.NewConfigWithBuffer(t, log.TRACE)
+
+		logger, err := log.Open("slog", config)
+		testutils.AssertNil(t, err)
+
+		logger.Trace().Msg(testMessage)
+
+		data, err := io.ReadAll(out)
+		testutils.AssertNil(t, err)
+		testutils.AssertStringContains(t, testMessage, string(data))
+		testutils.AssertStringContains(t, `"level":"trace"`, string(data))
+	})
+
+	t.Run("configuration with nil output", func(t *testing.T) {
+		config := log.DefaultConfig(nil)
+		config.Output = nil
+
+		logger, err := log.Open("slog", config)
+		testutils.AssertNil(t, err)
+		testutils.AssertNotNil(t, logger)
+	})
+
+	t.Run("implementation default format is text", func(t *testing.T) {
+		config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+		config.Format = log.ImplementationDefaultFormat
+
+		logger, err := log.Open("slog", config)
+		testutils.AssertNil(t, err)
+
+		logger.Info().WithStr("meta", testFieldValue).Msg("text")
+		testutils.AssertStringContains(t, "level=info message=text meta="+testFieldValue, out.String())
+	})
+}
+
+func TestSlog_TextFormats(t *testing.T) {
+	t.Run("logfmt", func(t *testing.T) {
+		config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+		config.Format = log.LogfmtFormat
+		config.TimeFormat = log.TimeFormatNone
+		config.EnableErrStack = false
+		logger, err := log.Open("slog", config)
+		testutils.AssertNil(t, err)
+
+		logger.Warn().
+			WithStr("str", "two words").
+			WithStr("strs", "a", "b").
+			WithInt("int", 42).
+			WithBool("bool", true).
+			WithDur("dur", 1500*time.Microsecond).
+			WithTime("ts", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)).
+			WithField("map", map[string]interface{}{"k": "v"}).
+			WithField("message", "collides").
+			WithError(errors.New(`bad "thing"`)).
+			Msg("message with = sign")
+		logger.Info().WithStr("empty", "").Send()
+
+		// The same lines as those of the zerolog and logrus drivers.
+		testutils.AssertEqual(t,
+			`level=warn message="message with = sign" bool=true dur=1.5 error="bad \"thing\"" fields.message=collides int=42 map="{\"k\":\"v\"}" str="two words" strs="[\"a\",\"b\"]" ts=2020-01-02T03:04:05Z`+"\n"+
+				`level=info empty=""`+"\n",
+			out.String())
+	})
+
+	t.Run("console", func(t *testing.T) {
+		config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+		config.Format = log.ConsoleFormat
+		config.TimeFormat = log.TimeFormatNone
+		config.EnableErrStack = true
+		logger, err := log.Open("slog", config)
+		testutils.AssertNil(t, err)
+
+		logger.Info().WithStr("str", "two words").WithBool("bool", true).Msg("message")
+		logger.Warn().WithError(errors.NewWithStackTrace("error value")).Msg("with stack")
+
+		lines := strings.Split(out.String(), "\n")
+		testutils.AssertEqual(t, `INF message bool=true str="two words"`, lines[0])
+		testutils.AssertEqual(t, `WRN with stack error="error value"`, lines[1])
+		testutils.AssertEqual(t, `  stack:`, lines[2])
+		testutils.AssertEqual(t, `    github.com/secureworks/logger/slog_test.TestSlog_TextFormats.func2`, lines[3])
+		testutils.AssertStringContains(t, "slog_test.go:", lines[4])
+	})
+}
+
+func TestSlog_Logging(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+
+	logger.Info().
+		WithStr("meta", testFieldValue).
+		WithInt("count", 3).
+		WithBool("ok", true).
+		Msg(testMessage)
+
+	var fields struct {
+		Level   string    `json:"level"`
+		Meta    string    `json:"meta"`
+		Count   int       `json:"count"`
+		OK      bool      `json:"ok"`
+		Message string    `json:"message"`
+		Time    time.Time `json:"time"`
+	}
+	err = json.Unmarshal(out.Bytes(), &fields)
+	testutils.AssertNil(t, err)
+
+	testutils.AssertEqual(t, "info", fields.Level)
+	testutils.AssertEqual(t, testFieldValue, fields.Meta)
+	testutils.AssertEqual(t, 3, fields.Count)
+	testutils.AssertTrue(t, fields.OK)
+	testutils.AssertEqual(t, testMessage, fields.Message)
+	testutils.AssertNearEqual(t, time.Now().Unix(), fields.Time.Unix(), 1)
+}
+
+func TestSlog_TypedFields(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	config.TimeFormat = log.TimeFormatNone
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+
+	logger.Info().
+		WithFloat("float", 2.5).
+		WithInt64("int64", -1<<40).
+		WithUint64("uint64s", 3, 4).
+		WithHex("hex", []byte{0xca, 0xfe}).
+		WithIP("ip", net.IPv4(192, 0, 2, 1)).
+		WithStringer("nil", nil).
+		WithJSON("json", []byte(`{"a":1}`)).
+		Send()
+
+	testutils.AssertEqual(t,
+		`{"level":"info","float":2.5,"int64":-1099511627776,"uint64s":[3,4],"hex":"cafe","ip":"192.0.2.1","nil":null,"json":{"a":1}}`+"\n",
+		out.String(),
+	)
+}
+
+func TestSlog_Async(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+
+	entry := logger.Info().Async()
+	entry.Msg("first")
+	testutils.AssertEqual(t, 0, out.Len())
+
+	entry.WithStr("meta", testFieldValue).Msg("second")
+	entry.Send()
+	testutils.AssertStringContains(t, `"message":"second","meta":"test-field-value"`, out.String())
+	testutils.AssertEqual(t, 1, strings.Count(out.String(), "\n"))
+}
+
+func TestSlog_Caller(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+
+	logger.Info().Caller().Msg(testMessage)
+
+	var fields struct {
+		Caller []string `json:"caller"`
+	}
+	err = json.Unmarshal(out.Bytes(), &fields)
+	testutils.AssertNil(t, err)
+	testutils.AssertEqual(t, 1, len(fields.Caller))
+	testutils.AssertStringContains(t, "slog_test.go:", fields.Caller[0])
+}
+
+func TestSlog_Errors(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+
+	logger.WithError(errors.New(testErrorValue)).WithStr("meta", testFieldValue).Msg(testMessage)
+
+	var fields struct {
+		Error   string `json:"error"`
+		Level   string `json:"level"`
+		Meta    string `json:"meta"`
+		Message string `json:"message"`
+		Stack   []struct {
+			File string `json:"file"`
+			Line int    `json:"line"`
+			Func string `json:"function"`
+		} `json:"stack"`
+	}
+	err = json.Unmarshal(out.Bytes(), &fields)
+	testutils.AssertNil(t, err)
+
+	// Error value.
+	testutils.AssertEqual(t, testErrorValue, fields.Error)
+	testutils.AssertEqual(t, "error", fields.Level)
+
+	// Stack trace.
+	testutils.AssertTrue(t, len(fields.Stack) > 0)
+
+	// Metadata fields.
+	testutils.AssertEqual(t, testFieldValue, fields.Meta)
+
+	// Nil error.
+	testutils.AssertNotPanics(t, func() { logger.WithError(nil).Msg("done") })
+
+	// Nil errors are skipped rather than written as null.
+	out.Reset()
+	config.EnableErrStack = false
+	logger, err = log.Open("slog", config)
+	testutils.AssertNil(t, err)
+	logger.Info().WithError(nil).Msg("done")
+	testutils.AssertFalse(t, strings.Contains(out.String(), `"error"`))
+}
+
+func TestSlog_Panic(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+
+	defer func() {
+		pv := recover()
+		testutils.AssertEqual(t, testMessage, pv)
+		testutils.AssertStringContains(t, `"level":"panic"`, out.String())
+	}()
+	logger.Panic().Msg(testMessage)
+	t.Errorf("did not panic")
+}
+
+func TestSlog_UnderlyingLogger(t *testing.T) {
+	config, _ := testutils.NewConfigWithBuffer(t, log.INFO)
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+
+	ul, ok := logger.(log.UnderlyingLogger)
+	testutils.AssertTrue(t, ok)
+	_, ok = ul.GetLogger().(*stdslog.Logger)
+	testutils.AssertTrue(t, ok)
+
+	var sb strings.Builder
+	ul.SetLogger(stdslog.New(stdslog.NewTextHandler(&sb, nil)))
+	logger.Info().Msg(testMessage)
+	testutils.AssertStringContains(t, `msg="`+testMessage+`"`, sb.String())
+}
+
+func TestSlog_Driver(t *testing.T) {
+	testutils.RunDriverTests(t, "slog")
+}
+
+func TestSlog_Time(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+	logger.Info().Msg("default")
+
+	var fields map[string]interface{}
+	testutils.AssertNil(t, json.Unmarshal(out.Bytes(), &fields))
+	_, err = time.Parse(time.RFC3339, fields[log.TimeField].(string))
+	testutils.AssertNil(t, err)
+
+	config, out = testutils.NewConfigWithBuffer(t, log.INFO)
+	config.FieldNames.Time = "ts"
+	config.TimeFormat = log.TimeFormatUnixMs
+	logger, err = log.Open("slog", config)
+	testutils.AssertNil(t, err)
+	before := time.Now().UnixNano() / int64(time.Millisecond)
+	logger.Info().Msg("unixms")
+
+	fields = nil
+	testutils.AssertNil(t, json.Unmarshal(out.Bytes(), &fields))
+	_, ok := fields[log.TimeField]
+	testutils.AssertFalse(t, ok)
+	testutils.AssertTrue(t, fields["ts"].(float64) >= float64(before))
+
+	config, out = testutils.NewConfigWithBuffer(t, log.INFO)
+	config.TimeFormat = log.TimeFormatNone
+	logger, err = log.Open("slog", config)
+	testutils.AssertNil(t, err)
+	logger.Info().Msg("none")
+	testutils.AssertFalse(t, strings.Contains(out.String(), `"time"`))
+}
+
+func TestSlog_FieldNames(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	config.FieldNames = log.FieldNames{Message: "msg", Level: "severity", Error: "err", Caller: "src"}
+	config.LevelCase = log.UpperCaseLevels
+	config.TimeFormat = log.TimeFormatNone
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+
+	logger.Error().Caller().WithError(errors.New(testErrorValue)).Send()
+	logger.Info().Msg(testMessage)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	testutils.AssertEqual(t, 2, len(lines))
+
+	var fields map[string]interface{}
+	testutils.AssertNil(t, json.Unmarshal([]byte(lines[0]), &fields))
+	testutils.AssertEqual(t, "ERROR", fields["severity"])
+	testutils.AssertEqual(t, testErrorValue, fields["err"])
+	testutils.AssertNotNil(t, fields["src"])
+	_, ok := fields["msg"]
+	testutils.AssertFalse(t, ok)
+
+	testutils.AssertEqual(t, `{"severity":"INFO","msg":"`+testMessage+`"}`, lines[1])
+}
+
+func TestSlog_Schema(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	config.Schema = log.GCPSchema()
+	config.TimeFormat = log.TimeFormatNone
+	config.StaticFields = map[string]interface{}{log.TraceIDField: "abc"}
+	logger, err := log.Open("slog", config)
+	testutils.AssertNil(t, err)
+
+	logger.Warn().Msg(testMessage)
+
+	testutils.AssertEqual(t,
+		`{"severity":"WARNING","message":"`+testMessage+`","logging.googleapis.com/trace":"abc"}`+"\n",
+		out.String(),
+	)
+	testutils.AssertEqual(t, config.Schema, log.SchemaOf(logger))
+}
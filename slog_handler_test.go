@@ -0,0 +1,113 @@
+//go:build go1.21
+// +build go1.21
+
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	"github.com/secureworks/logger/testlogger"
+)
+
+func TestNewSlogHandler(t *testing.T) {
+	t.Run("typed attributes", func(t *testing.T) {
+		logger := testlogger.MustNew(nil)
+		slogger := slog.New(log.NewSlogHandler(logger))
+
+		slogger.Info("message here",
+			"str", "value",
+			"int", 42,
+			"bool", true,
+			"dur", time.Second,
+			"float", 1.5,
+			"uint", uint64(math.MaxUint64),
+			"ip", net.IPv4(127, 0, 0, 1),
+			"err", errors.New("error value"),
+		)
+
+		entries := logger.GetEntries()
+		testutils.AssertEqual(t, 1, len(entries))
+		entry := entries[0]
+		testutils.AssertTrue(t, entry.Sent)
+		testutils.AssertEqual(t, log.INFO, entry.Level)
+		testutils.AssertEqual(t, "message here", entry.Message)
+		testutils.AssertEqual(t, "value", entry.Field("str"))
+		testutils.AssertEqual(t, int64(42), entry.Field("int"))
+		testutils.AssertEqual(t, true, entry.Field("bool"))
+		testutils.AssertEqual(t, time.Second, entry.Field("dur"))
+		testutils.AssertEqual(t, 1.5, entry.Field("float"))
+		testutils.AssertEqual(t, uint64(math.MaxUint64), entry.Field("uint"))
+		testutils.AssertEqual(t, "127.0.0.1", entry.Field("ip"))
+		testutils.AssertEqual(t, "error value", entry.Field("error"))
+	})
+
+	t.Run("levels", func(t *testing.T) {
+		config := log.DefaultConfig(func(string) string { return "" })
+		config.Level = log.TRACE
+		logger := testlogger.MustNew(config)
+		slogger := slog.New(log.NewSlogHandler(logger))
+
+		slogger.Log(context.Background(), slog.LevelDebug-4, "trace")
+		slogger.Debug("debug")
+		slogger.Warn("warn")
+		slogger.Error("error")
+		slogger.Log(context.Background(), slog.LevelError+4, "too high")
+
+		var lvls []log.Level
+		for _, entry := range logger.GetEntries() {
+			lvls = append(lvls, entry.Level)
+		}
+		testutils.AssertEqual(t, []log.Level{log.TRACE, log.DEBUG, log.WARN, log.ERROR, log.ERROR}, lvls)
+	})
+
+	t.Run("enabled uses the logger level", func(t *testing.T) {
+		logger := testlogger.MustNew(nil)
+		handler := log.NewSlogHandler(logger)
+
+		testutils.AssertFalse(t, handler.Enabled(context.Background(), slog.LevelDebug))
+		testutils.AssertTrue(t, handler.Enabled(context.Background(), slog.LevelInfo))
+	})
+
+	t.Run("groups and attrs nest", func(t *testing.T) {
+		logger := testlogger.MustNew(nil)
+		slogger := slog.New(log.NewSlogHandler(logger)).
+			With("top", "level").
+			WithGroup("req").
+			With("method", "GET").
+			WithGroup("inner")
+
+		slogger.Info("nested", "a", 1, slog.Group("g", "b", 2), slog.Group("empty"))
+
+		entry := logger.GetEntries()[0]
+		testutils.AssertEqual(t, "level", entry.Field("top"))
+		testutils.AssertEqual(t, map[string]interface{}{
+			"method": "GET",
+			"inner": map[string]interface{}{
+				"a": int64(1),
+				"g": map[string]interface{}{"b": int64(2)},
+			},
+		}, entry.Field("req"))
+	})
+
+	t.Run("handlers derived from the same parent do not share state", func(t *testing.T) {
+		logger := testlogger.MustNew(nil)
+		parent := slog.New(log.NewSlogHandler(logger)).WithGroup("p")
+		a := parent.With("a", 1)
+		b := parent.With("b", 2)
+
+		a.Info("a")
+		b.Info("b")
+
+		entries := logger.GetEntries()
+		testutils.AssertEqual(t, map[string]interface{}{"a": int64(1)}, entries[0].Field("p"))
+		testutils.AssertEqual(t, map[string]interface{}{"b": int64(2)}, entries[1].Field("p"))
+	})
+}
@@ -0,0 +1,10 @@
+module github.com/secureworks/logger/syslog
+
+go 1.18
+
+require (
+	github.com/secureworks/logger/internal v1.2.1
+	github.com/secureworks/logger/log v1.2.0
+)
+
+require github.com/secureworks/errors v0.1.2 // indirect
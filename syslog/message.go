@@ -0,0 +1,245 @@
+package syslog
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/secureworks/logger/internal/format"
+	"github.com/secureworks/logger/log"
+)
+
+// Severity is a syslog severity, see RFC 5424 section 6.2.1.
+type Severity int
+
+// Syslog severities.
+const (
+	SeverityEmergency Severity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInformational
+	SeverityDebug
+)
+
+// Facility is a syslog facility, see RFC 5424 section 6.2.1.
+type Facility int
+
+// Syslog facilities usable by processes. The zero Facility, kern, is
+// reserved for the kernel and is treated as FacilityUser.
+const (
+	FacilityUser   Facility = 1
+	FacilityDaemon Facility = 3
+	FacilityAuth   Facility = 4
+	FacilityLocal0 Facility = 16
+	FacilityLocal1 Facility = 17
+	FacilityLocal2 Facility = 18
+	FacilityLocal3 Facility = 19
+	FacilityLocal4 Facility = 20
+	FacilityLocal5 Facility = 21
+	FacilityLocal6 Facility = 22
+	FacilityLocal7 Facility = 23
+)
+
+// SeverityFromLevel maps a log.Level to a syslog Severity: TRACE and
+// DEBUG to debug, INFO to informational, WARN to warning, ERROR to
+// error, PANIC to critical and FATAL to alert.
+func SeverityFromLevel(lvl log.Level) Severity {
+	switch lvl {
+	case log.TRACE, log.DEBUG:
+		return SeverityDebug
+	case log.WARN:
+		return SeverityWarning
+	case log.ERROR:
+		return SeverityError
+	case log.PANIC:
+		return SeverityCritical
+	case log.FATAL:
+		return SeverityAlert
+	default:
+		return SeverityInformational
+	}
+}
+
+// A message decoded from a JSON log line.
+type message struct {
+	severity Severity
+	time     time.Time
+	msg      string
+	fields   map[string]interface{}
+}
+
+// Decodes a JSON log line written by one of the drivers, with the core
+// values named as given. Lines that are not JSON are used as the
+// message, at the informational severity.
+func parseMessage(line []byte, names log.FieldNames) *message {
+	line = []byte(strings.TrimRight(string(line), "\r\n"))
+	rec, err := format.FromJSON(line, names.Level, names.Time, names.Message)
+	if err != nil {
+		return &message{severity: SeverityInformational, time: time.Now(), msg: string(line)}
+	}
+
+	// Logrus and log/slog use "msg" for the message key when they are
+	// not used through a driver.
+	if rec.Message == "" {
+		if msg, ok := rec.Fields["msg"].(string); ok {
+			rec.Message = msg
+			delete(rec.Fields, "msg")
+		}
+	}
+
+	// LevelFromString also accepts the "warning" written by Logrus.
+	m := &message{
+		severity: SeverityFromLevel(log.LevelFromString(rec.Level)),
+		time:     time.Now(),
+		msg:      rec.Message,
+		fields:   rec.Fields,
+	}
+	if t, err := time.Parse(time.RFC3339Nano, rec.Time); err == nil {
+		m.time = t
+	}
+	return m
+}
+
+// Appends the message in the RFC 5424 format, with its fields as the
+// parameters of a single SD-ELEMENT.
+func (m *message) appendRFC5424(dst []byte, h *header) []byte {
+	dst = appendPRI(dst, h.facility, m.severity)
+	dst = append(dst, '1', ' ')
+	dst = m.time.AppendFormat(dst, rfc5424TimeFormat)
+	dst = append(dst, ' ')
+	dst = appendHeaderField(dst, h.hostname, 255)
+	dst = append(dst, ' ')
+	dst = appendHeaderField(dst, h.appName, 48)
+	dst = append(dst, ' ')
+	dst = appendHeaderField(dst, h.procID, 128)
+	dst = append(dst, ' ', '-', ' ') // MSGID.
+
+	if len(m.fields) == 0 {
+		dst = append(dst, '-')
+	} else {
+		dst = append(dst, '[')
+		dst = append(dst, h.sdID...)
+		for _, key := range format.SortedKeys(m.fields) {
+			dst = append(dst, ' ')
+			dst = append(dst, sdName(key)...)
+			dst = append(dst, '=', '"')
+			dst = appendSDValue(dst, fieldString(m.fields[key]))
+			dst = append(dst, '"')
+		}
+		dst = append(dst, ']')
+	}
+
+	if m.msg != "" {
+		dst = append(dst, ' ')
+		dst = append(dst, m.msg...)
+	}
+	return dst
+}
+
+// Appends the message in the RFC 3164 format, which has no structured
+// data: fields are appended to the message as logfmt.
+func (m *message) appendRFC3164(dst []byte, h *header) []byte {
+	dst = appendPRI(dst, h.facility, m.severity)
+	dst = m.time.AppendFormat(dst, time.Stamp)
+	dst = append(dst, ' ')
+	dst = appendHeaderField(dst, h.hostname, 255)
+	dst = append(dst, ' ')
+	dst = append(dst, h.appName...)
+	if h.procID != "" {
+		dst = append(dst, '[')
+		dst = append(dst, h.procID...)
+		dst = append(dst, ']')
+	}
+	dst = append(dst, ':', ' ')
+	dst = append(dst, m.msg...)
+	if len(m.fields) > 0 {
+		if m.msg != "" {
+			dst = append(dst, ' ')
+		}
+		dst = format.AppendLogfmt(dst, &format.Record{Fields: m.fields})
+	}
+	return dst
+}
+
+const rfc5424TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// Header values shared by every message written by a Writer.
+type header struct {
+	facility Facility
+	hostname string
+	appName  string
+	procID   string
+	sdID     string
+}
+
+func appendPRI(dst []byte, f Facility, s Severity) []byte {
+	dst = append(dst, '<')
+	dst = strconv.AppendInt(dst, int64(f)*8+int64(s), 10)
+	return append(dst, '>')
+}
+
+// Appends a header field, which must be printable US-ASCII without
+// spaces, or the NILVALUE "-" if it is empty.
+func appendHeaderField(dst []byte, s string, max int) []byte {
+	if s == "" {
+		return append(dst, '-')
+	}
+	for i := 0; i < len(s) && i < max; i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' {
+			c = '_'
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+// Returns a valid SD-NAME for key: at most 32 printable US-ASCII
+// characters other than '=', ' ', ']' and '"'.
+func sdName(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key) && sb.Len() < 32; i++ {
+		c := key[i]
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		sb.WriteByte(c)
+	}
+	if sb.Len() == 0 {
+		return "_"
+	}
+	return sb.String()
+}
+
+// Appends a PARAM-VALUE, escaping '"', '\' and ']'.
+func appendSDValue(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			dst = append(dst, '\\', c)
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// Returns a field value as a string: strings as they are, other values
+// as JSON.
+func fieldString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	byt, err := json.Marshal(val)
+	if err != nil {
+		return ""
+	}
+	return string(byt)
+}
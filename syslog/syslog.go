@@ -0,0 +1,312 @@
+// Package syslog provides an io.Writer that sends the JSON lines written
+// by the logger drivers to a syslog server, for use as log.Config.Output
+// with log.JSONFormat:
+//
+//	w, err := syslog.Dial(syslog.Config{Network: "tcp+tls", Address: "logs:6514"})
+//	if err != nil {
+//		// ...
+//	}
+//	config := log.DefaultConfig(nil)
+//	config.Format = log.JSONFormat
+//	config.Output = w
+//	logger, err := log.Open("zerolog", config)
+//
+// Each line is sent as one syslog message: the level is mapped to a
+// severity (see SeverityFromLevel), the time and message are used as
+// they are and the remaining fields are sent as RFC 5424 structured
+// data.
+package syslog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/secureworks/logger/log"
+)
+
+// MessageFormat is the syslog message format written by a Writer.
+type MessageFormat int
+
+const (
+	// RFC5424 is the format described in RFC 5424, with fields sent as
+	// structured data.
+	RFC5424 MessageFormat = iota
+
+	// RFC3164 is the older BSD format described in RFC 3164, with fields
+	// appended to the message as logfmt.
+	RFC3164
+)
+
+// Config configures a Writer.
+type Config struct {
+	// Network is one of "udp", "tcp", "tcp+tls", "unix" or "unixgram".
+	// If Network and Address are empty the local syslog server is used,
+	// found at one of the usual unix socket paths.
+	Network string
+
+	// Address is the address of the syslog server, eg: "logs:514" or
+	// "/dev/log".
+	Address string
+
+	// TLSConfig is the TLS configuration used if Network is "tcp+tls".
+	// The server name is taken from Address if it is not set.
+	TLSConfig *tls.Config
+
+	// Format is the message format, RFC5424 by default.
+	Format MessageFormat
+
+	// Facility is the facility messages are sent with, FacilityUser by
+	// default.
+	Facility Facility
+
+	// AppName is the APP-NAME of messages; the base name of the
+	// executable by default.
+	AppName string
+
+	// Hostname is the HOSTNAME of messages; os.Hostname by default.
+	Hostname string
+
+	// SDID is the SD-ID of the structured data element holding the
+	// fields of messages, "fields@32473" by default. IDs without an '@'
+	// must be registered with IANA.
+	SDID string
+
+	// Timeout is the timeout for connecting and for each write, 5s by
+	// default.
+	Timeout time.Duration
+
+	// MaxBackoff is the longest a Writer waits before trying to connect
+	// again after failing to, 30s by default.
+	MaxBackoff time.Duration
+
+	// FieldNames are those of the log.Config of the Loggers writing to
+	// the Writer, used to find the level, time and message of lines.
+	FieldNames log.FieldNames
+}
+
+// Defaults for Config.
+const (
+	DefaultSDID       = "fields@32473"
+	DefaultTimeout    = 5 * time.Second
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// The backoff after the first failure to connect, doubled after each
+// subsequent failure.
+const minBackoff = 100 * time.Millisecond
+
+// Writer is an io.WriteCloser that sends each line written to it as a
+// syslog message. Messages are framed using octet counting over stream
+// transports (RFC 6587) and sent one per datagram otherwise. It is safe
+// for concurrent use.
+//
+// If a write fails the Writer reconnects and tries again once. If it
+// fails to connect it waits for an exponentially increasing backoff
+// before trying again, failing writes in the meantime; failed lines are
+// dropped and counted, see Dropped.
+type Writer struct {
+	dropped uint64 // Accessed atomically; first for 64-bit alignment.
+
+	config Config
+	header header
+	stream bool
+
+	mu      sync.Mutex
+	conn    net.Conn
+	backoff time.Duration
+	retryAt time.Time // When to try connecting again.
+	closed  bool
+	buf     []byte
+}
+
+// Dial returns a new Writer for config, connected to the syslog server.
+func Dial(config Config) (*Writer, error) {
+	if config.Network == "" && config.Address == "" {
+		network, address, err := localSyslog()
+		if err != nil {
+			return nil, err
+		}
+		config.Network, config.Address = network, address
+	}
+	w := &Writer{config: config}
+
+	switch config.Network {
+	case "tcp", "tcp4", "tcp6", "tcp+tls", "unix":
+		w.stream = true
+	case "udp", "udp4", "udp6", "unixgram":
+	default:
+		return nil, fmt.Errorf("syslog: Unsupported network (%s)", config.Network)
+	}
+	if w.config.Timeout <= 0 {
+		w.config.Timeout = DefaultTimeout
+	}
+	if w.config.MaxBackoff <= 0 {
+		w.config.MaxBackoff = DefaultMaxBackoff
+	}
+	w.config.FieldNames = config.FieldNames.WithDefaults()
+
+	w.header = header{
+		facility: config.Facility,
+		hostname: config.Hostname,
+		appName:  config.AppName,
+		procID:   strconv.Itoa(os.Getpid()),
+		sdID:     sdName(config.SDID),
+	}
+	if w.header.facility == 0 {
+		w.header.facility = FacilityUser
+	}
+	if w.header.hostname == "" {
+		w.header.hostname, _ = os.Hostname()
+	}
+	if w.header.appName == "" {
+		w.header.appName = filepath.Base(os.Args[0])
+	}
+	if config.SDID == "" {
+		w.header.sdID = DefaultSDID
+	}
+
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write sends each line in p as a syslog message.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := w.send(parseMessage(line, w.config.FieldNames)); err != nil {
+			atomic.AddUint64(&w.dropped, 1)
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of lines that could not be sent.
+func (w *Writer) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Close closes the connection to the syslog server.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// Sends m, reconnecting and trying again once if the write fails.
+func (w *Writer) send(m *message) error {
+	w.buf = w.buf[:0]
+	if w.stream {
+		w.buf = append(w.buf, "0000000000 "...) // Replaced by the length.
+	}
+	start := len(w.buf)
+	if w.config.Format == RFC3164 {
+		w.buf = m.appendRFC3164(w.buf, &w.header)
+	} else {
+		w.buf = m.appendRFC5424(w.buf, &w.header)
+	}
+	frame := w.buf[start:]
+	if w.stream {
+		prefix := strconv.AppendInt(nil, int64(len(frame)), 10)
+		prefix = append(prefix, ' ')
+		frame = w.buf[start-len(prefix):]
+		copy(frame, prefix)
+	}
+
+	for retried := false; ; retried = true {
+		if w.conn == nil {
+			if err := w.connect(); err != nil {
+				return err
+			}
+		}
+		err := w.conn.SetWriteDeadline(time.Now().Add(w.config.Timeout))
+		if err == nil {
+			_, err = w.conn.Write(frame)
+		}
+		if err == nil {
+			return nil
+		}
+
+		_ = w.conn.Close()
+		w.conn = nil
+		if retried {
+			return fmt.Errorf("syslog: Failed to write to %s: %w", w.config.Address, err)
+		}
+	}
+}
+
+// Connects to the syslog server, unless waiting for the backoff after a
+// failure to.
+func (w *Writer) connect() error {
+	now := time.Now()
+	if now.Before(w.retryAt) {
+		return fmt.Errorf("syslog: Not connected to %s, retrying in %s", w.config.Address, w.retryAt.Sub(now))
+	}
+
+	conn, err := w.dial()
+	if err != nil {
+		if w.backoff == 0 {
+			w.backoff = minBackoff
+		} else if w.backoff *= 2; w.backoff > w.config.MaxBackoff {
+			w.backoff = w.config.MaxBackoff
+		}
+		w.retryAt = now.Add(w.backoff)
+		return fmt.Errorf("syslog: Failed to connect to %s: %w", w.config.Address, err)
+	}
+
+	w.conn = conn
+	w.backoff = 0
+	w.retryAt = time.Time{}
+	return nil
+}
+
+func (w *Writer) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: w.config.Timeout}
+	if w.config.Network != "tcp+tls" {
+		return dialer.Dial(w.config.Network, w.config.Address)
+	}
+	return tls.DialWithDialer(dialer, "tcp", w.config.Address, w.config.TLSConfig)
+}
+
+// Finds the socket of the local syslog server.
+func localSyslog() (network, address string, err error) {
+	for _, address := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, address, DefaultTimeout)
+			if err == nil {
+				_ = conn.Close()
+				return network, address, nil
+			}
+		}
+	}
+	return "", "", errors.New("syslog: Local syslog server not found")
+}
@@ -0,0 +1,306 @@
+package syslog_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	"github.com/secureworks/logger/syslog"
+)
+
+// A local syslog server that collects the messages it receives.
+type server struct {
+	addr     string
+	messages chan string
+	conns    chan net.Conn
+	close    func()
+}
+
+// Starts a server listening on network. Stream connections are read
+// using octet-counting framing.
+func startServer(t *testing.T, network string, tlsConfig *tls.Config) *server {
+	t.Helper()
+
+	s := &server{messages: make(chan string, 100), conns: make(chan net.Conn, 10)}
+	address := "127.0.0.1:0"
+	if strings.HasPrefix(network, "unix") {
+		address = filepath.Join(t.TempDir(), "log.sock")
+	}
+
+	if network == "udp" || network == "unixgram" {
+		pc, err := net.ListenPacket(network, address)
+		testutils.AssertNil(t, err)
+		s.addr = pc.LocalAddr().String()
+		s.close = func() { _ = pc.Close() }
+		go func() {
+			buf := make([]byte, 64<<10)
+			for {
+				n, _, err := pc.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+				s.messages <- string(buf[:n])
+			}
+		}()
+		t.Cleanup(s.close)
+		return s
+	}
+
+	var (
+		ln  net.Listener
+		err error
+	)
+	if tlsConfig != nil {
+		ln, err = tls.Listen("tcp", address, tlsConfig)
+	} else {
+		ln, err = net.Listen(network, address)
+	}
+	testutils.AssertNil(t, err)
+	s.addr = ln.Addr().String()
+	s.close = func() { _ = ln.Close() }
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.conns <- conn
+			go s.readFrames(conn)
+		}
+	}()
+	t.Cleanup(s.close)
+	return s
+}
+
+func (s *server) readFrames(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		lenStr, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(lenStr, " "))
+		if err != nil {
+			s.messages <- "invalid frame length: " + lenStr
+			return
+		}
+		frame := make([]byte, n)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return
+		}
+		s.messages <- string(frame)
+	}
+}
+
+func (s *server) next(t *testing.T) string {
+	t.Helper()
+
+	select {
+	case msg := <-s.messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
+
+// Returns a TLS configuration for a server with a self-signed
+// certificate and one for a client trusting it.
+func tlsConfigs(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testutils.AssertNil(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "syslog"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	testutils.AssertNil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	testutils.AssertNil(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{RootCAs: pool}
+	return server, client
+}
+
+const line = `{"level":"warn","time":"2024-01-02T03:04:05.123456Z","message":"disk \"low\"","free":12.5,"path":"/var]","tags":["a","b"]}` + "\n"
+
+func TestSeverityFromLevel(t *testing.T) {
+	for lvl, sev := range map[log.Level]syslog.Severity{
+		log.TRACE: syslog.SeverityDebug,
+		log.DEBUG: syslog.SeverityDebug,
+		log.INFO:  syslog.SeverityInformational,
+		log.WARN:  syslog.SeverityWarning,
+		log.ERROR: syslog.SeverityError,
+		log.PANIC: syslog.SeverityCritical,
+		log.FATAL: syslog.SeverityAlert,
+	} {
+		testutils.AssertEqual(t, sev, syslog.SeverityFromLevel(lvl))
+	}
+}
+
+func TestWriter(t *testing.T) {
+	pid := os.Getpid()
+	want := fmt.Sprintf(`<12>1 2024-01-02T03:04:05.123456Z host agent %d - `+
+		`[fields@32473 free="12.5" path="/var\]" tags="[\"a\",\"b\"\]"] disk "low"`, pid)
+
+	serverTLS, clientTLS := tlsConfigs(t)
+	for _, tc := range []struct {
+		network string
+		server  *tls.Config
+		client  *tls.Config
+	}{
+		{network: "udp"},
+		{network: "tcp"},
+		{network: "tcp+tls", server: serverTLS, client: clientTLS},
+		{network: "unix"},
+		{network: "unixgram"},
+	} {
+		tc := tc
+		t.Run(tc.network, func(t *testing.T) {
+			s := startServer(t, strings.TrimSuffix(tc.network, "+tls"), tc.server)
+			w, err := syslog.Dial(syslog.Config{
+				Network:   tc.network,
+				Address:   s.addr,
+				TLSConfig: tc.client,
+				AppName:   "agent",
+				Hostname:  "host",
+			})
+			testutils.AssertNil(t, err)
+			defer w.Close()
+
+			n, err := w.Write([]byte(line))
+			testutils.AssertNil(t, err)
+			testutils.AssertEqual(t, len(line), n)
+			testutils.AssertEqual(t, want, s.next(t))
+
+			_, err = w.Write([]byte(`{"level":"info","msg":"two"}` + "\n"))
+			testutils.AssertNil(t, err)
+			testutils.AssertTrue(t, strings.HasPrefix(s.next(t), "<14>1 "))
+		})
+	}
+
+	t.Run("formats", func(t *testing.T) {
+		s := startServer(t, "udp", nil)
+		w, err := syslog.Dial(syslog.Config{
+			Network:  "udp",
+			Address:  s.addr,
+			Format:   syslog.RFC3164,
+			Facility: syslog.FacilityLocal0,
+			AppName:  "agent",
+			Hostname: "host",
+		})
+		testutils.AssertNil(t, err)
+		defer w.Close()
+
+		_, err = w.Write([]byte(line))
+		testutils.AssertNil(t, err)
+		testutils.AssertEqual(t,
+			fmt.Sprintf(`<132>Jan  2 03:04:05 host agent[%d]: disk "low" free=12.5 path=/var] tags="[\"a\",\"b\"]"`, pid),
+			s.next(t))
+
+		_, err = w.Write([]byte("not json\n"))
+		testutils.AssertNil(t, err)
+		testutils.AssertStringContains(t, "agent["+strconv.Itoa(pid)+"]: not json", s.next(t))
+	})
+
+	t.Run("maps logrus levels", func(t *testing.T) {
+		s := startServer(t, "udp", nil)
+		w, err := syslog.Dial(syslog.Config{Network: "udp", Address: s.addr})
+		testutils.AssertNil(t, err)
+		defer w.Close()
+
+		_, err = w.Write([]byte(`{"level":"warning","msg":"hi"}` + "\n"))
+		testutils.AssertNil(t, err)
+		msg := s.next(t)
+		testutils.AssertTrue(t, strings.HasPrefix(msg, "<12>1 "))
+		testutils.AssertTrue(t, strings.HasSuffix(msg, " - - hi"))
+	})
+}
+
+func TestWriter_Reconnect(t *testing.T) {
+	t.Run("after the connection is closed", func(t *testing.T) {
+		s := startServer(t, "tcp", nil)
+		w, err := syslog.Dial(syslog.Config{Network: "tcp", Address: s.addr})
+		testutils.AssertNil(t, err)
+		defer w.Close()
+
+		_, err = w.Write([]byte(`{"level":"info","msg":"one"}`))
+		testutils.AssertNil(t, err)
+		testutils.AssertStringContains(t, " one", s.next(t))
+		(<-s.conns).Close()
+
+		// Writes to the closed connection may succeed until it is reset.
+		deadline := time.Now().Add(5 * time.Second)
+		for len(s.conns) == 0 && time.Now().Before(deadline) {
+			_, err = w.Write([]byte(`{"level":"info","msg":"two"}`))
+			testutils.AssertNil(t, err)
+			time.Sleep(10 * time.Millisecond)
+		}
+		testutils.AssertEqual(t, 1, len(s.conns))
+		testutils.AssertStringContains(t, " two", s.next(t))
+	})
+
+	t.Run("backs off while the server is down", func(t *testing.T) {
+		s := startServer(t, "tcp", nil)
+		w, err := syslog.Dial(syslog.Config{Network: "tcp", Address: s.addr, MaxBackoff: time.Second})
+		testutils.AssertNil(t, err)
+		defer w.Close()
+
+		s.close()
+		(<-s.conns).Close()
+		for err == nil {
+			_, err = w.Write([]byte(`{"level":"info","msg":"lost"}`))
+		}
+		testutils.AssertStringContains(t, "Failed to", err.Error())
+
+		_, err = w.Write([]byte(`{"level":"info","msg":"lost"}`))
+		testutils.AssertStringContains(t, "retrying in", err.Error())
+		testutils.AssertTrue(t, w.Dropped() >= 2)
+	})
+
+	t.Run("fails to dial", func(t *testing.T) {
+		_, err := syslog.Dial(syslog.Config{Network: "tcp", Address: "127.0.0.1:1"})
+		testutils.AssertNotNil(t, err)
+
+		_, err = syslog.Dial(syslog.Config{Network: "sctp", Address: "127.0.0.1:1"})
+		testutils.AssertStringContains(t, "Unsupported network", err.Error())
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		s := startServer(t, "udp", nil)
+		w, err := syslog.Dial(syslog.Config{Network: "udp", Address: s.addr})
+		testutils.AssertNil(t, err)
+		testutils.AssertNil(t, w.Close())
+
+		_, err = w.Write([]byte("x\n"))
+		testutils.AssertTrue(t, errors.Is(err, os.ErrClosed))
+	})
+}
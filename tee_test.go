@@ -0,0 +1,104 @@
+package logger_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/logrus"
+	"github.com/secureworks/logger/testlogger"
+	_ "github.com/secureworks/logger/zerolog"
+)
+
+func TestTee(t *testing.T) {
+	newTee := func(t *testing.T) (log.Logger, *bytes.Buffer, *bytes.Buffer) {
+		t.Helper()
+		infoConfig, infoOut := testutils.NewConfigWithBuffer(t, log.INFO)
+		infoLogger, err := log.Open("zerolog", infoConfig)
+		testutils.AssertNil(t, err)
+		debugConfig, debugOut := testutils.NewConfigWithBuffer(t, log.DEBUG)
+		debugLogger, err := log.Open("logrus", debugConfig)
+		testutils.AssertNil(t, err)
+		return log.Tee(infoLogger, debugLogger), infoOut, debugOut
+	}
+
+	t.Run("writes to each logger at its level", func(t *testing.T) {
+		logger, infoOut, debugOut := newTee(t)
+
+		logger.Debug().Msg("debug message")
+		logger.Named("db").With(map[string]interface{}{"bound": "value"}).
+			Info().WithStr("key", "value").Msg("info message")
+
+		testutils.AssertFalse(t, bytes.Contains(infoOut.Bytes(), []byte("debug message")))
+		testutils.AssertStringContains(t, "debug message", debugOut.String())
+		for _, out := range []string{infoOut.String(), debugOut.String()} {
+			testutils.AssertStringContains(t, "info message", out)
+			testutils.AssertStringContains(t, `"key":"value"`, out)
+			testutils.AssertStringContains(t, `"bound":"value"`, out)
+			testutils.AssertStringContains(t, `"logger":"db"`, out)
+		}
+	})
+
+	t.Run("is enabled if any logger is", func(t *testing.T) {
+		logger, _, _ := newTee(t)
+		le, ok := logger.(interface{ IsLevelEnabled(log.Level) bool })
+		testutils.AssertTrue(t, ok)
+		testutils.AssertTrue(t, le.IsLevelEnabled(log.DEBUG))
+		testutils.AssertFalse(t, le.IsLevelEnabled(log.TRACE))
+	})
+
+	t.Run("async entries are sent once", func(t *testing.T) {
+		logger, infoOut, debugOut := newTee(t)
+
+		entry := logger.Warn().Async().Caller()
+		entry.Msg("async message")
+		testutils.AssertEqual(t, 0, infoOut.Len()+debugOut.Len())
+
+		entry.Send()
+		testutils.AssertStringContains(t, "async message", infoOut.String())
+		testutils.AssertStringContains(t, "tee_test.go", infoOut.String())
+		testutils.AssertStringContains(t, "tee_test.go", debugOut.String())
+	})
+
+	t.Run("panics once after every logger has written", func(t *testing.T) {
+		first := testlogger.MustNew(nil)
+		second := testlogger.MustNew(nil)
+		logger := log.Tee(first, second)
+
+		var pv interface{}
+		func() {
+			defer func() { pv = recover() }()
+			logger.Panic().Msg("panic message")
+		}()
+
+		testutils.AssertEqual(t, "panic message", pv)
+		for _, l := range []*testlogger.Logger{first, second} {
+			entries := l.GetEntries()
+			testutils.AssertEqual(t, 1, len(entries))
+			testutils.AssertTrue(t, entries[0].Sent)
+		}
+	})
+
+	t.Run("does not panic if no logger enables the level", func(t *testing.T) {
+		first := testlogger.MustNew(&log.Config{Level: log.FATAL})
+		second := testlogger.MustNew(&log.Config{Level: log.FATAL})
+		logger := log.Tee(first, second)
+
+		testutils.AssertNotPanics(t, func() { logger.Panic().Msg("panic message") })
+	})
+
+	t.Run("panics once with driver loggers", func(t *testing.T) {
+		logger, infoOut, debugOut := newTee(t)
+
+		var pv interface{}
+		func() {
+			defer func() { pv = recover() }()
+			logger.Panic().Msg("panic message")
+		}()
+
+		testutils.AssertEqual(t, "panic message", pv)
+		testutils.AssertStringContains(t, "panic message", infoOut.String())
+		testutils.AssertStringContains(t, "panic message", debugOut.String())
+	})
+}
@@ -9,9 +9,11 @@ package testlogger
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"strings"
 	"sync"
@@ -44,10 +46,17 @@ func New(config *log.Config, opts ...log.Option) (*Logger, error) {
 		Config:            config,
 		WriteCloserBuffer: &bytes.Buffer{}, // For WriteCloser.
 		ExitFn:            os.Exit,
+		Clock:             time.Now,
 		entries:           []*Entry{},
 		// multiple go routines can append to entries at the same time, so we will
 		// use this mutux to lock any access made to the entries field
 		entriesMutex: sync.Mutex{},
+		sampling:     log.NewSampling(config.Sampler),
+		hooks:        new(log.Hooks),
+	}
+
+	if len(config.StaticFields) > 0 {
+		logger.fields = config.Redaction.RedactFields(config.Schema.Fields(config.StaticFields))
 	}
 
 	// Change default output, as long as os.Stdout (for examples) is not set.
@@ -92,41 +101,79 @@ type Logger struct {
 	// os.Exit.
 	ExitFn func(int)
 
+	// Clock returns the time entries are sent, see Entry.Time. It
+	// defaults to time.Now. Child Loggers use the root's.
+	Clock func() time.Time
+
 	// entries holds a list of all the entries generated by the logger,
 	// so that we can make assertions against them.
 	entries []*Entry
 
 	entriesMutex sync.Mutex
 
+	// levelMutex guards Config.Level, see SetLevel.
+	levelMutex sync.RWMutex
+
 	underlyingLoggerValue interface{}
+
+	// parent is the Logger that holds the entries for child Loggers
+	// created using With.
+	parent *Logger
+
+	// fields are bound using With and copied into each new Entry.
+	fields map[string]interface{}
+
+	// name is set using Named.
+	name string
+
+	// sampling applies Config.Sampler; child Loggers use the root's.
+	sampling *log.Sampling
+
+	// hooks are added using log.WithHooks; child Loggers use the root's.
+	hooks *log.Hooks
+
+	// lvl is set on Loggers created using Named that have their own
+	// level, see log.LevelOverrides; others use Config.Level.
+	lvl *log.AtomicLevel
 }
 
 var _ log.Logger = (*Logger)(nil)
+var _ log.LevelController = (*Logger)(nil)
+var _ log.DroppedCounter = (*Logger)(nil)
+var _ log.HookLogger = (*Logger)(nil)
+var _ log.Flusher = (*Logger)(nil)
+var _ log.SchemaLogger = (*Logger)(nil)
 
 // GetEntries can be used to the logs that have been posted up to the start of program or since
 // last call to GetEntries (which ever is most recent)
 // to call this method, you will need to cast the logger to testlogger.Logger
 func (l *Logger) GetEntries() []*Entry {
-	l.entriesMutex.Lock()
-	defer l.entriesMutex.Unlock()
-	rtn := l.entries
-	l.entries = []*Entry{}
+	r := l.root()
+	r.entriesMutex.Lock()
+	defer r.entriesMutex.Unlock()
+	rtn := r.entries
+	r.entries = []*Entry{}
 	return rtn
 }
 
 func (l *Logger) IsLevelEnabled(lvl log.Level) bool {
-	return lvl >= l.Config.Level
+	return lvl >= l.Level()
 }
 
 func (l *Logger) Entry(lvl log.Level) log.Entry {
 	entry := &Entry{
 		Logger: l,
 		Level:  lvl,
-		Fields: make(map[string]interface{}),
+		Fields: make(map[string]interface{}, len(l.fields)),
 	}
-	l.entriesMutex.Lock()
-	defer l.entriesMutex.Unlock()
-	l.entries = append(l.entries, entry)
+	for k, val := range l.fields {
+		entry.Fields[k] = val
+	}
+
+	r := l.root()
+	r.entriesMutex.Lock()
+	defer r.entriesMutex.Unlock()
+	r.entries = append(r.entries, entry)
 	return entry
 }
 
@@ -139,30 +186,130 @@ func (l *Logger) Panic() log.Entry { return l.Entry(log.PANIC) }
 func (l *Logger) Fatal() log.Entry { return l.Entry(log.FATAL) }
 
 func (l *Logger) WithError(err error) log.Entry {
-	return l.Entry(l.Config.Level).WithError(err)
+	return l.Entry(l.Level()).WithError(err)
 }
 
 func (l *Logger) WithField(k string, val interface{}) log.Entry {
-	e, _ := l.Entry(l.Config.Level).(*Entry)
-	e.Fields[k] = val
-	return e
+	return l.Entry(l.Level()).WithField(k, val)
 }
 
 func (l *Logger) WithFields(fields map[string]interface{}) log.Entry {
-	e := l.Entry(l.Config.Level)
+	e := l.Entry(l.Level())
 	for k, val := range fields {
 		e.WithField(k, val)
 	}
 	return e
 }
 
+// With returns a child Logger that sets fields on every Entry it
+// creates. The child shares its Config, buffers and entries with the
+// receiver, so GetEntries may be called on either.
+func (l *Logger) With(fields map[string]interface{}) log.Logger {
+	return l.child(l.Config.Redaction.RedactFields(fields))
+}
+
+// Named returns a child Logger, as With does, that sets the
+// log.LoggerNameField on every Entry it creates. Its level is taken from
+// Config.LevelOverrides, see log.LevelOverrides.
+func (l *Logger) Named(name string) log.Logger {
+	name = log.JoinName(l.name, name)
+	child := l.child(map[string]interface{}{log.LoggerNameField: name})
+	child.name = name
+	if lvl, inherit := l.Config.LevelOverrides.NamedLevel(l.name, name); !inherit {
+		child.lvl = log.NewAtomicLevel(lvl)
+	}
+	return child
+}
+
 func (l *Logger) WriteCloser(_ log.Level) io.WriteCloser { return l }
 
+// SetLevel sets Config.Level, which is shared with child Loggers. Use
+// Level rather than reading Config.Level directly if SetLevel may be
+// called concurrently. Loggers created using Named that have their own
+// level set it instead.
+func (l *Logger) SetLevel(lvl log.Level) {
+	if l.lvl != nil {
+		l.lvl.SetLevel(lvl)
+		return
+	}
+	r := l.root()
+	r.levelMutex.Lock()
+	defer r.levelMutex.Unlock()
+	l.Config.Level = lvl
+}
+
+// Level returns the level of the Logger, see SetLevel.
+func (l *Logger) Level() log.Level {
+	if l.lvl != nil {
+		return l.lvl.Level()
+	}
+	r := l.root()
+	r.levelMutex.RLock()
+	defer r.levelMutex.RUnlock()
+	return l.Config.Level
+}
+
+// Dropped returns the number of entries dropped by Config.Sampler.
+func (l *Logger) Dropped() uint64 {
+	return l.root().sampling.Dropped()
+}
+
+// Hooks returns the Hooks added using log.WithHooks, which are fired
+// when an Entry is sent.
+func (l *Logger) Hooks() *log.Hooks {
+	return l.root().hooks
+}
+
+// Schema returns the Config.Schema of the Logger.
+func (l *Logger) Schema() *log.Schema {
+	return l.Config.Schema
+}
+
+// Returns a child Logger with fields bound in addition to those of the
+// receiver.
+func (l *Logger) child(fields map[string]interface{}) *Logger {
+	bound := make(map[string]interface{}, len(l.fields)+len(fields))
+	for k, val := range l.fields {
+		bound[k] = val
+	}
+	for k, val := range fields {
+		bound[k] = val
+	}
+
+	return &Logger{
+		Config:                l.Config,
+		WriteCloserBuffer:     l.WriteCloserBuffer,
+		ExitFn:                l.ExitFn,
+		underlyingLoggerValue: l.underlyingLoggerValue,
+		parent:                l.root(),
+		fields:                bound,
+		name:                  l.name,
+		lvl:                   l.lvl,
+	}
+}
+
+// Returns the Logger holding the entries.
+func (l *Logger) root() *Logger {
+	if l.parent != nil {
+		return l.parent
+	}
+	return l
+}
+
 // WriteCloser implementation.
 
 func (l *Logger) Write(p []byte) (int, error) { return l.WriteCloserBuffer.Write(p) }
 func (l *Logger) Close() error                { return nil }
 
+// Flush flushes Config.Output if it is a log.Flusher. It is called
+// before ExitFn or panicking.
+func (l *Logger) Flush() error {
+	if f, ok := l.Config.Output.(log.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
 // GetLogger will return the value set in SetLogger.
 func (l *Logger) GetLogger() interface{} {
 	return l.underlyingLoggerValue
@@ -196,16 +343,29 @@ type Entry struct {
 	// output buffer.
 	Sent bool
 
+	// Dropped is true if the entry was not sent because Config.Sampler
+	// dropped it.
+	Dropped bool
+
 	// Message stores the message field.
 	Message string
+
+	// Time is when the entry was sent, from Logger.Clock. It is written
+	// to the output, as the drivers write it, only if Config.TimeFormat
+	// is set, so that the output is reproducible by default.
+	Time time.Time
+
+	// err is set using WithError, for the log.Record passed to Hooks.
+	err error
 }
 
 var _ log.Entry = (*Entry)(nil)
+var _ log.Emitter = (*Entry)(nil)
 
 func (e *Entry) Async() log.Entry { e.IsAsync = !e.IsAsync; return e }
 
 func (e *Entry) WithField(k string, val interface{}) log.Entry {
-	e.Fields[k] = val
+	e.Fields[k] = e.Logger.Config.Redaction.Redact(k, val)
 	return e
 }
 
@@ -217,7 +377,7 @@ func (e *Entry) WithFields(fields map[string]interface{}) log.Entry {
 }
 
 func (e *Entry) Caller(vals ...int) log.Entry {
-	return e.WithField(log.CallerField, vals)
+	return e.WithField(e.names().Caller, vals)
 }
 
 func (e *Entry) WithError(errs ...error) log.Entry {
@@ -225,9 +385,11 @@ func (e *Entry) WithError(errs ...error) log.Entry {
 		return e
 	}
 	if len(errs) == 1 {
-		return e.WithField("error", errs[0].Error())
+		e.err = errs[0]
+		return e.WithField(e.names().Error, errs[0].Error())
 	}
-	return e.WithField("error", errs)
+	e.err = fmt.Errorf("%v", errs)
+	return e.WithField(e.names().Error, errs)
 }
 
 func (e *Entry) WithBool(k string, vals ...bool) log.Entry {
@@ -290,6 +452,78 @@ func (e *Entry) WithTime(k string, vals ...time.Time) log.Entry {
 	return e.WithField(k, vals)
 }
 
+func (e *Entry) WithFloat(k string, vals ...float64) log.Entry {
+	if len(vals) == 0 {
+		return e
+	}
+	if len(vals) == 1 {
+		return e.WithField(k, vals[0])
+	}
+	return e.WithField(k, vals)
+}
+
+func (e *Entry) WithInt64(k string, vals ...int64) log.Entry {
+	if len(vals) == 0 {
+		return e
+	}
+	if len(vals) == 1 {
+		return e.WithField(k, vals[0])
+	}
+	return e.WithField(k, vals)
+}
+
+func (e *Entry) WithUint64(k string, vals ...uint64) log.Entry {
+	if len(vals) == 0 {
+		return e
+	}
+	if len(vals) == 1 {
+		return e.WithField(k, vals[0])
+	}
+	return e.WithField(k, vals)
+}
+
+func (e *Entry) WithBytes(k string, b []byte) log.Entry {
+	return e.WithField(k, string(b))
+}
+
+func (e *Entry) WithHex(k string, b []byte) log.Entry {
+	return e.WithField(k, hex.EncodeToString(b))
+}
+
+func (e *Entry) WithIP(k string, vals ...net.IP) log.Entry {
+	if len(vals) == 0 {
+		return e
+	}
+	if len(vals) == 1 {
+		return e.WithField(k, vals[0].String())
+	}
+	strs := make([]string, len(vals))
+	for i, ip := range vals {
+		strs[i] = ip.String()
+	}
+	return e.WithField(k, strs)
+}
+
+func (e *Entry) WithStringer(k string, val fmt.Stringer) log.Entry {
+	if val == nil {
+		return e.WithField(k, nil)
+	}
+	return e.WithField(k, val.String())
+}
+
+func (e *Entry) WithJSON(k string, raw []byte) log.Entry {
+	return e.WithField(k, json.RawMessage(raw))
+}
+
+// Nested objects are set as maps, see log.DictFields.
+func (e *Entry) WithDict(k string, fn func(log.Dict)) log.Entry {
+	return e.WithField(k, log.DictFields(fn))
+}
+
+func (e *Entry) WithObject(k string, obj log.ObjectMarshaler) log.Entry {
+	return e.WithField(k, log.ObjectFields(obj))
+}
+
 func (e *Entry) Trace() log.Entry { e.Level = log.TRACE; return e }
 func (e *Entry) Debug() log.Entry { e.Level = log.DEBUG; return e }
 func (e *Entry) Info() log.Entry  { e.Level = log.INFO; return e }
@@ -299,7 +533,7 @@ func (e *Entry) Panic() log.Entry { e.Level = log.PANIC; return e }
 func (e *Entry) Fatal() log.Entry { e.Level = log.FATAL; return e }
 
 func (e *Entry) Msg(msg string) {
-	e.Message = msg
+	e.Message = e.Logger.Config.Redaction.Scrub(msg)
 	if !e.IsAsync {
 		e.Send()
 	}
@@ -312,26 +546,86 @@ func (e *Entry) Msgf(format string, vals ...interface{}) {
 // Send writes a JSON version of the fields with any message and the
 // level.
 func (e *Entry) Send() {
+	byt, ok := e.emit()
+	if !ok {
+		return
+	}
+
+	switch e.Level {
+	case log.FATAL:
+		_ = e.Logger.Flush()
+		e.Logger.ExitFn(1)
+	case log.PANIC:
+		_ = e.Logger.Flush()
+		panic(&testloggerError{Entry: e, msg: string(byt)})
+	}
+}
+
+// Emit writes the entry as Send does, without calling ExitFn or
+// panicking.
+func (e *Entry) Emit() {
+	e.emit()
+}
+
+// Writes the entry, returning what was written and false if it was
+// dropped.
+func (e *Entry) emit() ([]byte, bool) {
+	if !e.Logger.root().sampling.Sample(e.Level) {
+		e.Dropped = true
+		return nil, false
+	}
+	e.Time = e.Logger.root().Clock()
+	if e.Logger.Config.UTC {
+		e.Time = e.Time.UTC()
+	}
+	e.fireHooks()
+
 	fields := e.Fields
-	fields["level"] = StringFromLevel(e.Level)
+	if e.Logger.Config.TimeFormat != "" {
+		if stamp := log.NewTimestamp(e.Logger.Config); stamp != nil {
+			fields[stamp.Key] = stamp.Value(e.Time)
+		}
+	}
+	names := e.names()
+	fields[names.Level] = log.NewLevelNames(e.Logger.Config).Name(e.Level)
 	if e.Message != "" {
-		fields["message"] = e.Message
+		fields[names.Message] = e.Message
 	}
 
 	byt, err := json.Marshal(fields)
 	if err == nil {
-		e.Logger.entriesMutex.Lock()
+		r := e.Logger.root()
+		r.entriesMutex.Lock()
 		_, _ = e.Logger.Config.Output.Write(byt)
-		e.Logger.entriesMutex.Unlock()
+		r.entriesMutex.Unlock()
 		e.Sent = true
 	}
+	return byt, true
+}
 
-	switch e.Level {
-	case log.FATAL:
-		e.Logger.ExitFn(1)
-	case log.PANIC:
-		panic(&testloggerError{Entry: e, msg: string(byt)})
+// Fires the Hooks of the Logger for the entry.
+func (e *Entry) fireHooks() {
+	hooks := e.Logger.Hooks()
+	if !hooks.Enabled(e.Level) {
+		return
 	}
+
+	fields := make(map[string]interface{}, len(e.Fields))
+	for k, val := range e.Fields {
+		fields[k] = val
+	}
+	names := e.names()
+	delete(fields, names.Caller)
+	if e.err != nil {
+		delete(fields, names.Error)
+	}
+	hooks.Fire(log.NewRecord(e.Level, e.Message, fields, e.err, nil, e.Time))
+}
+
+// Returns the names of the core fields, as set by Config.FieldNames and
+// Config.Schema.
+func (e *Entry) names() log.FieldNames {
+	return log.NewFieldNames(e.Logger.Config)
 }
 
 type testloggerError struct {
@@ -403,21 +697,8 @@ func (e *Entry) RequestRemoteAddr() string {
 
 // StringFromLevel is a convenience for printing out log.Levels.
 func StringFromLevel(lvl log.Level) string {
-	switch lvl {
-	case log.TRACE:
-		return "TRACE"
-	case log.DEBUG:
-		return "DEBUG"
-	case log.INFO:
-		return "INFO"
-	case log.WARN:
-		return "WARN"
-	case log.ERROR:
-		return "ERROR"
-	case log.PANIC:
-		return "PANIC"
-	case log.FATAL:
-		return "FATAL"
+	if !lvl.IsValid() {
+		return "UNKN"
 	}
-	return "UNKN"
+	return lvl.String()
 }
@@ -0,0 +1,148 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/secureworks/logger/internal/testutils"
+	"github.com/secureworks/logger/log"
+	_ "github.com/secureworks/logger/logrus"
+	"github.com/secureworks/logger/testlogger"
+	_ "github.com/secureworks/logger/zerolog"
+)
+
+func TestTimestamps(t *testing.T) {
+	render := func(t *testing.T, driver string, config *log.Config) map[string]interface{} {
+		t.Helper()
+
+		out := new(bytes.Buffer)
+		config.Level = log.INFO
+		config.Output = out
+		logger, err := log.Open(driver, config)
+		testutils.AssertNil(t, err)
+		logger.Info().Msg("message")
+
+		fields := make(map[string]interface{})
+		dec := json.NewDecoder(out)
+		dec.UseNumber()
+		testutils.AssertNil(t, dec.Decode(&fields))
+		delete(fields, "message")
+		delete(fields, "msg")
+		return fields
+	}
+
+	t.Run("default", func(t *testing.T) {
+		for _, driver := range []string{"zerolog", "logrus"} {
+			before := time.Now().Truncate(time.Second)
+			fields := render(t, driver, &log.Config{})
+			testutils.AssertEqual(t, 2, len(fields))
+
+			ts, err := time.Parse(time.RFC3339, fields["time"].(string))
+			testutils.AssertNil(t, err)
+			testutils.AssertFalse(t, ts.Before(before))
+		}
+	})
+
+	t.Run("unix formats", func(t *testing.T) {
+		for format, unit := range map[string]time.Duration{
+			log.TimeFormatUnix:      time.Second,
+			log.TimeFormatUnixMs:    time.Millisecond,
+			log.TimeFormatUnixMicro: time.Microsecond,
+			log.TimeFormatUnixNano:  time.Nanosecond,
+		} {
+			for _, driver := range []string{"zerolog", "logrus"} {
+				before := time.Now().UnixNano() / int64(unit)
+				fields := render(t, driver, &log.Config{FieldNames: log.FieldNames{Time: "ts"}, TimeFormat: format})
+				testutils.AssertEqual(t, 2, len(fields))
+
+				n, err := fields["ts"].(json.Number).Int64()
+				testutils.AssertNil(t, err)
+				testutils.AssertTrue(t, n >= before)
+				testutils.AssertTrue(t, n <= time.Now().UnixNano()/int64(unit))
+			}
+		}
+	})
+
+	t.Run("layout in UTC", func(t *testing.T) {
+		for _, driver := range []string{"zerolog", "logrus"} {
+			fields := render(t, driver, &log.Config{TimeFormat: time.RFC3339Nano, UTC: true})
+			str := fields["time"].(string)
+			testutils.AssertTrue(t, strings.HasSuffix(str, "Z"))
+
+			_, err := time.Parse(time.RFC3339Nano, str)
+			testutils.AssertNil(t, err)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		for _, driver := range []string{"zerolog", "logrus"} {
+			fields := render(t, driver, &log.Config{TimeFormat: log.TimeFormatNone})
+			testutils.AssertEqual(t, map[string]interface{}{"level": "info"}, fields)
+		}
+	})
+
+	t.Run("always written", func(t *testing.T) {
+		for _, driver := range []string{"zerolog", "logrus"} {
+			out := new(bytes.Buffer)
+			logger, err := log.Open(driver, &log.Config{
+				Level:      log.INFO,
+				Output:     out,
+				TimeFormat: log.TimeFormatUnix,
+			})
+			testutils.AssertNil(t, err)
+			logger.Info().WithStr("time", "field").Send()
+			testutils.AssertStringContains(t, `"time":1`, out.String())
+		}
+	})
+
+	t.Run("logfmt", func(t *testing.T) {
+		for _, driver := range []string{"zerolog", "logrus"} {
+			out := new(bytes.Buffer)
+			logger, err := log.Open(driver, &log.Config{
+				Level:      log.INFO,
+				Format:     log.LogfmtFormat,
+				Output:     out,
+				FieldNames: log.FieldNames{Time: "ts"},
+				TimeFormat: log.TimeFormatUnix,
+			})
+			testutils.AssertNil(t, err)
+			logger.Info().Msg("message")
+			testutils.AssertStringContains(t, "level=info ts=1", out.String())
+		}
+	})
+}
+
+func TestTestlogger_Clock(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+
+	logger, err := testlogger.New(&log.Config{
+		Level:      log.INFO,
+		TimeFormat: log.TimeFormatUnix,
+		UTC:        true,
+	})
+	testutils.AssertNil(t, err)
+	logger.Clock = func() time.Time { return now }
+
+	logger.WithField("child", "yes").Info().Msg("message")
+
+	entries := logger.GetEntries()
+	testutils.AssertEqual(t, 1, len(entries))
+	testutils.AssertEqual(t, now.UTC(), entries[0].Time)
+	testutils.AssertEqual(t, time.UTC, entries[0].Time.Location())
+	testutils.AssertStringContains(t,
+		`"time":1577952245`,
+		logger.Config.Output.(*bytes.Buffer).String(),
+	)
+
+	// Without a TimeFormat, times are not written.
+	logger, err = testlogger.New(&log.Config{Level: log.INFO})
+	testutils.AssertNil(t, err)
+	logger.Clock = func() time.Time { return now }
+	logger.Info().Msg("message")
+
+	testutils.AssertEqual(t, now, logger.GetEntries()[0].Time)
+	testutils.AssertFalse(t, strings.Contains(logger.Config.Output.(*bytes.Buffer).String(), "time"))
+}
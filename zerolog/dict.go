@@ -0,0 +1,171 @@
+package zerolog
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/secureworks/logger/log"
+)
+
+// dict implements log.Dict by encoding fields into a Zerolog event, so
+// nested objects are written without reflection. Fields are redacted
+// by their path, as log.RedactionPolicy redacts nested maps.
+type dict struct {
+	ev     *zerolog.Event
+	redact *log.RedactionPolicy
+	path   string                 // Of the object, eg: "http.request".
+	fields map[string]interface{} // Set if the fields are kept, see entry.keep.
+}
+
+var _ log.Dict = (*dict)(nil)
+
+// Returns a dict for the nested object key, keeping its fields if the
+// entry keeps key.
+func (e *entry) dict(key string) *dict {
+	d := &dict{redact: e.redact, path: key}
+	if e.keepAll || e.dedup.IsKeyField(key) {
+		d.fields = make(map[string]interface{})
+	}
+	return d
+}
+
+func (d *dict) Str(key, val string) log.Dict {
+	if d.redacted(key) {
+		return d
+	}
+	val = d.redact.Scrub(val)
+	d.ev.Str(key, val)
+	d.keep(key, val)
+	return d
+}
+
+func (d *dict) Bool(key string, val bool) log.Dict {
+	if !d.redacted(key) {
+		d.ev.Bool(key, val)
+		d.keep(key, val)
+	}
+	return d
+}
+
+func (d *dict) Int(key string, val int) log.Dict {
+	if !d.redacted(key) {
+		d.ev.Int(key, val)
+		d.keep(key, val)
+	}
+	return d
+}
+
+func (d *dict) Int64(key string, val int64) log.Dict {
+	if !d.redacted(key) {
+		d.ev.Int64(key, val)
+		d.keep(key, val)
+	}
+	return d
+}
+
+func (d *dict) Uint64(key string, val uint64) log.Dict {
+	if !d.redacted(key) {
+		d.ev.Uint64(key, val)
+		d.keep(key, val)
+	}
+	return d
+}
+
+func (d *dict) Float(key string, val float64) log.Dict {
+	if !d.redacted(key) {
+		d.ev.Float64(key, val)
+		d.keep(key, val)
+	}
+	return d
+}
+
+func (d *dict) Dur(key string, val time.Duration) log.Dict {
+	if !d.redacted(key) {
+		d.ev.Dur(key, val)
+		d.keep(key, val)
+	}
+	return d
+}
+
+func (d *dict) Time(key string, val time.Time) log.Dict {
+	if !d.redacted(key) {
+		d.ev.Time(key, val)
+		d.keep(key, val)
+	}
+	return d
+}
+
+func (d *dict) Field(key string, val interface{}) log.Dict {
+	val = d.redact.Redact(d.path+"."+key, val)
+	d.ev.Interface(key, val)
+	d.keep(key, val)
+	return d
+}
+
+func (d *dict) Dict(key string, fn func(log.Dict)) log.Dict {
+	if d.redacted(key) {
+		return d
+	}
+	sub := d.sub(key)
+	sub.ev = zerolog.Dict()
+	if fn != nil {
+		fn(sub)
+	}
+	d.ev.Dict(key, sub.ev)
+	d.keep(key, sub.fields)
+	return d
+}
+
+func (d *dict) Object(key string, obj log.ObjectMarshaler) log.Dict {
+	if d.redacted(key) {
+		return d
+	}
+	if obj == nil {
+		d.ev.Interface(key, nil)
+		d.keep(key, nil)
+		return d
+	}
+	sub := d.sub(key)
+	d.ev.Object(key, objectMarshaler{obj: obj, dict: sub})
+	d.keep(key, sub.fields)
+	return d
+}
+
+// Returns a dict for the nested object key.
+func (d *dict) sub(key string) *dict {
+	sub := &dict{redact: d.redact, path: d.path + "." + key}
+	if d.fields != nil {
+		sub.fields = make(map[string]interface{})
+	}
+	return sub
+}
+
+// Writes the field key as log.Redacted if the RedactionPolicy denies
+// it, and reports whether it did.
+func (d *dict) redacted(key string) bool {
+	if !d.redact.IsDenied(d.path + "." + key) {
+		return false
+	}
+	d.ev.Str(key, log.Redacted)
+	d.keep(key, log.Redacted)
+	return true
+}
+
+func (d *dict) keep(key string, val interface{}) {
+	if d.fields != nil {
+		d.fields[key] = val
+	}
+}
+
+// objectMarshaler adapts a log.ObjectMarshaler to Zerolog's, encoding
+// the fields of the object into the event Zerolog passes it.
+type objectMarshaler struct {
+	obj  log.ObjectMarshaler
+	dict *dict
+}
+
+func (m objectMarshaler) MarshalZerologObject(ev *zerolog.Event) {
+	m.dict.ev = ev
+	m.obj.MarshalLogObject(m.dict)
+}
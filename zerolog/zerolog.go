@@ -4,15 +4,20 @@
 package zerolog
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 
 	"github.com/secureworks/logger/internal/common"
+	"github.com/secureworks/logger/internal/format"
 	"github.com/secureworks/logger/log"
 )
 
@@ -41,16 +46,55 @@ func init() {
 // the given configuration and Zerolog options.
 func newLogger(config *log.Config, opts ...log.Option) (log.Logger, error) {
 	zlvl := lvlToZerolog(config.Level)
+	names := log.NewFieldNames(config)
 	logger := &logger{
-		errStack: config.EnableErrStack,
-		lvl:      zlvl,
+		errStack:  config.EnableErrStack,
+		names:     &names,
+		levels:    log.NewLevelNames(config),
+		schema:    config.Schema,
+		lvl:       log.NewAtomicLevel(config.Level),
+		overrides: config.LevelOverrides,
+		sampling:  log.NewSampling(config.Sampler),
+		redact:    config.Redaction,
+		hooks:     new(log.Hooks),
+		stamp:     log.NewTimestamp(config),
 	}
+	logger.dedup = log.NewDeduper(config.Dedup, logger.writeDedupSummary)
 
 	output := config.Output
 	if output == nil {
 		output = os.Stderr
 	}
+	// Detect terminals before output is wrapped by an AsyncWriter.
+	terminal := format.IsTerminal(output)
+	if config.Async != nil {
+		logger.async = log.NewAsyncWriter(output, config.Async)
+		output = logger.async
+	}
+	logger.flusher, _ = output.(log.Flusher)
+
+	var encode format.Encoder
+	switch config.Format {
+	case log.LogfmtFormat:
+		encode = format.AppendLogfmt
+	case log.ConsoleFormat:
+		encode = format.ConsoleEncoder(terminal)
+	}
 
+	if encode != nil {
+		// Zerolog only writes JSON, so convert each line as it is written.
+		w := &format.Writer{
+			Out:        output,
+			Encode:     encode,
+			LevelKey:   names.Level,
+			MessageKey: names.Message,
+			StackKey:   names.Stack,
+		}
+		if logger.stamp != nil {
+			w.TimeKey = logger.stamp.Key
+		}
+		output = w
+	}
 	zlog := zerolog.New(output).Level(zlvl)
 	logger.lg = &zlog
 
@@ -60,22 +104,47 @@ func newLogger(config *log.Config, opts ...log.Option) (log.Logger, error) {
 			return nil, err
 		}
 	}
+
+	// Bind the static fields as With does, so that fields with the same
+	// keys replace them.
+	if len(config.StaticFields) > 0 {
+		logger.bind(config.Schema.Fields(config.StaticFields))
+	}
 	return logger, nil
 }
 
 // Logger implementation.
 
 type logger struct {
-	lg       *zerolog.Logger
-	lvl      zerolog.Level
-	errStack bool
+	lg        *zerolog.Logger
+	lvl       *log.AtomicLevel
+	name      string
+	overrides log.LevelOverrides
+	sampling  *log.Sampling
+	dedup     *log.Deduper
+	redact    *log.RedactionPolicy
+	hooks     *log.Hooks
+	fields    map[string]interface{} // Fields bound using With, see emit.
+	stamp     *log.Timestamp         // Nil if times are not written.
+	async     *log.AsyncWriter       // Set if created for Config.Async.
+	flusher   log.Flusher            // Set if the output is a log.Flusher.
+	names     *log.FieldNames
+	levels    *log.LevelNames
+	schema    *log.Schema
+	errStack  bool
 }
 
 var _ log.Logger = (*logger)(nil)
 var _ log.UnderlyingLogger = (*logger)(nil)
+var _ log.LevelController = (*logger)(nil)
+var _ log.DroppedCounter = (*logger)(nil)
+var _ log.HookLogger = (*logger)(nil)
+var _ log.Flusher = (*logger)(nil)
+var _ log.SchemaLogger = (*logger)(nil)
+var _ io.Closer = (*logger)(nil)
 
 func (l *logger) IsLevelEnabled(lvl log.Level) bool {
-	return lvlToZerolog(lvl) <= l.lvl
+	return lvlToZerolog(lvl) >= lvlToZerolog(l.lvl.Level())
 }
 
 func (l *logger) WithError(err error) log.Entry {
@@ -90,6 +159,46 @@ func (l *logger) WithFields(fields map[string]interface{}) log.Entry {
 	return l.Entry(0).WithFields(fields)
 }
 
+// With returns a child logger with the fields bound to it. They are
+// written by each entry, after its own fields, rather than bound to a
+// zerolog.Context, so that fields set on the entry replace them instead
+// of being written twice.
+func (l *logger) With(fields map[string]interface{}) log.Logger {
+	if l.notValid() || len(fields) == 0 {
+		return l
+	}
+
+	child := *l
+	child.fields = make(map[string]interface{}, len(l.fields)+len(fields))
+	for key, val := range l.fields {
+		child.fields[key] = val
+	}
+	for key, val := range l.redact.RedactFields(fields) {
+		child.fields[key] = val
+	}
+	return &child
+}
+
+// Binds fields to the receiver itself, rather than to a child as With
+// does, so that they are also written by entries such as dedup
+// summaries that the receiver logs.
+func (l *logger) bind(fields map[string]interface{}) {
+	*l = *l.With(fields).(*logger)
+}
+
+func (l *logger) Named(name string) log.Logger {
+	if l.notValid() {
+		return l
+	}
+
+	child := *l
+	child.name = log.JoinName(l.name, name)
+	if lvl, inherit := l.overrides.NamedLevel(l.name, child.name); !inherit {
+		child.lvl = log.NewAtomicLevel(lvl)
+	}
+	return &child
+}
+
 func (l *logger) Entry(lvl log.Level) log.Entry {
 	return l.newEntry(lvlToZerolog(lvl))
 }
@@ -106,6 +215,65 @@ func (l *logger) WriteCloser(lvl log.Level) io.WriteCloser {
 	return writeLevelCloser{log: l, lvl: lvl}
 }
 
+// LevelController implementation.
+
+// SetLevel changes the level of the logger, its children and any of
+// their entries that have not been sent yet. The level of the
+// underlying zerolog.Logger is not changed: entries are written with
+// zerolog.NoLevel and filtered by this driver.
+func (l *logger) SetLevel(lvl log.Level) {
+	l.lvl.SetLevel(lvl)
+}
+
+func (l *logger) Level() log.Level {
+	return l.lvl.Level()
+}
+
+// DroppedCounter implementation.
+
+// Dropped returns the number of entries dropped by Config.Sampler, and
+// by the log.AsyncWriter used for Config.Async.
+func (l *logger) Dropped() uint64 {
+	return l.sampling.Dropped() + l.async.Dropped()
+}
+
+// Flusher and io.Closer implementation.
+
+// Flush writes the pending dedup summaries, and flushes the output if
+// it is a log.Flusher, such as the log.AsyncWriter used for
+// Config.Async.
+func (l *logger) Flush() error {
+	l.dedup.Flush()
+	if l.flusher == nil {
+		return nil
+	}
+	return l.flusher.Flush()
+}
+
+// Close writes the pending dedup summaries, flushes the output and
+// closes the log.AsyncWriter used for Config.Async, which is shared
+// with the parent and children of the logger. Other outputs are not
+// closed.
+func (l *logger) Close() error {
+	if l.async != nil {
+		l.dedup.Flush()
+		return l.async.Close()
+	}
+	return l.Flush()
+}
+
+// HookLogger implementation.
+
+func (l *logger) Hooks() *log.Hooks {
+	return l.hooks
+}
+
+// SchemaLogger implementation.
+
+func (l *logger) Schema() *log.Schema {
+	return l.schema
+}
+
 // UnderlyingLogger implementation.
 
 func (l *logger) GetLogger() interface{} {
@@ -148,18 +316,40 @@ func (l *logger) newEntry(lvl zerolog.Level) log.Entry {
 	//
 	// See: https://github.com/rs/zerolog/issues/408
 	ent := l.lg.WithLevel(zerolog.NoLevel)
-	if l.errStack {
-		ent = ent.Stack()
-	}
 
 	return &entry{
-		ent:    ent,
-		caller: make([]string, 0, 1),
-		loglvl: l.lvl,
-		lvl:    lvl,
+		ent:      ent,
+		caller:   make([]string, 0, 1),
+		name:     l.name,
+		loglvl:   l.lvl,
+		sampling: l.sampling,
+		dedup:    l.dedup,
+		redact:   l.redact,
+		hooks:    l.hooks,
+		flusher:  l.flusher,
+		keepAll:  l.hooks.Len() > 0,
+		bound:    l.fields,
+		stamp:    l.stamp,
+		names:    l.names,
+		levels:   l.levels,
+		errStack: l.errStack,
+		lvl:      lvl,
 	}
 }
 
+// Writes the summary of entries suppressed by l.dedup.
+func (l *logger) writeDedupSummary(s log.DedupSummary) {
+	e, _ := l.newEntry(lvlToZerolog(s.Level)).(*entry)
+	if e.notValid() {
+		return
+	}
+	e.summary = true
+	e.WithFields(s.Fields).
+		WithStr(log.SuppressedMessage, s.Message).
+		WithInt(log.SuppressedCount, s.Count).
+		Msg(s.Msg())
+}
+
 func (l *logger) notValid() bool {
 	return l == nil || l.lg == nil
 }
@@ -186,6 +376,28 @@ func lvlToZerolog(lvl log.Level) zerolog.Level {
 	}
 }
 
+// Map internal Zerolog log levels to log.Level.
+func lvlFromZerolog(lvl zerolog.Level) log.Level {
+	switch lvl {
+	case zerolog.TraceLevel:
+		return log.TRACE
+	case zerolog.DebugLevel:
+		return log.DEBUG
+	case zerolog.InfoLevel:
+		return log.INFO
+	case zerolog.WarnLevel:
+		return log.WARN
+	case zerolog.ErrorLevel:
+		return log.ERROR
+	case zerolog.PanicLevel:
+		return log.PANIC
+	case zerolog.FatalLevel:
+		return log.FATAL
+	default:
+		return log.INFO
+	}
+}
+
 // WriteCloser hook implementation.
 
 type writeLevelCloser struct {
@@ -212,16 +424,33 @@ func (wlc writeLevelCloser) Close() error {
 // Entry implementation.
 
 type entry struct {
-	ent    *zerolog.Event
-	caller []string
-	msg    string
-	async  bool
-	loglvl zerolog.Level
-	lvl    zerolog.Level
+	ent      *zerolog.Event
+	caller   []string
+	msg      string
+	async    bool
+	name     string // Written at Send, as zerolog.Context can't replace fields.
+	loglvl   *log.AtomicLevel
+	sampling *log.Sampling
+	dedup    *log.Deduper
+	redact   *log.RedactionPolicy
+	hooks    *log.Hooks
+	flusher  log.Flusher            // Flushed before panicking or exiting.
+	keepAll  bool                   // Set if there are Hooks, see keep.
+	bound    map[string]interface{} // Fields bound to the logger, see emit.
+	shadowed []string               // Keys of bound set on the entry, see keep.
+	keys     map[string]interface{} // Fields of the entry, see keep.
+	stamp    *log.Timestamp         // Nil if times are not written.
+	err      error                  // Set using WithError, for Hooks.
+	summary  bool                   // Set on dedup summaries.
+	names    *log.FieldNames
+	levels   *log.LevelNames
+	errStack bool
+	lvl      zerolog.Level
 }
 
 var _ log.Entry = (*entry)(nil)
 var _ log.UnderlyingLogger = (*entry)(nil)
+var _ log.Emitter = (*entry)(nil)
 
 func (e *entry) Async() log.Entry {
 	if e.notValid() {
@@ -261,10 +490,37 @@ func (e *entry) WithError(errs ...error) log.Entry {
 		return e
 	}
 
+	// Zerolog's Err writes to the fields named by its package vars,
+	// so the error and its stack trace are written here.
 	if le == 1 {
-		e.ent = e.ent.Err(errs[0])
+		err := errs[0]
+		if e.errStack && err != nil {
+			st, _ := common.WithStackTrace(err, 3)
+			e.ent = e.ent.Interface(e.names.Stack, st.StackTrace())
+			e.shadow(e.names.Stack)
+		}
+		if err != nil {
+			e.shadow(e.names.Error)
+		}
+		// The RedactionPolicy returns the scrubbed text of errors with
+		// secrets.
+		if str, ok := e.redact.Redact(e.names.Error, err).(string); ok {
+			e.ent = e.ent.Str(e.names.Error, str)
+		} else {
+			e.ent = e.ent.AnErr(e.names.Error, err)
+		}
+		e.err = err
 	} else {
-		e.ent = e.ent.Errs(zerolog.ErrorFieldName, errs)
+		if e.redact == nil {
+			e.ent = e.ent.Errs(e.names.Error, errs)
+		} else {
+			e.ent = e.ent.Interface(e.names.Error, e.redactErrs(errs))
+		}
+		e.err = multiError{errs}
+		e.shadow(e.names.Error)
+	}
+	if e.dedup.IsKeyField(e.names.Error) {
+		e.keep(e.names.Error, e.err)
 	}
 	return e
 }
@@ -273,7 +529,9 @@ func (e *entry) WithField(key string, val interface{}) log.Entry {
 	if e.notValid() {
 		return e
 	}
+	val = e.redact.Redact(key, val)
 	e.ent = e.ent.Interface(key, val)
+	e.keep(key, val)
 	return e
 }
 
@@ -281,90 +539,263 @@ func (e *entry) WithFields(fields map[string]interface{}) log.Entry {
 	if e.notValid() || len(fields) == 0 {
 		return e
 	}
+	fields = e.redact.RedactFields(fields)
 	e.ent = e.ent.Fields(fields)
+	for key, val := range fields {
+		e.keep(key, val)
+	}
 	return e
 }
 
 func (e *entry) WithBool(key string, bls ...bool) log.Entry {
 	lb := len(bls)
-	if e.notValid() || lb == 0 {
+	if e.notValid() || lb == 0 || e.redacted(key) {
 		return e
 	}
 
 	if lb == 1 {
 		e.ent = e.ent.Bool(key, bls[0])
+		e.keep(key, bls[0])
 	} else {
 		e.ent = e.ent.Bools(key, bls)
+		e.keep(key, bls)
 	}
 	return e
 }
 
 func (e *entry) WithDur(key string, durs ...time.Duration) log.Entry {
 	ld := len(durs)
-	if e.notValid() || ld == 0 {
+	if e.notValid() || ld == 0 || e.redacted(key) {
 		return e
 	}
 
 	if ld == 1 {
 		e.ent = e.ent.Dur(key, durs[0])
+		e.keep(key, durs[0])
 	} else {
 		e.ent = e.ent.Durs(key, durs)
+		e.keep(key, durs)
 	}
 	return e
 }
 
 func (e *entry) WithInt(key string, is ...int) log.Entry {
 	li := len(is)
-	if e.notValid() || li == 0 {
+	if e.notValid() || li == 0 || e.redacted(key) {
 		return e
 	}
 
 	if li == 1 {
 		e.ent = e.ent.Int(key, is[0])
+		e.keep(key, is[0])
 	} else {
 		e.ent = e.ent.Ints(key, is)
+		e.keep(key, is)
 	}
 	return e
 }
 
 func (e *entry) WithUint(key string, us ...uint) log.Entry {
 	lu := len(us)
-	if e.notValid() || lu == 0 {
+	if e.notValid() || lu == 0 || e.redacted(key) {
 		return e
 	}
 
 	if lu == 1 {
 		e.ent = e.ent.Uint(key, us[0])
+		e.keep(key, us[0])
 	} else {
 		e.ent = e.ent.Uints(key, us)
+		e.keep(key, us)
 	}
 	return e
 }
 
 func (e *entry) WithStr(key string, strs ...string) log.Entry {
 	ls := len(strs)
-	if e.notValid() || ls == 0 {
+	if e.notValid() || ls == 0 || e.redacted(key) {
 		return e
 	}
+	if e.redact != nil {
+		strs = e.redact.Redact(key, strs).([]string)
+	}
 
 	if ls == 1 {
 		e.ent = e.ent.Str(key, strs[0])
+		e.keep(key, strs[0])
 	} else {
 		e.ent = e.ent.Strs(key, strs)
+		e.keep(key, strs)
 	}
 	return e
 }
 
 func (e *entry) WithTime(key string, ts ...time.Time) log.Entry {
 	lt := len(ts)
-	if e.notValid() || lt == 0 {
+	if e.notValid() || lt == 0 || e.redacted(key) {
 		return e
 	}
 
 	if lt == 1 {
 		e.ent = e.ent.Time(key, ts[0])
+		e.keep(key, ts[0])
 	} else {
 		e.ent = e.ent.Times(key, ts)
+		e.keep(key, ts)
+	}
+	return e
+}
+
+func (e *entry) WithFloat(key string, fs ...float64) log.Entry {
+	lf := len(fs)
+	if e.notValid() || lf == 0 || e.redacted(key) {
+		return e
+	}
+
+	if lf == 1 {
+		e.ent = e.ent.Float64(key, fs[0])
+		e.keep(key, fs[0])
+	} else {
+		e.ent = e.ent.Floats64(key, fs)
+		e.keep(key, fs)
+	}
+	return e
+}
+
+func (e *entry) WithInt64(key string, is ...int64) log.Entry {
+	li := len(is)
+	if e.notValid() || li == 0 || e.redacted(key) {
+		return e
+	}
+
+	if li == 1 {
+		e.ent = e.ent.Int64(key, is[0])
+		e.keep(key, is[0])
+	} else {
+		e.ent = e.ent.Ints64(key, is)
+		e.keep(key, is)
+	}
+	return e
+}
+
+func (e *entry) WithUint64(key string, us ...uint64) log.Entry {
+	lu := len(us)
+	if e.notValid() || lu == 0 || e.redacted(key) {
+		return e
+	}
+
+	if lu == 1 {
+		e.ent = e.ent.Uint64(key, us[0])
+		e.keep(key, us[0])
+	} else {
+		e.ent = e.ent.Uints64(key, us)
+		e.keep(key, us)
+	}
+	return e
+}
+
+// Values that may hold secrets are written using WithField when there
+// is a RedactionPolicy, so that they are redacted as other fields are.
+
+func (e *entry) WithBytes(key string, b []byte) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	if e.redact != nil {
+		return e.WithField(key, string(b))
+	}
+	e.ent = e.ent.Bytes(key, b)
+	e.keep(key, string(b))
+	return e
+}
+
+func (e *entry) WithHex(key string, b []byte) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	if e.redact != nil {
+		return e.WithField(key, hex.EncodeToString(b))
+	}
+	e.ent = e.ent.Hex(key, b)
+	e.keep(key, hex.EncodeToString(b))
+	return e
+}
+
+func (e *entry) WithIP(key string, ips ...net.IP) log.Entry {
+	li := len(ips)
+	if e.notValid() || li == 0 || e.redacted(key) {
+		return e
+	}
+
+	val := common.IPValue(ips)
+	if li == 1 {
+		e.ent = e.ent.IPAddr(key, ips[0])
+	} else {
+		e.ent = e.ent.Strs(key, val.([]string))
+	}
+	e.keep(key, val)
+	return e
+}
+
+func (e *entry) WithStringer(key string, val fmt.Stringer) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	if e.redact != nil {
+		return e.WithField(key, common.StringerValue(val))
+	}
+	e.ent = e.ent.Stringer(key, val)
+	e.keep(key, common.StringerValue(val))
+	return e
+}
+
+// The RedactionPolicy decodes raw JSON, and re-encodes it if it has
+// anything to redact.
+func (e *entry) WithJSON(key string, raw []byte) log.Entry {
+	if e.notValid() {
+		return e
+	}
+	if e.redact != nil {
+		return e.WithField(key, json.RawMessage(raw))
+	}
+	e.ent = e.ent.RawJSON(key, raw)
+	e.keep(key, json.RawMessage(raw))
+	return e
+}
+
+func (e *entry) WithDict(key string, fn func(log.Dict)) log.Entry {
+	if e.notValid() || e.redacted(key) {
+		return e
+	}
+	d := e.dict(key)
+	d.ev = zerolog.Dict()
+	if fn != nil {
+		fn(d)
+	}
+	e.ent = e.ent.Dict(key, d.ev)
+	if d.fields != nil {
+		e.keep(key, d.fields)
+	} else {
+		e.shadow(key)
+	}
+	return e
+}
+
+func (e *entry) WithObject(key string, obj log.ObjectMarshaler) log.Entry {
+	if e.notValid() || e.redacted(key) {
+		return e
+	}
+	if obj == nil {
+		e.ent = e.ent.Interface(key, nil)
+		e.keep(key, nil)
+		return e
+	}
+	d := e.dict(key)
+	e.ent = e.ent.Object(key, objectMarshaler{obj: obj, dict: d})
+	if d.fields != nil {
+		e.keep(key, d.fields)
+	} else {
+		e.shadow(key)
 	}
 	return e
 }
@@ -386,47 +817,88 @@ func (e *entry) Msg(msg string) {
 		return
 	}
 
-	e.msg = msg
+	e.msg = e.redact.Scrub(msg)
 	if !e.async {
 		e.Send()
 	}
 }
 
 func (e *entry) Send() {
-	if !e.enabled() {
+	if !e.emit() {
+		return
+	}
+
+	// These are called by e.done here:
+	//   - https://github.com/rs/zerolog/blob/791ca15d999a97768ffd3b040116f9f5a772661a/event.go
+	//
+	// They are disabled however by our use of 'NoLevel', so we retain the
+	// functions here.
+	//
+	switch e.lvl {
+	case zerolog.PanicLevel:
+		e.flush()
+		panic(e.msg)
+	case zerolog.FatalLevel:
+		e.flush()
+		os.Exit(1)
+	}
+}
+
+func (e *entry) Emit() {
+	e.emit()
+}
+
+// Writes the entry, reporting whether it was written.
+func (e *entry) emit() bool {
+	if !e.enabled() || !e.allowed() {
 		// If we cut out early && the entry is valid, recycle it.
 		if !e.notValid() {
 			putEvent(e.ent)
 			e.ent = nil
 		}
 
-		return
+		return false
 	}
 
 	// Nil out zerolog.Entry as we're done with it. Mostly helps gc and
 	// disables future method calls on this type.
 	defer func() { e.ent = nil }()
 
+	e.fireHooks()
+
+	if len(e.bound) > 0 {
+		e.ent = e.ent.Fields(e.boundFields())
+	}
 	if len(e.caller) > 0 {
-		e.ent = e.ent.Strs(log.CallerField, e.caller)
+		e.ent = e.ent.Strs(e.names.Caller, e.caller)
 	}
-	e.ent = e.ent.Str(zerolog.LevelFieldName, zerolog.LevelFieldMarshalFunc(e.lvl))
-
+	if e.name != "" {
+		e.ent = e.ent.Str(log.LoggerNameField, e.name)
+	}
+	if e.stamp != nil {
+		switch t := e.stamp.Value(time.Now()).(type) {
+		case int64:
+			e.ent = e.ent.Int64(e.stamp.Key, t)
+		case string:
+			e.ent = e.ent.Str(e.stamp.Key, t)
+		}
+	}
+	e.ent = e.ent.Str(e.names.Level, e.levels.Name(lvlFromZerolog(e.lvl)))
 	changeEventLevel(e.ent, e.lvl) // Change the level if we can, before calling Msg.
-	e.ent.Msg(e.msg)               // Recycles the zerolog.Entry for us (do not call putEvent again).
 
-	// These are called by e.done here:
-	//   - https://github.com/rs/zerolog/blob/791ca15d999a97768ffd3b040116f9f5a772661a/event.go
-	//
-	// They are disabled however by our use of 'NoLevel', so we retain the
-	// functions here.
-	//
-	switch e.lvl {
-	case zerolog.PanicLevel:
-		panic(e.msg)
-	case zerolog.FatalLevel:
-		os.Exit(1)
+	// Msg writes the message to the field named by Zerolog's package
+	// var, after running Zerolog hooks with it, so it is only used if
+	// that is the name wanted. Both recycle the zerolog.Entry for us (do
+	// not call putEvent again).
+	if e.names.Message == zerolog.MessageFieldName {
+		e.ent.Msg(e.msg)
+		return true
+	}
+	if e.msg != "" {
+		e.ent = e.ent.Str(e.names.Message, e.msg)
 	}
+	e.ent.Send()
+	return true
 }
 
 // UnderlyingLogger implementation.
@@ -470,7 +942,116 @@ func (e *entry) notValid() bool {
 }
 
 func (e *entry) enabled() bool {
-	return !e.notValid() && e.lvl >= e.loglvl
+	return !e.notValid() && e.lvl >= lvlToZerolog(e.loglvl.Level())
+}
+
+// Applies deduplication and sampling to an enabled entry.
+func (e *entry) allowed() bool {
+	lvl := lvlFromZerolog(e.lvl)
+	if e.dedup != nil && !e.summary {
+		fields := make(map[string]interface{}, len(e.bound)+len(e.keys)+1)
+		for k, v := range e.bound {
+			fields[k] = v
+		}
+		for k, v := range e.keys {
+			fields[k] = v
+		}
+		if e.name != "" {
+			fields[log.LoggerNameField] = e.name
+		}
+		if !e.dedup.Allow(lvl, e.msg, fields) {
+			return false
+		}
+	}
+	return e.sampling.Sample(lvl)
+}
+
+// Fires the Hooks for the entry with the fields kept by keep.
+func (e *entry) fireHooks() {
+	lvl := lvlFromZerolog(e.lvl)
+	if !e.hooks.Enabled(lvl) {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(e.bound)+len(e.keys)+1)
+	for k, v := range e.bound {
+		fields[k] = v
+	}
+	for k, v := range e.keys {
+		fields[k] = v
+	}
+	if e.name != "" {
+		fields[log.LoggerNameField] = e.name
+	}
+	if e.err != nil {
+		delete(fields, e.names.Error) // Kept for dedup.
+	}
+	e.hooks.Fire(log.NewRecord(lvl, e.msg, fields, e.err, e.caller, time.Now()))
+}
+
+// Writes the field key as redacted if the redaction policy denies it.
+func (e *entry) redacted(key string) bool {
+	if !e.redact.IsDenied(key) {
+		return false
+	}
+	e.ent = e.ent.Str(key, log.Redacted)
+	e.keep(key, log.Redacted)
+	return true
+}
+
+// Returns the messages of errs, redacted, as WithError writes them.
+func (e *entry) redactErrs(errs []error) []interface{} {
+	vals := make([]interface{}, len(errs))
+	for i, err := range errs {
+		if err != nil {
+			vals[i] = e.redact.Redact(e.names.Error, err.Error())
+		}
+	}
+	return vals
+}
+
+// Returns the bound fields that are not set on the entry.
+func (e *entry) boundFields() map[string]interface{} {
+	if len(e.shadowed) == 0 {
+		return e.bound
+	}
+	fields := make(map[string]interface{}, len(e.bound))
+	for key, val := range e.bound {
+		fields[key] = val
+	}
+	for _, key := range e.shadowed {
+		delete(fields, key)
+	}
+	return fields
+}
+
+// Keeps the values of fields for Hooks and to identify duplicate
+// entries, as they can't be read back from the zerolog.Event, and
+// records the bound fields they replace. Each method setting a field
+// calls it, or shadow if it does not keep the value.
+func (e *entry) keep(key string, val interface{}) {
+	e.shadow(key)
+	if !e.keepAll && !e.dedup.IsKeyField(key) {
+		return
+	}
+	if e.keys == nil {
+		e.keys = make(map[string]interface{}, 1)
+	}
+	e.keys[key] = val
+}
+
+// Records that the entry sets key, if it is bound, so that the bound
+// value is not written too.
+func (e *entry) shadow(key string) {
+	if _, ok := e.bound[key]; ok {
+		e.shadowed = append(e.shadowed, key)
+	}
+}
+
+func (e *entry) flush() {
+	if e.flusher != nil {
+		_ = e.flusher.Flush()
+	}
 }
 
 func (e *entry) setLevel(lvl zerolog.Level) log.Entry {
@@ -480,3 +1061,20 @@ func (e *entry) setLevel(lvl zerolog.Level) log.Entry {
 	e.lvl = lvl
 	return e
 }
+
+// Multi-error utility implementation, for the Record of an entry with
+// several errors.
+type multiError struct {
+	errs []error
+}
+
+func (me multiError) Error() string {
+	sb := new(strings.Builder)
+	sb.Grow(len(me.errs) * 32)
+
+	for _, e := range me.errs {
+		fmt.Fprintf(sb, "%v\n", e)
+	}
+
+	return sb.String()
+}
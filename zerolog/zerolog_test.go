@@ -117,3 +117,41 @@ func TestZerolog_Errors(t *testing.T) {
 	// Nil error stack trace.
 	testutils.AssertNotPanics(t, func() { logger.WithError(nil).Msg("done") })
 }
+
+func TestZerolog_Driver(t *testing.T) {
+	testutils.RunDriverTests(t, "zerolog")
+}
+
+type testObject struct {
+	ID    string
+	Count int
+}
+
+func (o testObject) MarshalLogObject(d log.Dict) {
+	d.Str("id", o.ID).Int("count", o.Count)
+}
+
+func TestZerolog_WithDict(t *testing.T) {
+	config, out := testutils.NewConfigWithBuffer(t, log.INFO)
+	config.TimeFormat = log.TimeFormatNone
+	config.Redaction = log.DefaultRedactionPolicy()
+	logger, err := log.Open("zerolog", config)
+	testutils.AssertNil(t, err)
+
+	logger.Info().
+		WithDict("http", func(d log.Dict) {
+			d.Str("method", "GET").
+				Int("status", 200).
+				Dict("headers", func(d log.Dict) { d.Str("authorization", "Bearer abc") }).
+				Object("obj", testObject{ID: "a", Count: 1})
+		}).
+		WithObject("obj", testObject{ID: "b", Count: 2}).
+		Msg(testMessage)
+
+	// Fields are written in order, as Zerolog encodes them itself.
+	testutils.AssertEqual(t,
+		`{"http":{"method":"GET","status":200,"headers":{"authorization":"[REDACTED]"},"obj":{"id":"a","count":1}},`+
+			`"obj":{"id":"b","count":2},"level":"info","message":"`+testMessage+`"}`+"\n",
+		out.String(),
+	)
+}